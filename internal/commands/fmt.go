@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"github.com/cirruslabs/cirrus-cli/pkg/yamlhelper"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var (
+	ErrFmt               = errors.New("failed to format the configuration")
+	ErrMultipleDocuments = errors.New("configuration files with multiple YAML documents are not supported")
+)
+
+var fmtFile string
+var fmtCheck bool
+var fmtToStarlark bool
+
+func format(cmd *cobra.Command, args []string) error {
+	// https://github.com/spf13/cobra/issues/340#issuecomment-374617413
+	cmd.SilenceUsage = true
+
+	source, err := ioutil.ReadFile(fmtFile)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFmt, err)
+	}
+
+	if fmtToStarlark {
+		starlarkSource, err := larker.ConvertYAML(source)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrFmt, err)
+		}
+
+		_, err = fmt.Fprint(cmd.OutOrStdout(), starlarkSource)
+
+		return err
+	}
+
+	formatted, err := formatYAML(source)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFmt, err)
+	}
+
+	if bytes.Equal(source, formatted) {
+		return nil
+	}
+
+	if fmtCheck {
+		return fmt.Errorf("%w: %s is not formatted, run \"cirrus fmt -f %s\" to fix this",
+			ErrFmt, fmtFile, fmtFile)
+	}
+
+	fileInfo, err := os.Stat(fmtFile)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFmt, err)
+	}
+
+	if err := ioutil.WriteFile(fmtFile, formatted, fileInfo.Mode().Perm()); err != nil {
+		return fmt.Errorf("%w: %v", ErrFmt, err)
+	}
+
+	return nil
+}
+
+// formatYAML re-indents the YAML configuration while keeping the comments, anchors and aliases intact.
+func formatYAML(source []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(source))
+
+	var document yaml.Node
+
+	if err := decoder.Decode(&document); err != nil {
+		if errors.Is(err, io.EOF) {
+			return source, nil
+		}
+
+		return nil, err
+	}
+
+	if err := decoder.Decode(&yaml.Node{}); !errors.Is(err, io.EOF) {
+		return nil, ErrMultipleDocuments
+	}
+
+	clearMergeTags(&document)
+
+	formatted, err := yamlhelper.PrettyPrint(&document)
+	if err != nil {
+		return nil, err
+	}
+
+	return separateTopLevelKeys(formatted), nil
+}
+
+// clearMergeTags works around the encoder emitting the merge keys as "!!merge <<".
+func clearMergeTags(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!merge" {
+		node.Tag = ""
+	}
+
+	for _, child := range node.Content {
+		clearMergeTags(child)
+	}
+}
+
+// separateTopLevelKeys puts an empty line before each top-level key (and the comments preceding it),
+// since the decoder doesn't keep the empty lines.
+func separateTopLevelKeys(formatted string) []byte {
+	var result []string
+
+	lines := strings.Split(formatted, "\n")
+	commentStart := -1
+	seenKey := false
+
+	for _, line := range lines {
+		isTopLevel := line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-")
+
+		switch {
+		case isTopLevel && strings.HasPrefix(line, "#"):
+			if commentStart == -1 {
+				commentStart = len(result)
+			}
+		case isTopLevel:
+			if seenKey {
+				insertAt := len(result)
+				if commentStart != -1 {
+					insertAt = commentStart
+				}
+
+				result = append(result[:insertAt], append([]string{""}, result[insertAt:]...)...)
+			}
+
+			seenKey = true
+			commentStart = -1
+		default:
+			commentStart = -1
+		}
+
+		result = append(result, line)
+	}
+
+	return []byte(strings.Join(result, "\n"))
+}
+
+func newFmtCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fmt",
+		Short: "Format the YAML configuration or convert it to Starlark",
+		Long: "Rewrites the YAML configuration file with the canonical formatting, " +
+			"preserving the comments, anchors and aliases.\n\n" +
+			"With --to-starlark, prints an equivalent .cirrus.star instead, whose main() " +
+			"returns the same configuration.",
+		RunE: format,
+	}
+
+	cmd.PersistentFlags().StringVarP(&fmtFile, "file", "f", ".cirrus.yml", "YAML configuration file to format")
+	cmd.PersistentFlags().BoolVar(&fmtCheck, "check", false,
+		"don't modify the file, but fail if it's not formatted")
+	cmd.PersistentFlags().BoolVar(&fmtToStarlark, "to-starlark", false,
+		"print an equivalent Starlark configuration instead of formatting the file")
+
+	return cmd
+}
@@ -0,0 +1,101 @@
+package commands_test
+
+import (
+	"bytes"
+	"github.com/cirruslabs/cirrus-cli/internal/commands"
+	"github.com/cirruslabs/cirrus-cli/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"testing"
+)
+
+var unformattedConfig = []byte(`# Shared container
+container: &container
+    image: debian:latest   # pinned below
+task:
+    <<: *container
+    script:
+    - make test
+`)
+
+const formattedConfig = `# Shared container
+container: &container
+  image: debian:latest # pinned below
+
+task:
+  <<: *container
+  script:
+    - make test
+`
+
+func fmtWithArgs(t *testing.T, args ...string) (string, error) {
+	command := commands.NewRootCmd()
+	command.SetArgs(append([]string{"fmt"}, args...))
+
+	var output bytes.Buffer
+	command.SetOut(&output)
+	command.SetErr(&output)
+
+	err := command.Execute()
+
+	return output.String(), err
+}
+
+// TestFmt ensures that the configuration is formatted in place with the comments and anchors preserved.
+func TestFmt(t *testing.T) {
+	testutil.TempChdir(t)
+
+	require.NoError(t, ioutil.WriteFile(".cirrus.yml", unformattedConfig, 0600))
+
+	_, err := fmtWithArgs(t, "--check")
+	assert.ErrorIs(t, err, commands.ErrFmt)
+
+	_, err = fmtWithArgs(t)
+	require.NoError(t, err)
+
+	formatted, err := ioutil.ReadFile(".cirrus.yml")
+	require.NoError(t, err)
+	assert.Equal(t, formattedConfig, string(formatted))
+
+	_, err = fmtWithArgs(t, "--check")
+	assert.NoError(t, err)
+}
+
+func TestFmtMultipleDocuments(t *testing.T) {
+	testutil.TempChdir(t)
+
+	require.NoError(t, ioutil.WriteFile(".cirrus.yml", []byte("task:\n  script: make\n---\ntask: {}\n"), 0600))
+
+	_, err := fmtWithArgs(t)
+	assert.ErrorIs(t, err, commands.ErrFmt)
+}
+
+func TestFmtToStarlark(t *testing.T) {
+	testutil.TempChdir(t)
+
+	require.NoError(t, ioutil.WriteFile("config.yml", unformattedConfig, 0600))
+
+	output, err := fmtWithArgs(t, "--file", "config.yml", "--to-starlark")
+	require.NoError(t, err)
+
+	assert.Equal(t, `def main(ctx):
+    return [
+        # Shared container
+        ("container", {
+            "image": "debian:latest",  # pinned below
+        }),
+        ("task", {
+            "image": "debian:latest",
+            "script": [
+                "make test",
+            ],
+        }),
+    ]
+`, output)
+
+	// The file itself is left intact
+	config, err := ioutil.ReadFile("config.yml")
+	require.NoError(t, err)
+	assert.Equal(t, unformattedConfig, config)
+}
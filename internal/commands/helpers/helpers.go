@@ -72,13 +72,19 @@ func ReadYAMLConfig(path string) (string, error) {
 	return string(yamlConfig), nil
 }
 
-func EvaluateStarlarkConfig(ctx context.Context, path string, env map[string]string) (string, error) {
+func EvaluateStarlarkConfig(
+	ctx context.Context,
+	path string,
+	env map[string]string,
+	opts ...larker.Option,
+) (string, error) {
 	starlarkSource, err := ioutil.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
 
-	lrk := larker.New(larker.WithFileSystem(local.New(".")), larker.WithEnvironment(env))
+	opts = append([]larker.Option{larker.WithFileSystem(local.New(".")), larker.WithEnvironment(env)}, opts...)
+	lrk := larker.New(opts...)
 
 	result, err := lrk.MainOptional(ctx, string(starlarkSource))
 	if err != nil {
@@ -88,7 +94,7 @@ func EvaluateStarlarkConfig(ctx context.Context, path string, env map[string]str
 	return result.YAMLConfig, nil
 }
 
-func ReadCombinedConfig(ctx context.Context, env map[string]string) (string, error) {
+func ReadCombinedConfig(ctx context.Context, env map[string]string, opts ...larker.Option) (string, error) {
 	// Here we read the .cirrus.yaml first so that if the error would arise
 	// and will be inspected it would indicate the preferable extension
 	yamlConfig, yamlErr := ReadYAMLConfig(".cirrus.yaml")
@@ -103,7 +109,7 @@ func ReadCombinedConfig(ctx context.Context, env map[string]string) (string, err
 		}
 	}
 
-	starlarkConfig, starlarkErr := EvaluateStarlarkConfig(ctx, ".cirrus.star", env)
+	starlarkConfig, starlarkErr := EvaluateStarlarkConfig(ctx, ".cirrus.star", env, opts...)
 	if starlarkErr != nil && !os.IsNotExist(starlarkErr) {
 		return "", starlarkErr
 	}
@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/internal/evaluator"
+	"github.com/cirruslabs/cirrus-cli/pkg/executorservice"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var ErrAdditionalInstances = errors.New("failed to retrieve additional instances supported by the Cirrus Cloud")
+
+// AdditionalInstances retrieves the instance types that are supported by the Cirrus Cloud
+// in addition to the ones built into the parser.
+func AdditionalInstances() (map[string]protoreflect.MessageDescriptor, error) {
+	additionalInstances, err := executorservice.New().SupportedInstances()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAdditionalInstances, err)
+	}
+
+	transformedInstances, err := evaluator.TransformAdditionalInstances(additionalInstances)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAdditionalInstances, err)
+	}
+
+	return transformedInstances, nil
+}
@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"io/ioutil"
+	"os"
+)
+
+// ModuleOptions configures the Starlark module loading: the remote modules are cached in the user's cache directory
+// and pinned to the commits recorded in the .cirrus.lock file (if any) in the current directory.
+//
+// Since the CLI runs on behalf of the user, the user's .netrc file and the SSH agent are used
+// to authenticate the remote modules.
+func ModuleOptions(offline bool) ([]larker.Option, error) {
+	result := []larker.Option{larker.WithLocalCredentials()}
+
+	storeDir, err := modules.DefaultStoreDir()
+	if err == nil {
+		result = append(result, larker.WithModuleStore(modules.NewStore(storeDir)))
+	}
+
+	lockfileBytes, err := ioutil.ReadFile(modules.LockfileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		lockfile, err := modules.ParseLockfile(lockfileBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrConfigurationReadFailed, modules.LockfileName, err)
+		}
+
+		result = append(result, larker.WithLockfile(lockfile))
+	}
+
+	if offline {
+		result = append(result, larker.WithOfflineMode())
+	}
+
+	return result, nil
+}
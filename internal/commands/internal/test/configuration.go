@@ -2,14 +2,40 @@ package test
 
 import (
 	"errors"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"os"
 )
 
+var ErrInvalidConfiguration = errors.New("invalid test configuration")
+
 type Configuration struct {
 	Environment   map[string]string `yaml:"env"`
 	AffectedFiles []string          `yaml:"affected_files"`
+	Hooks         []*HookInvocation `yaml:"hooks"`
+
+	// HTTP, when set (even to an empty list), makes the test hermetic: the requests made
+	// through the http module are served with these canned responses and the requests
+	// matching none of them fail the test.
+	HTTP []*HTTPResponse `yaml:"http"`
+}
+
+// HookInvocation describes a hook (e.g. on_build_failed) to call with the specified
+// JSON-compatible arguments.
+type HookInvocation struct {
+	Name      string        `yaml:"name"`
+	Arguments []interface{} `yaml:"arguments"`
+}
+
+// HTTPResponse is a canned response to the requests matching the method (any if empty)
+// and the URL regular expression.
+type HTTPResponse struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
 }
 
 func LoadConfiguration(path string) (*Configuration, error) {
@@ -33,5 +59,26 @@ func LoadConfiguration(path string) (*Configuration, error) {
 		return nil, err
 	}
 
+	// The expected results are stored in files named after the hooks
+	seenHooks := map[string]struct{}{}
+
+	for _, hook := range config.Hooks {
+		if hook.Name == "" {
+			return nil, fmt.Errorf("%w: %s: hook name is required", ErrInvalidConfiguration, path)
+		}
+
+		if _, ok := seenHooks[hook.Name]; ok {
+			return nil, fmt.Errorf("%w: %s: hook %s is declared more than once, use a separate "+
+				"test directory to test it with different arguments", ErrInvalidConfiguration, path, hook.Name)
+		}
+		seenHooks[hook.Name] = struct{}{}
+	}
+
+	for _, response := range config.HTTP {
+		if response.URL == "" {
+			return nil, fmt.Errorf("%w: %s: HTTP response URL is required", ErrInvalidConfiguration, path)
+		}
+	}
+
 	return config, nil
 }
@@ -0,0 +1,49 @@
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package test
+
+import (
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/coverage"
+	"github.com/cirruslabs/echelon"
+	"io"
+	"os"
+)
+
+// writeCoverage writes the collected coverage in the requested formats and logs its summary.
+func writeCoverage(logger *echelon.Logger, testCoverage *coverage.Coverage) error {
+	if lcovFilename != "" {
+		if err := writeCoverageFile(lcovFilename, testCoverage.WriteLCOV); err != nil {
+			return err
+		}
+	}
+
+	if coberturaFilename != "" {
+		if err := writeCoverageFile(coberturaFilename, testCoverage.WriteCobertura); err != nil {
+			return err
+		}
+	}
+
+	covered, total := testCoverage.Summary()
+	if total != 0 {
+		logger.Infof("Starlark line coverage: %.1f%% (%d of %d lines)",
+			float64(covered)*100/float64(total), covered, total)
+	}
+
+	return nil
+}
+
+func writeCoverageFile(filename string, write func(w io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
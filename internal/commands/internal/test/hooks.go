@@ -0,0 +1,57 @@
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cirruslabs/echelon"
+	"path/filepath"
+)
+
+// compareHook calls the hook with the arguments from the test configuration and compares
+// its result and logs against the expected ones stored in the .cirrus.expected.<hook>.json
+// and .cirrus.expected.<hook>.log files.
+func compareHook(
+	ctx context.Context,
+	logger *echelon.Logger,
+	testDir string,
+	testConfig *Configuration,
+	source string,
+	hook *HookInvocation,
+) ([]*Comparison, error) {
+	mocks, err := newMocks(testConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	hookResult, err := newLarker(testDir, testConfig, mocks).Hook(ctx, source, hook.Name, hook.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTest, err)
+	}
+
+	resultBytes, err := json.MarshalIndent(hookResult.Result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s() returned a result that cannot be represented as JSON: %v",
+			ErrTest, hook.Name, err)
+	}
+	resultBytes = append(resultBytes, '\n')
+
+	resultComparison, err := compareFile(logger, hook.Name+"() result",
+		filepath.Join(testDir, ".cirrus.expected."+hook.Name+".json"), resultBytes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	logsComparison, err := compareFile(logger, hook.Name+"() logs",
+		filepath.Join(testDir, ".cirrus.expected."+hook.Name+".log"), hookResult.OutputLogs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpComparison := checkHTTPRequests(logger, testDir, hook.Name+"()", mocks)
+
+	return []*Comparison{resultComparison, logsComparison, httpComparison}, nil
+}
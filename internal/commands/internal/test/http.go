@@ -0,0 +1,58 @@
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package test
+
+import (
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/builtin"
+	"github.com/cirruslabs/echelon"
+	"path/filepath"
+	"strings"
+)
+
+// newMocks returns the mocks that serve the canned HTTP responses from the test configuration
+// or nil if the test configuration has no http field, in which case the requests reach the network.
+func newMocks(testConfig *Configuration) (*builtin.Mocks, error) {
+	if testConfig.HTTP == nil {
+		return nil, nil
+	}
+
+	mocks := builtin.NewMocks()
+	mocks.RequireHTTPMocks()
+
+	for _, response := range testConfig.HTTP {
+		err := mocks.AddHTTP(&builtin.HTTPMock{
+			Method:  response.Method,
+			URL:     response.URL,
+			Status:  response.Status,
+			Headers: response.Headers,
+			Body:    response.Body,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid HTTP response URL %q: %v", ErrInvalidConfiguration, response.URL, err)
+		}
+	}
+
+	return mocks, nil
+}
+
+// checkHTTPRequests fails the test if the Starlark code made requests that none
+// of the canned HTTP responses match.
+func checkHTTPRequests(logger *echelon.Logger, testDir string, where string, mocks *builtin.Mocks) *Comparison {
+	if mocks == nil || len(mocks.UnexpectedHTTPRequests()) == 0 {
+		return &Comparison{FoundDifference: false}
+	}
+
+	logger.Warnf("Detected unexpected HTTP requests in %s:", where)
+	for _, request := range mocks.UnexpectedHTTPRequests() {
+		logger.Warnf("%s", request)
+	}
+
+	return &Comparison{
+		FoundDifference: true,
+		Message:         fmt.Sprintf("Unexpected HTTP requests in %s", where),
+		RawDetails:      strings.Join(mocks.UnexpectedHTTPRequests(), "\n"),
+		Path:            filepath.Join(testDir, ".cirrus.testconfig.yml"),
+	}
+}
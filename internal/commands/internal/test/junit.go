@@ -0,0 +1,138 @@
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"io/ioutil"
+	"time"
+)
+
+// JUnitReport accumulates the results of both kinds of tests in a format understood by most CI systems.
+type JUnitReport struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []*JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitProblem `xml:"failure,omitempty"`
+	Error     *JUnitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type JUnitProblem struct {
+	Message string `xml:"message,attr"`
+	Details string `xml:",chardata"`
+}
+
+// AddComparisons adds the results of comparing the .cirrus.star's main() or hook output
+// against the expected one as a test case of the test directory's suite.
+func (report *JUnitReport) AddComparisons(testDir string, name string, comparisons ...*Comparison) {
+	var suite *JUnitTestSuite
+
+	for _, existingSuite := range report.Suites {
+		if existingSuite.Name == testDir {
+			suite = existingSuite
+		}
+	}
+
+	if suite == nil {
+		suite = &JUnitTestSuite{
+			Name: testDir,
+			Time: formatSeconds(0),
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+
+	testCase := &JUnitTestCase{
+		Name:      name,
+		ClassName: testDir,
+		Time:      formatSeconds(0),
+	}
+
+	for _, comparison := range comparisons {
+		if !comparison.FoundDifference {
+			continue
+		}
+
+		testCase.Failure = &JUnitProblem{
+			Message: fmt.Sprintf("%s (%s)", comparison.Message, comparison.Path),
+			Details: comparison.RawDetails,
+		}
+		suite.Failures++
+
+		break
+	}
+
+	suite.Tests++
+	suite.TestCases = append(suite.TestCases, testCase)
+}
+
+// AddTestResults adds the results of a test_*.star file as a suite.
+func (report *JUnitReport) AddTestResults(testFile string, results []*larker.TestResult) {
+	suite := &JUnitTestSuite{
+		Name:  testFile,
+		Tests: len(results),
+	}
+
+	var totalNanos int64
+
+	for _, result := range results {
+		testCase := &JUnitTestCase{
+			Name:      result.Name,
+			ClassName: testFile,
+			Time:      formatSeconds(result.DurationNanos),
+			SystemOut: string(result.OutputLogs),
+		}
+
+		if !result.Passed() {
+			problem := &JUnitProblem{
+				Message: result.ErrorMessage,
+				Details: string(result.OutputLogs),
+			}
+
+			if result.AssertionFailed {
+				testCase.Failure = problem
+				suite.Failures++
+			} else {
+				testCase.Error = problem
+				suite.Errors++
+			}
+		}
+
+		totalNanos += result.DurationNanos
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suite.Time = formatSeconds(totalNanos)
+
+	report.Suites = append(report.Suites, suite)
+}
+
+func (report *JUnitReport) WriteFile(path string) error {
+	xmlBytes, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append([]byte(xml.Header), append(xmlBytes, '\n')...), 0600)
+}
+
+func formatSeconds(nanos int64) string {
+	return fmt.Sprintf("%.3f", time.Duration(nanos).Seconds())
+}
@@ -4,11 +4,14 @@
 package test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/cirruslabs/cirrus-cli/internal/commands/logs"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/builtin"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/coverage"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/local"
 	"github.com/cirruslabs/echelon"
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -24,6 +27,12 @@ var ErrTest = errors.New("test failed")
 var update bool
 var output string
 var reportFilename string
+var junitFilename string
+var lcovFilename string
+var coberturaFilename string
+
+// testCoverage collects the Starlark line coverage when requested.
+var testCoverage *coverage.Coverage
 
 type Comparison struct {
 	FoundDifference bool
@@ -56,38 +65,33 @@ func (comparison *Comparison) AsCirrusAnnotation() *CirrusAnnotation {
 
 // compareConfig compares generated configuration against an expected one.
 func compareConfig(logger *echelon.Logger, testDir string, yamlConfig string) (*Comparison, error) {
-	expectedConfigFilename := filepath.Join(testDir, ".cirrus.expected.yml")
-	expectedConfigBytes, err := ioutil.ReadFile(expectedConfigFilename)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrTest, err)
-	}
-
-	comparison := logDifferenceIfAny(logger, "YAML", string(expectedConfigBytes), yamlConfig)
-	comparison.Path = expectedConfigFilename
-
-	if update && comparison.FoundDifference {
-		if err := ioutil.WriteFile(expectedConfigFilename, []byte(yamlConfig), 0600); err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrTest, err)
-		}
-		comparison.FoundDifference = false
-	}
-
-	return comparison, nil
+	return compareFile(logger, "YAML", filepath.Join(testDir, ".cirrus.expected.yml"), []byte(yamlConfig), true)
 }
 
 // compareLogs compares generated log against an expected one.
 func compareLogs(logger *echelon.Logger, testDir string, actualLogs []byte) (*Comparison, error) {
-	logsFilename := filepath.Join(testDir, ".cirrus.expected.log")
-	logsBytes, err := ioutil.ReadFile(logsFilename)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
+	return compareFile(logger, "logs", filepath.Join(testDir, ".cirrus.expected.log"), actualLogs, false)
+}
+
+// compareFile compares the actual contents against the expected ones stored in a file,
+// updating the file instead of reporting a difference when asked to.
+func compareFile(
+	logger *echelon.Logger,
+	where string,
+	expectedFilename string,
+	actual []byte,
+	mustExist bool,
+) (*Comparison, error) {
+	expectedBytes, err := ioutil.ReadFile(expectedFilename)
+	if err != nil && (mustExist || !errors.Is(err, os.ErrNotExist)) {
 		return nil, fmt.Errorf("%w: %v", ErrTest, err)
 	}
 
-	comparison := logDifferenceIfAny(logger, "logs", string(logsBytes), string(actualLogs))
-	comparison.Path = logsFilename
+	comparison := logDifferenceIfAny(logger, where, string(expectedBytes), string(actual))
+	comparison.Path = expectedFilename
 
 	if update && comparison.FoundDifference {
-		if err := ioutil.WriteFile(logsFilename, actualLogs, 0600); err != nil {
+		if err := ioutil.WriteFile(expectedFilename, actual, 0600); err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrTest, err)
 		}
 		comparison.FoundDifference = false
@@ -96,18 +100,17 @@ func compareLogs(logger *echelon.Logger, testDir string, actualLogs []byte) (*Co
 	return comparison, nil
 }
 
-func writeReport(yamlComparison, logsComparison *Comparison) error {
+func writeReport(comparisons []*Comparison) error {
 	if reportFilename == "" {
 		return nil
 	}
 
 	var annotations []*CirrusAnnotation
 
-	if annotation := yamlComparison.AsCirrusAnnotation(); annotation != nil {
-		annotations = append(annotations, annotation)
-	}
-	if annotation := logsComparison.AsCirrusAnnotation(); annotation != nil {
-		annotations = append(annotations, annotation)
+	for _, comparison := range comparisons {
+		if annotation := comparison.AsCirrusAnnotation(); annotation != nil {
+			annotations = append(annotations, annotation)
+		}
 	}
 
 	if len(annotations) == 0 {
@@ -140,11 +143,37 @@ func test(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
 	// Discover tests
-	var testDirs []string
+	var testDirs, testFiles []string
+	seenTestDirs := map[string]struct{}{}
+
+	addTestDir := func(testDir string) {
+		if _, ok := seenTestDirs[testDir]; !ok {
+			seenTestDirs[testDir] = struct{}{}
+			testDirs = append(testDirs, testDir)
+		}
+	}
+
 	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
 		// Does it look like a Starlark test?
 		if info.Name() == ".cirrus.expected.yml" {
-			testDirs = append(testDirs, filepath.Dir(path))
+			addTestDir(filepath.Dir(path))
+		}
+
+		// Does it only test the hooks?
+		if info.Name() == ".cirrus.testconfig.yml" {
+			testConfig, err := LoadConfiguration(path)
+			if err != nil {
+				return err
+			}
+
+			if len(testConfig.Hooks) != 0 {
+				addTestDir(filepath.Dir(path))
+			}
+		}
+
+		// Does it look like a Starlark unit test file?
+		if isUnitTestFile(info) {
+			testFiles = append(testFiles, path)
 		}
 
 		return nil
@@ -160,64 +189,175 @@ func test(cmd *cobra.Command, args []string) error {
 	// Run tests
 	var someTestsFailed bool
 
-	for _, testDir := range testDirs {
-		logger := logger.Scoped(testDir)
-
-		// Create Starlark executor and run .cirrus.star to generate the configuration
-		larkerOpts := []larker.Option{larker.WithTestMode()}
+	junit := &JUnitReport{}
 
-		fs := local.New(".")
-		fs.Chdir(testDir)
-		larkerOpts = append(larkerOpts, larker.WithFileSystem(fs))
+	testCoverage = nil
+	if lcovFilename != "" || coberturaFilename != "" {
+		testCoverage = coverage.New()
+	}
 
-		testConfig, err := LoadConfiguration(filepath.Join(testDir, ".cirrus.testconfig.yml"))
+	for _, testDir := range testDirs {
+		passed, err := runDirectoryTest(cmd.Context(), logger, junit, testDir)
 		if err != nil {
 			return err
 		}
-		larkerOpts = append(larkerOpts,
-			larker.WithEnvironment(testConfig.Environment),
-			larker.WithAffectedFiles(testConfig.AffectedFiles),
-		)
 
-		lrk := larker.New(larkerOpts...)
+		if !passed {
+			someTestsFailed = true
+		}
+	}
 
-		sourceBytes, err := ioutil.ReadFile(filepath.Join(testDir, ".cirrus.star"))
+	for _, testFile := range testFiles {
+		passed, err := runUnitTests(cmd.Context(), logger, junit, testFile)
 		if err != nil {
-			return fmt.Errorf("%w: %v", ErrTest, err)
+			return err
 		}
 
-		result, err := lrk.MainOptional(cmd.Context(), string(sourceBytes))
-		if err != nil {
-			return fmt.Errorf("%w: %v", ErrTest, err)
+		if !passed {
+			someTestsFailed = true
 		}
+	}
+
+	if junitFilename != "" {
+		if err := junit.WriteFile(junitFilename); err != nil {
+			return fmt.Errorf("%w: failed to write the JUnit report: %v", ErrTest, err)
+		}
+	}
+
+	if testCoverage != nil {
+		if err := writeCoverage(logger, testCoverage); err != nil {
+			return fmt.Errorf("%w: failed to write the coverage report: %v", ErrTest, err)
+		}
+	}
+
+	logger.Finish(!someTestsFailed)
+	if someTestsFailed {
+		return fmt.Errorf("%w: some tests failed", ErrTest)
+	}
 
-		yamlComparison, err := compareConfig(logger, testDir, result.YAMLConfig)
+	return nil
+}
+
+// runDirectoryTest compares the results of running the .cirrus.star's main() and hooks
+// against the expected ones and reports whether they're the same.
+func runDirectoryTest(
+	ctx context.Context,
+	logger *echelon.Logger,
+	junit *JUnitReport,
+	testDir string,
+) (bool, error) {
+	logger = logger.Scoped(testDir)
+
+	testConfig, err := LoadConfiguration(filepath.Join(testDir, ".cirrus.testconfig.yml"))
+	if err != nil {
+		return false, err
+	}
+
+	sourceBytes, err := ioutil.ReadFile(filepath.Join(testDir, ".cirrus.star"))
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrTest, err)
+	}
+
+	var allComparisons []*Comparison
+
+	// Hook-only tests have no expected configuration
+	_, err = os.Stat(filepath.Join(testDir, ".cirrus.expected.yml"))
+	if err == nil {
+		comparisons, err := compareMain(ctx, logger, testDir, testConfig, string(sourceBytes))
 		if err != nil {
-			return err
+			return false, err
 		}
-		logsComparison, err := compareLogs(logger, testDir, result.OutputLogs)
+
+		junit.AddComparisons(testDir, "main", comparisons...)
+		allComparisons = append(allComparisons, comparisons...)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("%w: %v", ErrTest, err)
+	}
+
+	for _, hook := range testConfig.Hooks {
+		comparisons, err := compareHook(ctx, logger, testDir, testConfig, string(sourceBytes), hook)
 		if err != nil {
-			return err
+			return false, err
 		}
 
-		if err := writeReport(yamlComparison, logsComparison); err != nil {
-			return err
+		junit.AddComparisons(testDir, hook.Name, comparisons...)
+		allComparisons = append(allComparisons, comparisons...)
+	}
+
+	if err := writeReport(allComparisons); err != nil {
+		return false, err
+	}
+
+	// Should we consider the test as failed?
+	passed := true
+
+	for _, comparison := range allComparisons {
+		if comparison.FoundDifference {
+			passed = false
 		}
+	}
 
-		// Should we consider the test as failed?
-		if yamlComparison.FoundDifference || logsComparison.FoundDifference {
-			someTestsFailed = true
+	logger.Finish(passed)
+
+	return passed, nil
+}
+
+// compareMain runs the .cirrus.star's main() and compares the generated configuration and logs
+// against the expected ones stored in the .cirrus.expected.yml and .cirrus.expected.log files.
+func compareMain(
+	ctx context.Context,
+	logger *echelon.Logger,
+	testDir string,
+	testConfig *Configuration,
+	source string,
+) ([]*Comparison, error) {
+	mocks, err := newMocks(testConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Starlark executor and run .cirrus.star to generate the configuration
+	result, err := newLarker(testDir, testConfig, mocks).MainOptional(ctx, source)
+	httpComparison := checkHTTPRequests(logger, testDir, "main()", mocks)
+	if err != nil {
+		// An unexpected HTTP request is most likely the reason main() failed
+		if httpComparison.FoundDifference {
+			return []*Comparison{httpComparison}, nil
 		}
 
-		logger.Finish(!yamlComparison.FoundDifference && !logsComparison.FoundDifference)
+		return nil, fmt.Errorf("%w: %v", ErrTest, err)
 	}
 
-	logger.Finish(!someTestsFailed)
-	if someTestsFailed {
-		return fmt.Errorf("%w: some tests failed", ErrTest)
+	yamlComparison, err := compareConfig(logger, testDir, result.YAMLConfig)
+	if err != nil {
+		return nil, err
+	}
+	logsComparison, err := compareLogs(logger, testDir, result.OutputLogs)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return []*Comparison{yamlComparison, logsComparison, httpComparison}, nil
+}
+
+func newLarker(testDir string, testConfig *Configuration, mocks *builtin.Mocks) *larker.Larker {
+	larkerOpts := []larker.Option{larker.WithTestMode()}
+
+	fs := local.New(".")
+	fs.Chdir(testDir)
+	larkerOpts = append(larkerOpts, larker.WithFileSystem(fs))
+
+	larkerOpts = append(larkerOpts,
+		larker.WithEnvironment(testConfig.Environment),
+		larker.WithAffectedFiles(testConfig.AffectedFiles),
+		larker.WithMocks(mocks),
+	)
+
+	if testCoverage != nil {
+		larkerOpts = append(larkerOpts, larker.WithCoverage(testCoverage))
+	}
+
+	return larker.New(larkerOpts...)
 }
 
 func logDifferenceIfAny(logger *echelon.Logger, where string, a, b string) *Comparison {
@@ -246,7 +386,10 @@ func NewTestCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "test",
 		Short: "Discover and run Starlark tests",
-		RunE:  test,
+		Long: "Discovers and runs two kinds of Starlark tests:\n\n" +
+			"* directories with .cirrus.expected.yml, where the .cirrus.star output is compared against it\n" +
+			"* test_*.star files, where each test_* function is ran as a unit test",
+		RunE: test,
 	}
 
 	cmd.PersistentFlags().BoolVar(&update, "update", false,
@@ -259,5 +402,14 @@ func NewTestCmd() *cobra.Command {
 		"additionally write a report in Cirrus Annotation Format (https://github.com/cirruslabs/cirrus-ci-annotations) "+
 			"to this file")
 
+	cmd.PersistentFlags().StringVar(&junitFilename, "junit", "",
+		"additionally write a report in JUnit XML format to this file")
+
+	cmd.PersistentFlags().StringVar(&lcovFilename, "lcov", "",
+		"collect the line coverage of the local Starlark files and write it in LCOV format to this file")
+
+	cmd.PersistentFlags().StringVar(&coberturaFilename, "cobertura", "",
+		"collect the line coverage of the local Starlark files and write it in Cobertura XML format to this file")
+
 	return cmd
 }
@@ -2,8 +2,10 @@ package test_test
 
 import (
 	"bytes"
+	"encoding/xml"
 	"fmt"
 	"github.com/cirruslabs/cirrus-cli/internal/commands"
+	"github.com/cirruslabs/cirrus-cli/internal/commands/internal/test"
 	"github.com/cirruslabs/cirrus-cli/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,7 +26,9 @@ func TestAll(t *testing.T) {
 	for _, fileInfo := range fileInfos {
 		fileInfo := fileInfo
 		t.Run(fileInfo.Name(), func(t *testing.T) {
-			if fileInfo.Name() == "update" || fileInfo.Name() == "report" {
+			if fileInfo.Name() == "update" || fileInfo.Name() == "report" || fileInfo.Name() == "unit-failing" ||
+				fileInfo.Name() == "hooks-update" || fileInfo.Name() == "http-unexpected" ||
+				fileInfo.Name() == "http-empty" {
 				return
 			}
 
@@ -41,6 +45,108 @@ func TestSimple(t *testing.T) {
 	assert.Contains(t, output, fmt.Sprintf("'%s' succeeded", adaptedPath))
 }
 
+// TestUnit ensures that the test_* functions from test_*.star files are discovered and ran successfully.
+func TestUnit(t *testing.T) {
+	output := runTestCommandAndGetOutput(t, "testdata/unit", []string{}, false)
+
+	assert.Contains(t, output, "'test_lib.star::test_default_image' succeeded")
+	assert.Contains(t, output, "'test_lib.star::test_go_version' succeeded")
+}
+
+func TestUnitJUnit(t *testing.T) {
+	_ = runTestCommandAndGetOutput(t, "testdata/unit-failing", []string{"--junit", "junit.xml"}, true)
+
+	junitBytes, err := ioutil.ReadFile("junit.xml")
+	require.NoError(t, err)
+
+	var report test.JUnitReport
+	require.NoError(t, xml.Unmarshal(junitBytes, &report))
+
+	require.Len(t, report.Suites, 1)
+	suite := report.Suites[0]
+	assert.Equal(t, "test_lib.star", suite.Name)
+	assert.Equal(t, 3, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Errors)
+
+	require.Len(t, suite.TestCases, 3)
+	assert.Nil(t, suite.TestCases[0].Failure)
+	assert.Nil(t, suite.TestCases[0].Error)
+	require.NotNil(t, suite.TestCases[1].Failure)
+	assert.Contains(t, suite.TestCases[1].Failure.Message, `"golang:latest" != "golang:1.17"`)
+	require.NotNil(t, suite.TestCases[2].Error)
+	assert.Contains(t, suite.TestCases[2].Error.Message, "unexpected")
+}
+
+// TestHooksUpdate ensures that the expected hook results and logs are created when running with --update.
+func TestHooksUpdate(t *testing.T) {
+	_ = runTestCommandAndGetOutput(t, "testdata/hooks-update", []string{"--update"}, false)
+
+	resultBytes, err := ioutil.ReadFile(".cirrus.expected.on_build_failed.json")
+	require.NoError(t, err)
+	assert.Equal(t, "[\n  \"notify\",\n  \"main\"\n]\n", string(resultBytes))
+
+	logsBytes, err := ioutil.ReadFile(".cirrus.expected.on_build_failed.log")
+	require.NoError(t, err)
+	assert.Equal(t, "build 1234 failed\n", string(logsBytes))
+
+	// Now that the expected files are in place, the test should pass without --update
+	_ = runTestCommandAndGetOutput(t, ".", []string{}, false)
+
+	require.NoError(t, ioutil.WriteFile(".cirrus.expected.on_build_failed.log", []byte("build 4321 failed\n"), 0600))
+	_ = runTestCommandAndGetOutput(t, ".", []string{}, true)
+}
+
+func TestHTTPUnexpected(t *testing.T) {
+	output := runTestCommandAndGetOutput(t, "testdata/http-unexpected", []string{}, true)
+
+	assert.Contains(t, output, "Detected unexpected HTTP requests in main()")
+	assert.Contains(t, output, "GET https://api.github.com/repos/cirruslabs/cirrus-cli/releases/latest")
+}
+
+// TestHTTPEmpty ensures that an empty list of canned responses
+// still prevents the requests from reaching the network.
+func TestHTTPEmpty(t *testing.T) {
+	output := runTestCommandAndGetOutput(t, "testdata/http-empty", []string{}, true)
+
+	assert.Contains(t, output, "Detected unexpected HTTP requests in main()")
+	assert.Contains(t, output, "GET https://api.github.com/repos/cirruslabs/cirrus-cli/releases/latest")
+}
+
+func TestCoverage(t *testing.T) {
+	output := runTestCommandAndGetOutput(t, "testdata/coverage",
+		[]string{"--lcov", "coverage.lcov", "--cobertura", "coverage.xml"}, false)
+
+	assert.Contains(t, output, "Starlark line coverage: 80.0% (4 of 5 lines)")
+
+	lcovBytes, err := ioutil.ReadFile("coverage.lcov")
+	require.NoError(t, err)
+
+	// Test files are not a subject of the coverage
+	assert.Equal(t, `TN:
+SF:lib.star
+DA:2,2
+DA:3,1
+DA:5,1
+DA:8,0
+LF:4
+LH:3
+end_of_record
+TN:
+SF:main/.cirrus.star
+DA:4,1
+LF:1
+LH:1
+end_of_record
+`, string(lcovBytes))
+
+	coberturaBytes, err := ioutil.ReadFile("coverage.xml")
+	require.NoError(t, err)
+
+	assert.Contains(t, string(coberturaBytes), `<class name="lib.star" filename="lib.star" line-rate="0.7500"`)
+	assert.Contains(t, string(coberturaBytes), `<line number="8" hits="0"></line>`)
+}
+
 func TestReport(t *testing.T) {
 	_ = runTestCommandAndGetOutput(t, "testdata/report", []string{"--report", "report-actual.json"}, true)
 
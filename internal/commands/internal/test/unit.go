@@ -0,0 +1,70 @@
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"github.com/cirruslabs/echelon"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func isUnitTestFile(info os.FileInfo) bool {
+	return !info.IsDir() && strings.HasPrefix(info.Name(), larker.TestFunctionPrefix) &&
+		strings.HasSuffix(info.Name(), ".star")
+}
+
+// runUnitTests runs the test_* functions from the test file and reports whether all of them have passed.
+func runUnitTests(ctx context.Context, logger *echelon.Logger, junit *JUnitReport, testFile string) (bool, error) {
+	testDir := filepath.Dir(testFile)
+
+	testConfig, err := LoadConfiguration(filepath.Join(testDir, ".cirrus.testconfig.yml"))
+	if err != nil {
+		return false, err
+	}
+
+	// The canned HTTP responses from the test configuration are available to all the tests
+	mocks, err := newMocks(testConfig)
+	if err != nil {
+		return false, err
+	}
+
+	lrk := newLarker(testDir, testConfig, mocks)
+
+	sourceBytes, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrTest, err)
+	}
+
+	results, err := lrk.Test(ctx, filepath.Base(testFile), string(sourceBytes))
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %v", ErrTest, testFile, err)
+	}
+
+	allPassed := true
+
+	for _, result := range results {
+		logger := logger.Scoped(testFile + "::" + result.Name)
+
+		if !result.Passed() {
+			allPassed = false
+
+			logger.Warnf("%s", result.ErrorMessage)
+
+			if len(result.OutputLogs) != 0 {
+				logger.Warnf("%s", strings.TrimSuffix(string(result.OutputLogs), "\n"))
+			}
+		}
+
+		logger.Finish(result.Passed())
+	}
+
+	junit.AddTestResults(testFile, results)
+
+	return allPassed, nil
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"github.com/cirruslabs/cirrus-cli/internal/commands/helpers"
+	"github.com/cirruslabs/cirrus-cli/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+var lspEnvironment []string
+
+func runLSP(cmd *cobra.Command, args []string) error {
+	// https://github.com/spf13/cobra/issues/340#issuecomment-374617413
+	cmd.SilenceUsage = true
+
+	server := lsp.New(cmd.InOrStdin(), cmd.OutOrStdout(),
+		lsp.WithEnvironment(helpers.EnvArgsToMap(lspEnvironment)))
+
+	return server.Serve(cmd.Context())
+}
+
+func newLSPCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a Language Server Protocol server for Cirrus CI configuration files over stdio",
+		RunE:  runLSP,
+	}
+
+	cmd.PersistentFlags().StringArrayVarP(&lspEnvironment, "environment", "e", []string{},
+		"set (-e A=B) or pass-through (-e A) an environment variable to the parser and the Starlark interpreter")
+
+	return cmd
+}
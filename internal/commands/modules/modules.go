@@ -0,0 +1,19 @@
+package modules
+
+import (
+	"github.com/cirruslabs/cirrus-cli/internal/commands/helpers"
+	"github.com/spf13/cobra"
+)
+
+func NewRootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "modules",
+		Short: "Manage the remote Starlark modules",
+	}
+
+	commands := []*cobra.Command{
+		NewUpdateCmd(),
+	}
+
+	return helpers.ConsumeSubCommands(cmd, commands)
+}
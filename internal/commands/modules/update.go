@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/internal/commands/helpers"
+	eenvironment "github.com/cirruslabs/cirrus-cli/internal/executor/environment"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/local"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"sort"
+)
+
+var ErrUpdate = errors.New("failed to update the modules")
+
+var environment []string
+var file string
+
+func update(cmd *cobra.Command, args []string) error {
+	// https://github.com/spf13/cobra/issues/340#issuecomment-374617413
+	cmd.SilenceUsage = true
+
+	env := eenvironment.Merge(
+		eenvironment.Static(),
+		eenvironment.ProjectSpecific("."),
+		helpers.EnvArgsToMap(environment),
+	)
+
+	storeDir, err := modules.DefaultStoreDir()
+	if err != nil {
+		return fmt.Errorf("%w: failed to locate the module cache: %v", ErrUpdate, err)
+	}
+
+	lockfile, err := loader.Lock(cmd.Context(), local.New("."), env, modules.NewStore(storeDir), file,
+		loader.WithLocalCredentials())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUpdate, err)
+	}
+
+	lockfileBytes, err := lockfile.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(modules.LockfileName, lockfileBytes, 0600); err != nil {
+		return fmt.Errorf("%w: %v", ErrUpdate, err)
+	}
+
+	var names []string
+	for name := range lockfile.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n", name, lockfile.Modules[name].Commit)
+	}
+
+	return nil
+}
+
+func NewUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Pin the remote Starlark modules to their latest commits in " + modules.LockfileName,
+		Long: "Resolves the revisions of the remote modules loaded by the Starlark configuration " +
+			"(including the ones loaded by other modules) to their latest commits, records them in the " +
+			modules.LockfileName + " file and populates the module cache, " +
+			"so that the configuration can be later evaluated with --offline.",
+		RunE: update,
+	}
+
+	cmd.PersistentFlags().StringArrayVarP(&environment, "environment", "e", []string{},
+		"set (-e A=B) or pass-through (-e A) an environment variable (e.g. CIRRUS_REPO_CLONE_TOKEN)")
+	cmd.PersistentFlags().StringVarP(&file, "file", "f", ".cirrus.star",
+		"Starlark configuration file to look for the load() statements in")
+
+	return cmd
+}
@@ -0,0 +1,45 @@
+package commands_test
+
+import (
+	"github.com/cirruslabs/cirrus-cli/internal/commands"
+	"github.com/cirruslabs/cirrus-cli/internal/testutil"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"testing"
+)
+
+func TestModulesUpdateWritesLockfile(t *testing.T) {
+	testutil.TempChdir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	require.NoError(t, ioutil.WriteFile(".cirrus.star", []byte("load(\"lib.star\", \"x\")\n"), 0600))
+	require.NoError(t, ioutil.WriteFile("lib.star", []byte("x = 1\n"), 0600))
+
+	command := commands.NewRootCmd()
+	command.SetArgs([]string{"modules", "update"})
+	require.NoError(t, command.Execute())
+
+	lockfileBytes, err := ioutil.ReadFile(modules.LockfileName)
+	require.NoError(t, err)
+
+	lockfile, err := modules.ParseLockfile(lockfileBytes)
+	require.NoError(t, err)
+	assert.Empty(t, lockfile.Modules)
+}
+
+// TestValidateOfflineUnpinnedModule ensures that the offline mode fails instead of accessing the network.
+func TestValidateOfflineUnpinnedModule(t *testing.T) {
+	testutil.TempChdir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	require.NoError(t, ioutil.WriteFile(".cirrus.star",
+		[]byte("load(\"github.com/cirrus-modules/helpers\", \"task\")\n\ndef main():\n    return []\n"), 0600))
+
+	command := commands.NewRootCmd()
+	command.SetArgs([]string{"validate", "--offline"})
+	err := command.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not pinned in .cirrus.lock")
+}
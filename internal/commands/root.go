@@ -3,6 +3,7 @@ package commands
 import (
 	"github.com/cirruslabs/cirrus-cli/internal/commands/helpers"
 	"github.com/cirruslabs/cirrus-cli/internal/commands/internal"
+	"github.com/cirruslabs/cirrus-cli/internal/commands/modules"
 	"github.com/cirruslabs/cirrus-cli/internal/commands/validate"
 	"github.com/cirruslabs/cirrus-cli/internal/commands/worker"
 	"github.com/cirruslabs/cirrus-cli/internal/version"
@@ -20,6 +21,10 @@ func NewRootCmd() *cobra.Command {
 		validate.NewValidateCmd(),
 		newRunCmd(),
 		newServeCmd(),
+		newLSPCmd(),
+		newSchemaCmd(),
+		newFmtCmd(),
+		modules.NewRootCmd(),
 		internal.NewRootCmd(),
 		worker.NewRootCmd(),
 	}
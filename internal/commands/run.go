@@ -6,6 +6,7 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"github.com/cirruslabs/cirrus-ci-agent/api"
 	"github.com/cirruslabs/cirrus-cli/internal/commands/helpers"
 	"github.com/cirruslabs/cirrus-cli/internal/commands/logs"
 	"github.com/cirruslabs/cirrus-cli/internal/executor"
@@ -14,9 +15,11 @@ import (
 	"github.com/cirruslabs/cirrus-cli/internal/executor/options"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/taskfilter"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/local"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
 	"github.com/spf13/cobra"
+	"io"
 	"os"
 	"strings"
 )
@@ -31,13 +34,22 @@ var affectedFiles []string
 var affectedFilesGitRevision string
 var affectedFilesGitCachedRevision string
 var verbose bool
+var logsDir string
+var dryRun bool
+var offline bool
 
 // Common instance-related flags.
 var lazyPull bool
 
 // Container-related flags.
 var containerBackendType string
+var containerHost string
+var containerCertPath string
+var containerTLSVerify bool
+var containerSSHIdentity string
+var podmanConnection string
 var containerLazyPull bool
+var additionalContainersLogsOnFailure bool
 
 // Container-related flags: Dockerfile as CI environment[1] feature.
 // [1]: https://cirrus-ci.org/guide/docker-builder-vm/#dockerfile-as-a-ci-environment
@@ -62,9 +74,14 @@ func run(cmd *cobra.Command, args []string) error {
 	)
 	userSpecifiedEnvironment := helpers.EnvArgsToMap(environment)
 
+	moduleOpts, err := helpers.ModuleOptions(offline)
+	if err != nil {
+		return err
+	}
+
 	// Retrieve the combined YAML configuration
 	combinedYAML, err := helpers.ReadCombinedConfig(cmd.Context(),
-		eenvironment.Merge(baseEnvironment, userSpecifiedEnvironment))
+		eenvironment.Merge(baseEnvironment, userSpecifiedEnvironment), moduleOpts...)
 	if err != nil {
 		return err
 	}
@@ -91,6 +108,7 @@ func run(cmd *cobra.Command, args []string) error {
 		parser.WithMissingInstancesAllowed(),
 		parser.WithAffectedFiles(affectedFiles),
 		parser.WithFileSystem(local.New(projectDir)),
+		parser.WithIncludeLocator(loader.FindModuleFS),
 	)
 	result, err := p.Parse(cmd.Context(), combinedYAML)
 	if err != nil {
@@ -101,6 +119,10 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if dryRun {
+		return printDryRun(cmd.OutOrStdout(), result, args)
+	}
+
 	var executorOpts []executor.Option
 
 	// Enable logging
@@ -124,10 +146,17 @@ func run(cmd *cobra.Command, args []string) error {
 		LazyPull:  lazyPull || containerLazyPull,
 		NoCleanup: debugNoCleanup,
 
+		AdditionalContainersLogsOnFailure: additionalContainersLogsOnFailure,
+
 		DockerfileImageTemplate: dockerfileImageTemplate,
 		DockerfileImagePush:     dockerfileImagePush,
 	}))
 
+	// Per-task logs
+	if logsDir != "" {
+		executorOpts = append(executorOpts, executor.WithLogsDir(logsDir))
+	}
+
 	// Tart-related options
 	executorOpts = append(executorOpts, executor.WithTartOptions(options.TartOptions{
 		LazyPull: lazyPull || tartLazyPull,
@@ -141,6 +170,13 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Container backend
 	executorOpts = append(executorOpts, executor.WithContainerBackendType(containerBackendType))
+	executorOpts = append(executorOpts, executor.WithContainerBackendConnection(containerbackend.Connection{
+		Host:             containerHost,
+		CertPath:         containerCertPath,
+		TLSVerify:        containerTLSVerify,
+		Identity:         containerSSHIdentity,
+		PodmanConnection: podmanConnection,
+	}))
 
 	// Run
 	e, err := executor.New(projectDir, result.Tasks, executorOpts...)
@@ -176,6 +212,13 @@ func newRunCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "")
 	cmd.PersistentFlags().StringVarP(&output, "output", "o", logs.DefaultFormat(), fmt.Sprintf("output format of logs, "+
 		"supported values: %s", strings.Join(logs.Formats(), ", ")))
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false,
+		"only print the tasks that would run and explain why the other tasks were filtered out or skipped")
+	cmd.PersistentFlags().StringVar(&logsDir, "logs-dir", "",
+		"directory to save the per-task logs to (e.g. the logs of the additional containers)")
+	cmd.PersistentFlags().BoolVar(&offline, "offline", false,
+		"only load the remote Starlark modules pinned in .cirrus.lock from the module cache, "+
+			"without accessing the network")
 
 	// Common instance-related flags
 	cmd.PersistentFlags().BoolVar(&lazyPull, "lazy-pull", false,
@@ -184,10 +227,25 @@ func newRunCmd() *cobra.Command {
 
 	// Container-related flags
 	cmd.PersistentFlags().StringVar(&containerBackendType, "container-backend", containerbackend.BackendTypeAuto,
-		fmt.Sprintf("container engine backend to use, either \"%s\", \"%s\" or \"%s\"",
-			containerbackend.BackendTypeDocker, containerbackend.BackendTypePodman, containerbackend.BackendTypeAuto))
+		fmt.Sprintf("container engine backend to use, either \"%s\", \"%s\", \"%s\" or \"%s\"",
+			containerbackend.BackendTypeDocker, containerbackend.BackendTypePodman,
+			containerbackend.BackendTypeContainerd, containerbackend.BackendTypeAuto))
+	cmd.PersistentFlags().StringVar(&containerHost, "container-host", "",
+		"container engine endpoint to connect to, e.g. unix:///run/user/1000/podman/podman.sock, "+
+			"tcp://build-host:2376 or ssh://user@build-host (defaults to DOCKER_HOST for Docker "+
+			"and CONTAINER_HOST for Podman)")
+	cmd.PersistentFlags().StringVar(&containerCertPath, "container-cert-path", "",
+		"directory with ca.pem, cert.pem and key.pem to use when connecting to the tcp:// container engine endpoint")
+	cmd.PersistentFlags().BoolVar(&containerTLSVerify, "container-tls-verify", false,
+		"verify the container engine endpoint's certificate against the ca.pem from --container-cert-path")
+	cmd.PersistentFlags().StringVar(&containerSSHIdentity, "container-ssh-identity", "",
+		"SSH private key to use when connecting to the ssh:// container engine endpoint")
+	cmd.PersistentFlags().StringVar(&podmanConnection, "podman-connection", "",
+		"name of the Podman connection from containers.conf to use (see \"podman system connection list\")")
 	cmd.PersistentFlags().BoolVar(&containerLazyPull, "container-lazy-pull", false,
 		"attempt to pull images only if they are missing locally (helpful in case of registry rate limits)")
+	cmd.PersistentFlags().BoolVar(&additionalContainersLogsOnFailure, "additional-containers-logs-on-failure", false,
+		"only show and save the logs of the additional containers when the task fails")
 
 	// Container-related flags: Dockerfile as CI environment feature
 	cmd.PersistentFlags().StringVar(&dockerfileImageTemplate, "dockerfile-image-template",
@@ -206,3 +264,41 @@ func newRunCmd() *cobra.Command {
 
 	return cmd
 }
+
+func printDryRun(w io.Writer, result *parser.Result, args []string) error {
+	tasks := result.Tasks
+
+	// Apply the same task filter as the executor would
+	if len(args) == 1 {
+		filteredTasks, err := taskfilter.MatchExactTask(args[0])(tasks)
+		if err != nil {
+			return err
+		}
+		tasks = filteredTasks
+	}
+
+	fmt.Fprintln(w, "Tasks that would run:")
+
+	for _, task := range tasks {
+		if task.Status == api.Status_SKIPPED {
+			continue
+		}
+
+		name := task.Name
+		if task.Metadata != nil && len(task.Metadata.UniqueLabels) != 0 {
+			name += " " + strings.Join(task.Metadata.UniqueLabels, " ")
+		}
+
+		fmt.Fprintf(w, "  %s\n", name)
+	}
+
+	if len(result.Explanations) != 0 {
+		fmt.Fprintln(w, "\nTasks that won't run:")
+
+		for _, explanation := range result.Explanations {
+			fmt.Fprintln(w, explanation.String())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/internal/commands/helpers"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/schema"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var schemaDraft string
+var schemaAdditionalInstances bool
+
+func printSchema(cmd *cobra.Command, args []string) error {
+	// https://github.com/spf13/cobra/issues/340#issuecomment-374617413
+	cmd.SilenceUsage = true
+
+	var parserOpts []parser.Option
+
+	if schemaAdditionalInstances {
+		additionalInstances, err := helpers.AdditionalInstances()
+		if err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%v, the schema will only include the built-in instances\n", err)
+		} else {
+			parserOpts = append(parserOpts, parser.WithAdditionalInstances(additionalInstances))
+		}
+	}
+
+	convertedSchema, err := schema.Convert(parser.New(parserOpts...).Schema(), schema.Draft(schemaDraft))
+	if err != nil {
+		return err
+	}
+
+	schemaBytes, err := json.MarshalIndent(convertedSchema, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), string(schemaBytes))
+
+	return err
+}
+
+func newSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for Cirrus CI configuration files",
+		RunE:  printSchema,
+	}
+
+	var drafts []string
+	for _, draft := range schema.Drafts() {
+		drafts = append(drafts, string(draft))
+	}
+
+	cmd.PersistentFlags().StringVar(&schemaDraft, "draft", string(schema.Draft7),
+		fmt.Sprintf("JSON Schema draft to use (%s)", strings.Join(drafts, ", ")))
+	cmd.PersistentFlags().BoolVar(&schemaAdditionalInstances, "additional-instances", false,
+		"include the instances supported by the Cirrus Cloud (e.g. gce_instance), requires network access")
+
+	return cmd
+}
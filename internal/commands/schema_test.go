@@ -0,0 +1,54 @@
+package commands_test
+
+import (
+	"bytes"
+	"github.com/cirruslabs/cirrus-cli/internal/commands"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+	"testing"
+)
+
+func schemaWithArgs(t *testing.T, args ...string) []byte {
+	command := commands.NewRootCmd()
+	command.SetArgs(append([]string{"schema"}, args...))
+
+	var output bytes.Buffer
+	command.SetOut(&output)
+
+	require.NoError(t, command.Execute())
+
+	return output.Bytes()
+}
+
+// TestSchemaDraft7 ensures that the exported schema can be used to validate configurations.
+func TestSchemaDraft7(t *testing.T) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaWithArgs(t, "--draft", "7")))
+	require.NoError(t, err)
+
+	result, err := schema.Validate(gojsonschema.NewStringLoader(
+		`{"container": {"image": "debian:latest", "cpu": 2}, "task": {"name": "Test", "script": "make test"}}`))
+	require.NoError(t, err)
+	assert.True(t, result.Valid(), result.Errors())
+
+	result, err = schema.Validate(gojsonschema.NewStringLoader(`{"container": {"cpu": "two"}}`))
+	require.NoError(t, err)
+	assert.False(t, result.Valid())
+}
+
+func TestSchemaDraft202012(t *testing.T) {
+	output := schemaWithArgs(t, "--draft", "2020-12")
+
+	assert.Contains(t, string(output), `"$schema": "https://json-schema.org/draft/2020-12/schema"`)
+	assert.Contains(t, string(output), `"prefixItems"`)
+	assert.NotContains(t, string(output), `"additionalItems"`)
+}
+
+func TestSchemaUnsupportedDraft(t *testing.T) {
+	command := commands.NewRootCmd()
+	command.SetArgs([]string{"schema", "--draft", "3"})
+	command.SetOut(&bytes.Buffer{})
+	command.SetErr(&bytes.Buffer{})
+
+	assert.Error(t, command.Execute())
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cirruslabs/cirrus-cli/internal/evaluator"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker"
 	"github.com/spf13/cobra"
 	"net"
 	"os"
@@ -16,6 +17,16 @@ var ErrServe = errors.New("serve failed")
 
 var address string
 
+// Resource limits of the Starlark evaluations, zero means no limit.
+var (
+	maxExecutionSteps    uint64
+	maxOutputBytes       int
+	maxLoadedModules     int
+	maxModuleSourceBytes int64
+)
+
+var allowRemoteIncludes bool
+
 func serve(cmd *cobra.Command, args []string) error {
 	// https://github.com/spf13/cobra/issues/340#issuecomment-374617413
 	cmd.SilenceUsage = true
@@ -27,7 +38,20 @@ func serve(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("listening on %s\n", lis.Addr().String())
 
-	if err := evaluator.Serve(cmd.Context(), lis); err != nil {
+	evaluatorOpts := []evaluator.Option{
+		evaluator.WithLarkerOptions(
+			larker.WithMaxExecutionSteps(maxExecutionSteps),
+			larker.WithMaxOutputBytes(maxOutputBytes),
+			larker.WithMaxLoadedModules(maxLoadedModules),
+			larker.WithMaxModuleSourceBytes(maxModuleSourceBytes),
+		),
+	}
+
+	if allowRemoteIncludes {
+		evaluatorOpts = append(evaluatorOpts, evaluator.WithRemoteIncludes())
+	}
+
+	if err := evaluator.Serve(cmd.Context(), lis, evaluatorOpts...); err != nil {
 		return fmt.Errorf("%w: %v", ErrServe, err)
 	}
 
@@ -48,6 +72,17 @@ func newServeCmd() *cobra.Command {
 	}
 
 	cmd.PersistentFlags().StringVarP(&address, "listen", "l", fmt.Sprintf(":%s", port), "address to listen on")
+	cmd.PersistentFlags().Uint64Var(&maxExecutionSteps, "max-execution-steps", 0,
+		"stop the Starlark evaluation after the specified number of computation steps (0 means no limit)")
+	cmd.PersistentFlags().IntVar(&maxOutputBytes, "max-output-bytes", 0,
+		"stop the Starlark evaluation once the print() output exceeds the specified size (0 means no limit)")
+	cmd.PersistentFlags().IntVar(&maxLoadedModules, "max-loaded-modules", 0,
+		"stop the Starlark evaluation once it loads more than the specified number of modules (0 means no limit)")
+	cmd.PersistentFlags().Int64Var(&maxModuleSourceBytes, "max-module-source-bytes", 0,
+		"stop the Starlark evaluation once the source code of the loaded modules exceeds the specified total size, "+
+			"excluding the data read by the fs and http modules (0 means no limit)")
+	cmd.PersistentFlags().BoolVar(&allowRemoteIncludes, "allow-remote-includes", false,
+		"allow the include: directive to retrieve the files from the remote repositories")
 
 	return cmd
 }
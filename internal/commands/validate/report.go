@@ -0,0 +1,289 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/lint"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
+	"io"
+	"strings"
+)
+
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatSARIF  = "sarif"
+	formatGitHub = "github"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported output format")
+
+var formats = []string{formatText, formatJSON, formatSARIF, formatGitHub}
+
+// reportEntry is a single issue or error found in the configuration.
+type reportEntry struct {
+	Path    string `json:"path"`
+	Line    uint64 `json:"line"`
+	Column  uint64 `json:"column"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+func (entry *reportEntry) String() string {
+	result := fmt.Sprintf("%s:%d:%d: %s: %s", entry.Path, entry.Line, entry.Column, entry.Level, entry.Message)
+
+	if entry.Rule != "" {
+		result += fmt.Sprintf(" [%s]", entry.Rule)
+	}
+
+	return result
+}
+
+// entryFromIssue converts the parser's or linter's issue into a report entry.
+//
+// These issues always refer to the main configuration file using a fixed path,
+// so the path is left empty for withReportPath() to fill it with the actual one.
+func entryFromIssue(issue *api.Issue, rule string) *reportEntry {
+	return &reportEntry{
+		Line:    issue.Line,
+		Column:  issue.Column,
+		Level:   strings.ToLower(issue.Level.String()),
+		Message: issue.Message,
+		Rule:    rule,
+	}
+}
+
+func entryFromError(path string, err error) *reportEntry {
+	entry := &reportEntry{
+		Path:    path,
+		Level:   strings.ToLower(api.Issue_ERROR.String()),
+		Message: err.Error(),
+	}
+
+	var re *parsererror.Rich
+	if errors.As(err, &re) {
+		entry.Line = uint64(re.Line())
+		entry.Column = uint64(re.Column())
+		entry.Message = re.Message()
+
+		// The error is in one of the included files
+		if re.Path() != "" {
+			entry.Path = re.Path()
+		}
+	}
+
+	return entry
+}
+
+func writeReport(w io.Writer, format string, entries []*reportEntry) error {
+	switch format {
+	case formatText:
+		for _, entry := range entries {
+			if _, err := fmt.Fprintln(w, entry.String()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case formatJSON:
+		return writeJSON(w, struct {
+			Issues []*reportEntry `json:"issues"`
+		}{
+			Issues: append([]*reportEntry{}, entries...),
+		})
+	case formatSARIF:
+		return writeJSON(w, sarifFromEntries(entries))
+	case formatGitHub:
+		for _, entry := range entries {
+			if _, err := fmt.Fprintln(w, githubCommandFromEntry(entry)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		return checkFormat(format)
+	}
+}
+
+func checkFormat(format string) error {
+	for _, supportedFormat := range formats {
+		if format == supportedFormat {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q, supported formats are: %s", ErrUnsupportedFormat, format,
+		strings.Join(formats, ", "))
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// githubCommandFromEntry produces a workflow command[1] that annotates the pull request.
+//
+// [1]: https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions
+func githubCommandFromEntry(entry *reportEntry) string {
+	command := "notice"
+
+	switch entry.Level {
+	case "error":
+		command = "error"
+	case "warning":
+		command = "warning"
+	}
+
+	properties := []string{"file=" + escapeGitHubProperty(entry.Path)}
+
+	if entry.Line != 0 {
+		properties = append(properties, fmt.Sprintf("line=%d", entry.Line))
+	}
+
+	if entry.Column != 0 {
+		properties = append(properties, fmt.Sprintf("col=%d", entry.Column))
+	}
+
+	if entry.Rule != "" {
+		properties = append(properties, "title="+escapeGitHubProperty(entry.Rule))
+	}
+
+	return fmt.Sprintf("::%s %s::%s", command, strings.Join(properties, ","), escapeGitHubData(entry.Message))
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// SARIF 2.1.0[1] log, only the parts that we actually use.
+//
+// [1]: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   uint64 `json:"startLine"`
+	StartColumn uint64 `json:"startColumn,omitempty"`
+}
+
+func sarifFromEntries(entries []*reportEntry) *sarifLog {
+	ruleDescriptions := make(map[string]string)
+	for _, rule := range lint.Rules() {
+		ruleDescriptions[rule.ID()] = rule.Description()
+	}
+
+	var rules []sarifRule
+	seenRules := make(map[string]struct{})
+	results := []sarifResult{}
+
+	for _, entry := range entries {
+		if entry.Rule != "" {
+			if _, ok := seenRules[entry.Rule]; !ok {
+				seenRules[entry.Rule] = struct{}{}
+				rules = append(rules, sarifRule{
+					ID:               entry.Rule,
+					ShortDescription: sarifMessage{Text: ruleDescriptions[entry.Rule]},
+				})
+			}
+		}
+
+		// SARIF has no "info" level
+		level := entry.Level
+		if level != "error" && level != "warning" {
+			level = "note"
+		}
+
+		physicalLocation := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: entry.Path},
+		}
+
+		// Lines and columns in SARIF are 1-based
+		if entry.Line != 0 {
+			physicalLocation.Region = &sarifRegion{
+				StartLine:   entry.Line,
+				StartColumn: entry.Column,
+			}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    entry.Rule,
+			Level:     level,
+			Message:   sarifMessage{Text: entry.Message},
+			Locations: []sarifLocation{{PhysicalLocation: physicalLocation}},
+		})
+	}
+
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "cirrus",
+						InformationURI: "https://github.com/cirruslabs/cirrus-cli",
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+	"io"
+)
+
+const (
+	resolvedFormatYAML = "yaml"
+	resolvedFormatJSON = "json"
+)
+
+var ErrUnsupportedResolvedFormat = errors.New("unsupported resolved tasks format")
+
+// writeResolvedTasks prints the tasks as seen by the executor after all the parsing stages, including
+// the service tasks, and with the dependencies additionally resolved to their names for readability.
+func writeResolvedTasks(w io.Writer, format string, tasks []*api.Task) error {
+	namesByID := make(map[int64]string)
+	for _, task := range tasks {
+		namesByID[task.LocalGroupId] = task.Name
+	}
+
+	resolvedTasks := []interface{}{}
+
+	for _, task := range tasks {
+		// Don't modify the original task, yet shun obsolete fields
+		task := proto.Clone(task).(*api.Task)
+		task.DeprecatedInstance = nil
+
+		marshalled, err := protojson.Marshal(task)
+		if err != nil {
+			return err
+		}
+
+		// Use json.Number to preserve the large integers (e.g. IDs) as is
+		decoder := json.NewDecoder(bytes.NewReader(marshalled))
+		decoder.UseNumber()
+
+		var resolvedTask map[string]interface{}
+		if err := decoder.Decode(&resolvedTask); err != nil {
+			return err
+		}
+
+		var dependsOn []string
+		for _, requiredGroup := range task.RequiredGroups {
+			dependsOn = append(dependsOn, namesByID[requiredGroup])
+		}
+		if len(dependsOn) != 0 {
+			resolvedTask["dependsOn"] = dependsOn
+		}
+
+		resolvedTasks = append(resolvedTasks, resolvedTask)
+	}
+
+	switch format {
+	case resolvedFormatJSON:
+		return writeJSON(w, resolvedTasks)
+	case resolvedFormatYAML:
+		// Go through JSON to turn json.Number's into the YAML numbers
+		jsonBytes, err := json.Marshal(resolvedTasks)
+		if err != nil {
+			return err
+		}
+
+		var document yaml.Node
+		if err := yaml.Unmarshal(jsonBytes, &document); err != nil {
+			return err
+		}
+		resetStyle(&document)
+
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&document); err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	default:
+		return fmt.Errorf("%w: %q, supported formats are: %s, %s", ErrUnsupportedResolvedFormat, format,
+			resolvedFormatYAML, resolvedFormatJSON)
+	}
+}
+
+// resetStyle makes the nodes unmarshalled from JSON to be marshalled in the block style,
+// the encoder will still quote the strings that would otherwise be interpreted as something else.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cirruslabs/cirrus-cli/internal/commands/helpers"
-	"github.com/cirruslabs/cirrus-cli/internal/evaluator"
 	eenvironment "github.com/cirruslabs/cirrus-cli/internal/executor/environment"
-	"github.com/cirruslabs/cirrus-cli/pkg/executorservice"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/debugger"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/local"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/lint"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
 	"github.com/spf13/cobra"
 	"io"
+	"os"
+	"sort"
 	"strings"
 )
 
@@ -20,26 +25,30 @@ var ErrValidate = errors.New("validate failed")
 var validateFile string
 var environment []string
 var shouldPrint bool
+var outputFormat string
+var resolvedFormat string
+var shouldExplain bool
 
-func additionalInstancesOption(stderr io.Writer) parser.Option {
-	// Try to retrieve additional instances from the Cirrus Cloud
-	additionalInstances, err := executorservice.New().SupportedInstances()
-	if err != nil {
-		_, _ = fmt.Fprintln(stderr, "failed to retrieve additional instances supported by the Cirrus Cloud,"+
-			"their validation will not be performed")
+// Lint flags.
+var shouldLint bool
+var lintDisable []string
+var offline bool
 
-		return parser.WithMissingInstancesAllowed()
-	}
+// Starlark debugger flags.
+var starlarkDebug bool
+var starlarkBreakpoints []string
 
-	transformedInstances, err := evaluator.TransformAdditionalInstances(additionalInstances)
+func additionalInstancesOption(stderr io.Writer) parser.Option {
+	// Try to retrieve additional instances from the Cirrus Cloud
+	additionalInstances, err := helpers.AdditionalInstances()
 	if err != nil {
-		_, _ = fmt.Fprintln(stderr, "failed to parse additional instances from the Cirrus Cloud,"+
+		_, _ = fmt.Fprintln(stderr, "failed to retrieve additional instances supported by the Cirrus Cloud, "+
 			"their validation will not be performed")
 
 		return parser.WithMissingInstancesAllowed()
 	}
 
-	return parser.WithAdditionalInstances(transformedInstances)
+	return parser.WithAdditionalInstances(additionalInstances)
 }
 
 func validate(cmd *cobra.Command, args []string) error {
@@ -55,48 +64,228 @@ func validate(cmd *cobra.Command, args []string) error {
 	userSpecifiedEnvironment := helpers.EnvArgsToMap(environment)
 	resultingEnvironment := eenvironment.Merge(baseEnvironment, userSpecifiedEnvironment)
 
+	// Validate the output format early to avoid doing any unnecessary work
+	if err := checkFormat(outputFormat); err != nil {
+		return err
+	}
+
+	// Only the text output can be mixed with the other output, otherwise the report won't be parseable
+	if shouldPrint && outputFormat != formatText {
+		return fmt.Errorf("%w: --print cannot be used together with --format", ErrValidate)
+	}
+
+	if resolvedFormat != "" && outputFormat != formatText {
+		return fmt.Errorf("%w: --resolved cannot be used together with --format", ErrValidate)
+	}
+
+	if shouldExplain && outputFormat != formatText {
+		return fmt.Errorf("%w: --explain cannot be used together with --format", ErrValidate)
+	}
+
+	larkerOpts, err := helpers.ModuleOptions(offline)
+	if err != nil {
+		return reportError(cmd, err)
+	}
+
+	if starlarkDebug || len(starlarkBreakpoints) != 0 {
+		debuggerOpt, err := debuggerOption(cmd)
+		if err != nil {
+			return err
+		}
+
+		larkerOpts = append(larkerOpts, debuggerOpt)
+	}
+
 	// Retrieve a combined YAML configuration or a specific one if asked to
 	var configuration string
-	var err error
 
 	switch {
 	case validateFile == "":
-		configuration, err = helpers.ReadCombinedConfig(cmd.Context(), resultingEnvironment)
-		if err != nil {
-			return err
-		}
+		configuration, err = helpers.ReadCombinedConfig(cmd.Context(), resultingEnvironment, larkerOpts...)
 	case strings.HasSuffix(validateFile, ".yml") || strings.HasSuffix(validateFile, ".yaml"):
 		configuration, err = helpers.ReadYAMLConfig(validateFile)
-		if err != nil {
-			return err
-		}
 	case strings.HasSuffix(validateFile, ".star"):
-		configuration, err = helpers.EvaluateStarlarkConfig(cmd.Context(), validateFile, resultingEnvironment)
-		if err != nil {
-			return err
-		}
+		configuration, err = helpers.EvaluateStarlarkConfig(cmd.Context(), validateFile, resultingEnvironment,
+			larkerOpts...)
 	default:
 		return ErrValidate
 	}
+	if err != nil {
+		return reportError(cmd, err)
+	}
 
 	if shouldPrint {
 		fmt.Fprint(cmd.OutOrStdout(), configuration)
 	}
 
 	// Parse
-	p := parser.New(parser.WithEnvironment(userSpecifiedEnvironment), additionalInstancesOption(cmd.ErrOrStderr()))
-	_, err = p.Parse(cmd.Context(), configuration)
+	p := parser.New(
+		parser.WithEnvironment(userSpecifiedEnvironment),
+		parser.WithFileSystem(local.New(".")),
+		parser.WithIncludeLocator(loader.FindModuleFS),
+		additionalInstancesOption(cmd.ErrOrStderr()),
+	)
+	result, err := p.Parse(cmd.Context(), configuration)
 	if err != nil {
-		if re, ok := err.(*parsererror.Rich); ok {
+		if re, ok := err.(*parsererror.Rich); ok && outputFormat == formatText {
 			fmt.Print(re.ContextLines())
 		}
 
+		return reportError(cmd, err)
+	}
+
+	if resolvedFormat != "" {
+		if err := writeResolvedTasks(cmd.OutOrStdout(), resolvedFormat, result.Tasks); err != nil {
+			return err
+		}
+	}
+
+	if shouldExplain {
+		for _, explanation := range result.Explanations {
+			fmt.Fprintln(cmd.OutOrStdout(), explanation.String())
+		}
+	}
+
+	var entries []*reportEntry
+
+	for _, parserIssue := range result.Issues {
+		entries = append(entries, entryFromIssue(parserIssue, ""))
+	}
+
+	var lintIssues []*lint.Issue
+
+	if shouldLint {
+		lintIssues, err = lint.New(lint.WithDisabledRules(lintDisable)).Lint(configuration, result)
+		if err != nil {
+			return fmt.Errorf("%w: failed to lint the configuration: %v", ErrValidate, err)
+		}
+
+		for _, lintIssue := range lintIssues {
+			entries = append(entries, entryFromIssue(lintIssue.Issue, lintIssue.RuleID))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Line != entries[j].Line {
+			return entries[i].Line < entries[j].Line
+		}
+
+		return entries[i].Column < entries[j].Column
+	})
+
+	if err := writeReport(cmd.OutOrStdout(), outputFormat, withReportPath(entries)); err != nil {
 		return err
 	}
 
+	if len(lintIssues) != 0 {
+		return fmt.Errorf("%w: found %d lint issue(s)", ErrValidate, len(lintIssues))
+	}
+
 	return nil
 }
 
+// debuggerOption creates a Starlark debugger that pauses the execution on the breakpoints
+// specified on the command line and interacts with the user through the terminal.
+func debuggerOption(cmd *cobra.Command) (larker.Option, error) {
+	var breakpoints []*debugger.Breakpoint
+
+	for _, rawBreakpoint := range starlarkBreakpoints {
+		breakpoint, err := debugger.ParseBreakpoint(rawBreakpoint)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrValidate, err)
+		}
+
+		breakpoints = append(breakpoints, breakpoint)
+	}
+
+	return larker.WithDebugger(debugger.New(cmd.InOrStdin(), cmd.ErrOrStderr(), breakpoints...)), nil
+}
+
+// reportError reports the error that prevented the validation in the machine-readable formats,
+// since for the text format it's already printed by Cobra.
+func reportError(cmd *cobra.Command, err error) error {
+	if outputFormat == formatText {
+		return err
+	}
+
+	entries := withReportPath([]*reportEntry{entryFromError("", err)})
+
+	if reportErr := writeReport(cmd.OutOrStdout(), outputFormat, entries); reportErr != nil {
+		return reportErr
+	}
+
+	return err
+}
+
+// configSource describes the files that the validated configuration comes from.
+type configSource struct {
+	yamlPath string
+
+	// yamlLines is the number of the configuration's leading lines that come from the yamlPath,
+	// the rest is generated by the starlarkPath (if any)
+	yamlLines int
+
+	starlarkPath string
+}
+
+func newConfigSource() *configSource {
+	switch {
+	case strings.HasSuffix(validateFile, ".yml") || strings.HasSuffix(validateFile, ".yaml"):
+		return &configSource{yamlPath: validateFile}
+	case strings.HasSuffix(validateFile, ".star"):
+		return &configSource{starlarkPath: validateFile}
+	}
+
+	// Same lookup as in helpers.ReadCombinedConfig()
+	source := &configSource{yamlPath: ".cirrus.yml"}
+
+	for _, path := range []string{".cirrus.yaml", ".cirrus.yml"} {
+		if yamlConfig, err := helpers.ReadYAMLConfig(path); err == nil {
+			source.yamlPath = path
+			source.yamlLines = strings.Count(yamlConfig, "\n") + 1
+
+			break
+		}
+	}
+
+	if _, err := os.Stat(".cirrus.star"); err == nil {
+		source.starlarkPath = ".cirrus.star"
+	}
+
+	return source
+}
+
+// locate fills the path of the entry that doesn't refer to a specific file (e.g. an included one).
+//
+// The line numbers in the configuration generated by Starlark don't correspond to the .star file,
+// so such entries only refer to the file itself.
+func (source *configSource) locate(entry *reportEntry) {
+	if entry.Path != "" {
+		return
+	}
+
+	if source.starlarkPath == "" || (entry.Line != 0 && int(entry.Line) <= source.yamlLines) {
+		entry.Path = source.yamlPath
+
+		return
+	}
+
+	entry.Path = source.starlarkPath
+	entry.Line = 0
+	entry.Column = 0
+}
+
+// withReportPath fills the path of the entries that don't refer to a specific file (e.g. an included one).
+func withReportPath(entries []*reportEntry) []*reportEntry {
+	source := newConfigSource()
+
+	for _, entry := range entries {
+		source.locate(entry)
+	}
+
+	return entries
+}
+
 func NewValidateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "validate",
@@ -111,6 +300,31 @@ func NewValidateCmd() *cobra.Command {
 		"use file as the configuration file (the path should end with either .yml or ..star)")
 	cmd.PersistentFlags().BoolVarP(&shouldPrint, "print", "p", false,
 		"print the configuration as YAML (useful for debugging Starlark files)")
+	cmd.PersistentFlags().StringVar(&outputFormat, "format", formatText,
+		fmt.Sprintf("output format of the found issues and errors (%s)", strings.Join(formats, ", ")))
+	cmd.PersistentFlags().StringVar(&resolvedFormat, "resolved", "",
+		"print the tasks that result from the configuration after expanding matrices, filtering "+
+			"and creating service tasks, either as YAML (the default) or JSON (--resolved=json)")
+	cmd.PersistentFlags().Lookup("resolved").NoOptDefVal = resolvedFormatYAML
+	cmd.PersistentFlags().BoolVar(&shouldExplain, "explain", false,
+		"explain why the tasks were filtered out by their only_if conditions or skipped by their skip conditions")
+	cmd.PersistentFlags().BoolVar(&offline, "offline", false,
+		"only load the remote Starlark modules pinned in .cirrus.lock from the module cache, "+
+			"without accessing the network")
+
+	// Starlark debugger flags
+	cmd.PersistentFlags().BoolVar(&starlarkDebug, "starlark-debug", false,
+		"interactively debug the Starlark configuration, pausing before the first statement "+
+			"unless the breakpoints are set")
+	cmd.PersistentFlags().StringArrayVar(&starlarkBreakpoints, "starlark-breakpoint", []string{},
+		"pause the Starlark execution before the statement on the specified line "+
+			"(e.g. --starlark-breakpoint .cirrus.star:12), implies --starlark-debug")
+
+	// Lint flags
+	cmd.PersistentFlags().BoolVar(&shouldLint, "lint", false,
+		"additionally check the configuration for likely mistakes that are not errors")
+	cmd.PersistentFlags().StringSliceVar(&lintDisable, "lint-disable", []string{},
+		"comma-separated list of lint rules to disable (e.g. --lint-disable=unused-anchor)")
 
 	return cmd
 }
@@ -2,9 +2,12 @@ package validate_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/cirruslabs/cirrus-cli/internal/commands"
+	"github.com/cirruslabs/cirrus-cli/internal/commands/validate"
 	"github.com/cirruslabs/cirrus-cli/internal/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"io"
 	"os"
 	"testing"
@@ -28,3 +31,160 @@ func TestValidateCloudInstance(t *testing.T) {
 	assert.NotContains(t, buf.String(), "failed",
 		"additional instance should be fetched and transformed successfully")
 }
+
+func validateWithArgs(t *testing.T, args ...string) (string, error) {
+	buf := bytes.NewBufferString("")
+
+	command := commands.NewRootCmd()
+	command.SetArgs(append([]string{"validate"}, args...))
+	command.SetOut(buf)
+	command.SetErr(io.Discard)
+	err := command.Execute()
+
+	return buf.String(), err
+}
+
+func TestValidateFormatJSON(t *testing.T) {
+	testutil.TempChdirPopulatedWith(t, "testdata/issues")
+
+	output, err := validateWithArgs(t, "--lint", "--format", "json")
+	require.Error(t, err)
+
+	var report struct {
+		Issues []map[string]interface{} `json:"issues"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"path":    ".cirrus.yml",
+			"line":    float64(1),
+			"column":  float64(12),
+			"level":   "warning",
+			"message": `anchor "container" is never used`,
+			"rule":    "unused-anchor",
+		},
+		{
+			"path":    ".cirrus.yml",
+			"line":    float64(4),
+			"column":  float64(1),
+			"level":   "warning",
+			"message": "you've probably meant lint_task",
+		},
+	}, report.Issues)
+}
+
+// TestValidateFormatStarlark ensures that the issues in the configuration generated by Starlark
+// are reported against the .star file without the line numbers, which only make sense for the generated YAML.
+func TestValidateFormatStarlark(t *testing.T) {
+	testutil.TempChdirPopulatedWith(t, "testdata/issues-starlark")
+
+	output, err := validateWithArgs(t, "--format", "json")
+	require.NoError(t, err)
+
+	var report struct {
+		Issues []map[string]interface{} `json:"issues"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"path":    ".cirrus.yml",
+			"line":    float64(4),
+			"column":  float64(1),
+			"level":   "warning",
+			"message": "you've probably meant test_task",
+		},
+		{
+			"path":    ".cirrus.star",
+			"line":    float64(0),
+			"column":  float64(0),
+			"level":   "warning",
+			"message": "you've probably meant lint_task",
+		},
+	}, report.Issues)
+}
+
+func TestValidateFormatSARIF(t *testing.T) {
+	testutil.TempChdirPopulatedWith(t, "testdata/issues")
+
+	output, err := validateWithArgs(t, "--lint", "--format", "sarif")
+	require.Error(t, err)
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &log))
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 2)
+	assert.Equal(t, "unused-anchor", log.Runs[0].Results[0].RuleID)
+	assert.Equal(t, "warning", log.Runs[0].Results[0].Level)
+	assert.Equal(t, 1, log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+}
+
+func TestValidateFormatGitHub(t *testing.T) {
+	testutil.TempChdirPopulatedWith(t, "testdata/issues")
+
+	output, err := validateWithArgs(t, "--format", "github")
+	require.NoError(t, err)
+
+	assert.Equal(t, "::warning file=.cirrus.yml,line=4,col=1::you've probably meant lint_task\n", output)
+}
+
+func TestValidateFormatUnsupported(t *testing.T) {
+	testutil.TempChdirPopulatedWith(t, "testdata/issues")
+
+	_, err := validateWithArgs(t, "--format", "xml")
+	require.ErrorIs(t, err, validate.ErrUnsupportedFormat)
+}
+
+func TestValidatePrintWithFormat(t *testing.T) {
+	testutil.TempChdirPopulatedWith(t, "testdata/issues")
+
+	output, err := validateWithArgs(t, "--print", "--format", "json")
+	require.ErrorIs(t, err, validate.ErrValidate)
+	assert.Empty(t, output)
+}
+
+func TestValidateResolved(t *testing.T) {
+	testutil.TempChdirPopulatedWith(t, "testdata/resolved")
+
+	output, err := validateWithArgs(t, "--resolved=json")
+	require.NoError(t, err)
+
+	var tasks []struct {
+		Name        string            `json:"name"`
+		Environment map[string]string `json:"environment"`
+		DependsOn   []string          `json:"dependsOn"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &tasks))
+
+	require.Len(t, tasks, 3)
+	assert.Equal(t, "build", tasks[0].Name)
+	assert.Empty(t, tasks[0].DependsOn)
+	assert.Equal(t, "test", tasks[1].Name)
+	assert.Equal(t, "1", tasks[1].Environment["V"])
+	assert.Equal(t, []string{"build"}, tasks[1].DependsOn)
+	assert.Equal(t, "yes", tasks[2].Environment["V"])
+	assert.Equal(t, []string{"build"}, tasks[2].DependsOn)
+
+	output, err = validateWithArgs(t, "--resolved")
+	require.NoError(t, err)
+	assert.Contains(t, output, "- commands:\n")
+	assert.Contains(t, output, "  dependsOn:\n    - build\n")
+}
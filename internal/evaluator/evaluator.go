@@ -12,6 +12,7 @@ import (
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/failing"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/github"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/memory"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
 	"google.golang.org/grpc"
@@ -34,6 +35,11 @@ var ErrGitHubFromEnvFailed = errors.New("got cirrus.fs call, but GitHub filesyst
 type ConfigurationEvaluatorServiceServer struct {
 	// must be embedded to have forward compatible implementations
 	api.UnimplementedCirrusConfigurationEvaluatorServiceServer
+
+	// larkerOpts are applied to every Starlark evaluation (e.g. to limit its resources)
+	larkerOpts []larker.Option
+
+	remoteIncludes bool
 }
 
 func addVersion(
@@ -53,10 +59,17 @@ func addVersion(
 	return handler(ctx, req)
 }
 
-func Serve(ctx context.Context, lis net.Listener) error {
+func Serve(ctx context.Context, lis net.Listener, opts ...Option) error {
 	server := grpc.NewServer(grpc.UnaryInterceptor(addVersion))
 
-	api.RegisterCirrusConfigurationEvaluatorServiceServer(server, &ConfigurationEvaluatorServiceServer{})
+	evaluatorServer := &ConfigurationEvaluatorServiceServer{}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(evaluatorServer)
+	}
+
+	api.RegisterCirrusConfigurationEvaluatorServiceServer(server, evaluatorServer)
 
 	errChan := make(chan error)
 
@@ -139,11 +152,11 @@ func (r *ConfigurationEvaluatorServiceServer) EvaluateConfig(
 	// Run Starlark script and register generated YAML configuration (if any)
 	// nolint:nestif // doesn't seem too complicated
 	if request.StarlarkConfig != "" {
-		lrk := larker.New(
+		lrk := larker.New(append([]larker.Option{
 			larker.WithFileSystem(fs),
 			larker.WithEnvironment(request.Environment),
 			larker.WithAffectedFiles(request.AffectedFiles),
-		)
+		}, r.larkerOpts...)...)
 
 		lrkResult, err := lrk.MainOptional(ctx, request.StarlarkConfig)
 		if err == nil {
@@ -169,11 +182,19 @@ func (r *ConfigurationEvaluatorServiceServer) EvaluateConfig(
 		return nil, err
 	}
 
+	// Retrieving the remote files is not subject to the Starlark evaluation limits,
+	// so only do that when explicitly allowed to
+	includeLocator := loader.FindLocalModuleFS
+	if r.remoteIncludes {
+		includeLocator = loader.FindModuleFS
+	}
+
 	// Parse combined YAML
 	p := parser.New(
 		parser.WithEnvironment(request.Environment),
 		parser.WithAffectedFiles(request.AffectedFiles),
 		parser.WithFileSystem(fs),
+		parser.WithIncludeLocator(includeLocator),
 		parser.WithAdditionalInstances(additionalInstances),
 		parser.WithAdditionalTaskProperties(request.AdditionalTaskProperties),
 	)
@@ -241,7 +262,7 @@ func (r *ConfigurationEvaluatorServiceServer) EvaluateFunction(
 	ctx context.Context,
 	request *api.EvaluateFunctionRequest,
 ) (*api.EvaluateFunctionResponse, error) {
-	lrk := larker.New(larker.WithEnvironment(request.Environment))
+	lrk := larker.New(append([]larker.Option{larker.WithEnvironment(request.Environment)}, r.larkerOpts...)...)
 
 	// Run Starlark hook
 	result, err := lrk.Hook(ctx, request.StarlarkConfig, request.FunctionName, request.Arguments.AsSlice())
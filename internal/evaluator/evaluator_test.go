@@ -319,6 +319,26 @@ task:
 	assert.EqualValues(t, firstIssue.Column, 3)
 }
 
+// TestRemoteIncludesDisabled ensures that the include: directive doesn't retrieve
+// the remote files unless explicitly allowed to.
+func TestRemoteIncludesDisabled(t *testing.T) {
+	yamlConfig := `include: github.com/cirrus-modules/helpers/ci.yml@main
+
+container:
+  image: debian:latest
+
+task:
+  script: true
+`
+
+	response, err := evaluateConfigHelper(t, &api.EvaluateConfigRequest{YamlConfig: yamlConfig})
+	require.NoError(t, err)
+	require.Len(t, response.Issues, 1)
+	assert.EqualValues(t, api.Issue_ERROR, response.Issues[0].Level)
+	assert.Contains(t, response.Issues[0].Message, "retrieving the remote files is disabled")
+	assert.EqualValues(t, 1, response.Issues[0].Line)
+}
+
 func TestHook(t *testing.T) {
 	config := `load("cirrus", "env")
 
@@ -0,0 +1,20 @@
+package evaluator
+
+import "github.com/cirruslabs/cirrus-cli/pkg/larker"
+
+type Option func(*ConfigurationEvaluatorServiceServer)
+
+// WithLarkerOptions applies the options to every Starlark evaluation (e.g. to limit its resources).
+func WithLarkerOptions(opts ...larker.Option) Option {
+	return func(server *ConfigurationEvaluatorServiceServer) {
+		server.larkerOpts = append(server.larkerOpts, opts...)
+	}
+}
+
+// WithRemoteIncludes allows the include: directive to retrieve the files from the remote repositories,
+// which are otherwise only looked up in the repository being evaluated.
+func WithRemoteIncludes() Option {
+	return func(server *ConfigurationEvaluatorServiceServer) {
+		server.remoteIncludes = true
+	}
+}
@@ -11,13 +11,17 @@ import (
 	"github.com/cirruslabs/cirrus-cli/internal/executor/environment"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/container"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/runconfig"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/options"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/platform"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/pullhelper"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/rpc"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/taskfilter"
 	"github.com/cirruslabs/echelon"
 	"github.com/cirruslabs/echelon/renderers"
 	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -29,14 +33,16 @@ type Executor struct {
 	rpc   *rpc.RPC
 
 	// Options
-	logger                   *echelon.Logger
-	taskFilter               taskfilter.TaskFilter
-	baseEnvironment          map[string]string
-	userSpecifiedEnvironment map[string]string
-	dirtyMode                bool
-	containerBackendType     string
-	containerOptions         options.ContainerOptions
-	tartOptions              options.TartOptions
+	logger                     *echelon.Logger
+	taskFilter                 taskfilter.TaskFilter
+	baseEnvironment            map[string]string
+	userSpecifiedEnvironment   map[string]string
+	dirtyMode                  bool
+	containerBackendType       string
+	containerBackendConnection containerbackend.Connection
+	containerOptions           options.ContainerOptions
+	tartOptions                options.TartOptions
+	logsDir                    string
 }
 
 func New(projectDir string, tasks []*api.Task, opts ...Option) (*Executor, error) {
@@ -124,6 +130,8 @@ func New(projectDir string, tasks []*api.Task, opts ...Option) (*Executor, error
 func (e *Executor) Run(ctx context.Context) error {
 	var firstErr error
 
+	e.prePullImages(ctx)
+
 	for {
 		// Pick next undone task to run
 		task := e.build.GetNextTask()
@@ -158,18 +166,27 @@ func (e *Executor) runSingleTask(ctx context.Context, task *build.Task) error {
 
 	// Prepare task's instance
 	instanceRunOpts := runconfig.RunConfig{
-		ContainerBackendType: e.containerBackendType,
-		ProjectDir:           e.build.ProjectDir,
-		Endpoint:             endpoint.NewLocal(e.rpc.ContainerEndpoint(), e.rpc.DirectEndpoint()),
-		ServerSecret:         e.rpc.ServerSecret(),
-		ClientSecret:         e.rpc.ClientSecret(),
-		TaskID:               task.ID,
-		DirtyMode:            e.dirtyMode,
-		ContainerOptions:     e.containerOptions,
-		TartOptions:          e.tartOptions,
+		ContainerBackendType:       e.containerBackendType,
+		ContainerBackendConnection: e.containerBackendConnection,
+		ProjectDir:                 e.build.ProjectDir,
+		Endpoint:                   endpoint.NewLocal(e.rpc.ContainerEndpoint(), e.rpc.DirectEndpoint()),
+		ServerSecret:               e.rpc.ServerSecret(),
+		ClientSecret:               e.rpc.ClientSecret(),
+		TaskID:                     task.ID,
+		DirtyMode:                  e.dirtyMode,
+		ContainerOptions:           e.containerOptions,
+		TartOptions:                e.tartOptions,
 	}
 
 	instanceRunOpts.SetLogger(taskLogger)
+	instanceRunOpts.SetTaskFailedFunc(func() bool {
+		status := task.Status()
+		return status == taskstatus.Failed || status == taskstatus.TimedOut
+	})
+
+	if e.logsDir != "" {
+		instanceRunOpts.LogsDir = filepath.Join(e.logsDir, taskLogsDirName(task))
+	}
 
 	// Respect custom agent version
 	if agentVersionFromEnv, ok := task.Environment["CIRRUS_AGENT_VERSION"]; ok {
@@ -217,6 +234,72 @@ func (e *Executor) runSingleTask(ctx context.Context, task *build.Task) error {
 	return nil
 }
 
+// prePullImages concurrently pulls all the container images that the tasks would need
+// before running these tasks, so that the tasks don't need to pull them one by one.
+func (e *Executor) prePullImages(ctx context.Context) {
+	references := e.images()
+	if len(references) == 0 {
+		return
+	}
+
+	backendType := e.containerBackendType
+	if backendType == "" {
+		backendType = containerbackend.BackendTypeAuto
+	}
+
+	backend, err := containerbackend.New(backendType, containerbackend.WithConnection(e.containerBackendConnection))
+	if err != nil {
+		e.logger.Debugf("not pre-pulling images: %v", err)
+		return
+	}
+	defer backend.Close()
+
+	prePullLogger := e.logger.Scoped("pre-pull images")
+
+	pulled := pullhelper.PrePull(ctx, references, backend, e.containerOptions, prePullLogger)
+
+	prePullLogger.Finish(true)
+
+	// No need to pull these again when running the tasks
+	e.containerOptions.NoPullImages = append(e.containerOptions.NoPullImages, pulled...)
+}
+
+// images returns the container images used by the tasks that are going to be run.
+func (e *Executor) images() []string {
+	var result []string
+
+	for _, task := range e.build.Tasks() {
+		agentVersion := platform.DefaultAgentVersion
+		if agentVersionFromEnv := task.Environment["CIRRUS_AGENT_VERSION"]; agentVersionFromEnv != "" {
+			agentVersion = agentVersionFromEnv
+		}
+
+		switch inst := task.Instance.(type) {
+		case *container.Instance:
+			result = append(result, inst.Platform.ContainerAgentImage(agentVersion), inst.Image)
+
+			for _, additionalContainer := range inst.AdditionalContainers {
+				result = append(result, additionalContainer.Image)
+			}
+		case *instance.PipeInstance:
+			result = append(result, platform.NewUnix().ContainerAgentImage(agentVersion))
+
+			for _, stage := range inst.Stages {
+				result = append(result, stage.Image)
+			}
+		}
+	}
+
+	return result
+}
+
+// taskLogsDirName returns a name for the task's logs directory that is safe to use on all platforms.
+func taskLogsDirName(task *build.Task) string {
+	name := fmt.Sprintf("%d-%s", task.ID, task.Name)
+
+	return container.SafeFileName(name)
+}
+
 func (e *Executor) transformDockerfileImageIfNeeded(reference string, strict bool) (string, error) {
 	// Modify image name if the user provided a custom template
 	if e.containerOptions.DockerfileImageTemplate == "" {
@@ -14,6 +14,7 @@ import (
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/container"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/options"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/platform"
 	"github.com/cirruslabs/cirrus-cli/internal/testutil"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/local"
@@ -195,6 +196,61 @@ func TestAdditionalContainers(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestAdditionalContainersNetwork ensures that additional containers are reachable by their names
+// and that the readiness command is run before the main container starts.
+func TestAdditionalContainersNetwork(t *testing.T) {
+	// Skip this test on Podman
+	if _, ok := testutil.ContainerBackendFromEnv(t).(*containerbackend.Podman); ok {
+		return
+	}
+
+	dir := testutil.TempDirPopulatedWith(t, "testdata/additional-containers-network")
+	buf := bytes.NewBufferString("")
+	logger := echelon.NewLogger(echelon.TraceLevel, renderers.NewSimpleRenderer(buf, nil))
+	err := testutil.ExecuteWithOptions(t, dir, executor.WithLogger(logger))
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "redis: PONG")
+}
+
+// TestAdditionalContainersLogs ensures that the additional container logs are shown
+// in their own scope and saved to the per-task logs directory.
+func TestAdditionalContainersLogs(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/additional-containers-logs")
+	logsDir := t.TempDir()
+
+	buf := bytes.NewBufferString("")
+	logger := echelon.NewLogger(echelon.TraceLevel, renderers.NewSimpleRenderer(buf, nil))
+	err := testutil.ExecuteWithOptions(t, dir, executor.WithLogger(logger), executor.WithLogsDir(logsDir))
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Additional container chatty")
+	assert.Contains(t, buf.String(), "hello from the additional container")
+
+	logFiles, err := filepath.Glob(filepath.Join(logsDir, "*", "chatty.log"))
+	require.NoError(t, err)
+	require.Len(t, logFiles, 1)
+
+	savedLogs, err := ioutil.ReadFile(logFiles[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(savedLogs), "hello from the additional container")
+}
+
+// TestAdditionalContainersLogsOnFailure ensures that the additional container logs are not shown
+// when the task succeeds and the corresponding option is set.
+func TestAdditionalContainersLogsOnFailure(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/additional-containers-logs")
+	logsDir := t.TempDir()
+
+	buf := bytes.NewBufferString("")
+	logger := echelon.NewLogger(echelon.TraceLevel, renderers.NewSimpleRenderer(buf, nil))
+	err := testutil.ExecuteWithOptions(t, dir, executor.WithLogger(logger), executor.WithLogsDir(logsDir),
+		executor.WithContainerOptions(options.ContainerOptions{AdditionalContainersLogsOnFailure: true}))
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "hello from the additional container")
+	logFiles, err := filepath.Glob(filepath.Join(logsDir, "*", "chatty.log"))
+	require.NoError(t, err)
+	assert.Empty(t, logFiles)
+}
+
 func TestCache(t *testing.T) {
 	dir := testutil.TempDirPopulatedWith(t, "testdata/cache")
 	err := testutil.Execute(t, dir)
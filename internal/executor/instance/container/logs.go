@@ -0,0 +1,184 @@
+package container
+
+import (
+	"fmt"
+	"github.com/cirruslabs/echelon"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// AdditionalContainerLogs captures the output of a single additional container
+// and presents it as a separate scope under the task.
+//
+// When configured to show the logs only on failure, the output is kept in memory
+// until the task's outcome is known.
+type AdditionalContainerLogs struct {
+	name        string
+	logger      *echelon.Logger
+	logsDir     string
+	logFileName string
+	onFailure   bool
+
+	scope    *echelon.Logger
+	file     *os.File
+	buffered []string
+
+	consumers sync.WaitGroup
+}
+
+func NewAdditionalContainerLogs(
+	logger *echelon.Logger,
+	name string,
+	logsDir string,
+	logFileName string,
+	onFailure bool,
+) *AdditionalContainerLogs {
+	return &AdditionalContainerLogs{
+		name:        name,
+		logger:      logger,
+		logsDir:     logsDir,
+		logFileName: logFileName,
+		onFailure:   onFailure,
+	}
+}
+
+// Consume starts reading the log lines from logChan in the background.
+func (acl *AdditionalContainerLogs) Consume(logChan <-chan string) {
+	if !acl.onFailure {
+		acl.open()
+	}
+
+	acl.consumers.Add(1)
+	go func() {
+		defer acl.consumers.Done()
+
+		for line := range logChan {
+			if acl.onFailure {
+				acl.buffered = append(acl.buffered, line)
+			} else {
+				acl.write(line)
+			}
+		}
+	}()
+}
+
+// Finish waits for the log stream to end and, when configured to show the logs only
+// on failure, flushes the buffered log lines if the task has failed.
+func (acl *AdditionalContainerLogs) Finish(failed bool) {
+	acl.consumers.Wait()
+
+	if acl.onFailure {
+		// Don't clutter the output with the empty scopes and log files
+		if !failed || len(acl.buffered) == 0 {
+			return
+		}
+
+		acl.open()
+		for _, line := range acl.buffered {
+			acl.write(line)
+		}
+		acl.buffered = nil
+	}
+
+	if acl.scope != nil {
+		acl.scope.Finish(!failed)
+	}
+
+	if acl.file != nil {
+		if err := acl.file.Close(); err != nil {
+			acl.logger.Warnf("failed to save logs of additional container %s: %v", acl.name, err)
+		}
+	}
+}
+
+func (acl *AdditionalContainerLogs) open() {
+	acl.scope = acl.logger.Scoped(fmt.Sprintf("Additional container %s", acl.name))
+
+	if acl.logsDir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(acl.logsDir, 0700); err != nil {
+		acl.logger.Warnf("failed to create logs directory for additional container %s: %v", acl.name, err)
+		return
+	}
+
+	logPath := filepath.Join(acl.logsDir, acl.logFileName)
+
+	file, err := os.Create(logPath)
+	if err != nil {
+		acl.logger.Warnf("failed to create log file for additional container %s: %v", acl.name, err)
+		return
+	}
+	acl.file = file
+}
+
+func (acl *AdditionalContainerLogs) write(line string) {
+	acl.scope.Infof("%s", line)
+
+	if acl.file != nil {
+		_, _ = fmt.Fprintln(acl.file, line)
+	}
+}
+
+// LogFileNames returns the names for the additional containers' log files that are safe to use
+// on all platforms. The names that would otherwise collide (e.g. the unnamed containers
+// or the names that only differ in the unsafe characters) are disambiguated with an index suffix.
+func LogFileNames(names []string) []string {
+	baseNames := make([]string, len(names))
+	occurrences := map[string]int{}
+
+	for i, name := range names {
+		if name == "" {
+			name = "unnamed"
+		}
+
+		baseNames[i] = SafeFileName(name)
+		occurrences[baseNames[i]]++
+	}
+
+	result := make([]string, len(names))
+	used := map[string]bool{}
+	indices := map[string]int{}
+
+	// Unique names are assigned first, so that the suffixed ones can't take them
+	for i, baseName := range baseNames {
+		if occurrences[baseName] == 1 {
+			result[i] = baseName
+			used[baseName] = true
+		}
+	}
+
+	for i, baseName := range baseNames {
+		if result[i] != "" {
+			continue
+		}
+
+		for {
+			indices[baseName]++
+
+			candidate := fmt.Sprintf("%s-%d", baseName, indices[baseName])
+			if !used[candidate] {
+				result[i] = candidate
+				used[candidate] = true
+
+				break
+			}
+		}
+	}
+
+	for i := range result {
+		result[i] += ".log"
+	}
+
+	return result
+}
+
+// SafeFileName replaces the characters that are not safe to use in a file name on all platforms.
+func SafeFileName(name string) string {
+	return unsafeFileNameCharacters.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,41 @@
+package container_test
+
+import (
+	"bytes"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/container"
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLogFileNames ensures that the additional containers never share a log file.
+func TestLogFileNames(t *testing.T) {
+	names := container.LogFileNames([]string{"", "", "redis", "my db", "my/db", "my_db-1"})
+
+	assert.Equal(t, []string{"unnamed-1.log", "unnamed-2.log", "redis.log", "my_db-2.log", "my_db-3.log", "my_db-1.log"},
+		names)
+}
+
+// TestAdditionalContainerLogsOnFailureWithoutOutput ensures that no scope and no log file
+// are created for a silent additional container when the task fails.
+func TestAdditionalContainerLogsOnFailureWithoutOutput(t *testing.T) {
+	logsDir := t.TempDir()
+
+	buf := bytes.NewBufferString("")
+	logger := echelon.NewLogger(echelon.TraceLevel, renderers.NewSimpleRenderer(buf, nil))
+
+	logChan := make(chan string)
+	close(logChan)
+
+	acLogs := container.NewAdditionalContainerLogs(logger, "silent", logsDir, "silent.log", true)
+	acLogs.Consume(logChan)
+	acLogs.Finish(true)
+
+	assert.NotContains(t, buf.String(), "Additional container silent")
+	_, err := os.Stat(filepath.Join(logsDir, "silent.log"))
+	require.True(t, os.IsNotExist(err))
+}
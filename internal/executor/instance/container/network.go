@@ -0,0 +1,258 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/runconfig"
+	"github.com/cirruslabs/echelon"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReadinessTimeout = 5 * time.Minute
+	readinessInterval       = time.Second
+)
+
+var (
+	ErrNetworkFailed   = errors.New("failed to create task network")
+	ErrReadinessFailed = errors.New("additional container is not ready")
+)
+
+// TaskNetwork isolates the main container and the additional containers of a single task
+// in their own user-defined network.
+//
+// Similarly to a Kubernetes pod, all containers share the network namespace of an idle
+// "network holder" container, which is attached to the user-defined network with the names
+// of the additional containers as aliases. This way the additional containers are reachable
+// both via localhost (which is what Cirrus CI does) and via their names.
+//
+// When the container backend can't create networks (e.g. containerd without CNI), the network holder
+// container is created without one and it's up to the backend to emulate the aliases.
+type TaskNetwork struct {
+	backend        containerbackend.ContainerBackend
+	logger         *echelon.Logger
+	noCleanup      bool
+	networkName    string
+	createdNetwork bool
+	holderID       string
+}
+
+func NewTaskNetwork(
+	ctx context.Context,
+	config *runconfig.RunConfig,
+	params *Params,
+	backend containerbackend.ContainerBackend,
+	existingNetwork string,
+) (*TaskNetwork, error) {
+	taskNetwork := &TaskNetwork{
+		backend:     backend,
+		logger:      config.Logger(),
+		noCleanup:   config.ContainerOptions.NoCleanup,
+		networkName: existingNetwork,
+	}
+
+	// Create a new network unless we're told to use an existing one (e.g. in Cloud Build)
+	if taskNetwork.networkName == "" {
+		taskNetwork.networkName = fmt.Sprintf("cirrus-task-network-%s", uuid.New().String())
+
+		taskNetwork.logger.Debugf("creating task network %s", taskNetwork.networkName)
+		err := backend.NetworkCreate(ctx, taskNetwork.networkName)
+		switch {
+		case errors.Is(err, containerbackend.ErrUnsupported):
+			// Fall back to sharing the network namespace of the network holder container,
+			// which still makes the additional containers reachable via localhost
+			taskNetwork.logger.Warnf("container backend can't create a task network (%v), additional containers "+
+				"will share the network namespace with the main container without isolation from other tasks", err)
+			taskNetwork.networkName = ""
+		case err != nil:
+			return nil, fmt.Errorf("%w: %v", ErrNetworkFailed, err)
+		default:
+			taskNetwork.createdNetwork = true
+		}
+	}
+
+	var aliases []string
+	for _, additionalContainer := range params.AdditionalContainers {
+		if additionalContainer.Name != "" {
+			aliases = append(aliases, additionalContainer.Name)
+		}
+	}
+
+	input := &containerbackend.ContainerCreateInput{
+		Image:          params.Platform.ContainerAgentImage(config.GetAgentVersion()),
+		Entrypoint:     params.Platform.ContainerIdleCommand(),
+		Network:        taskNetwork.networkName,
+		NetworkAliases: aliases,
+	}
+
+	cont, err := backend.ContainerCreate(ctx, input, "")
+	if err != nil {
+		taskNetwork.Close()
+
+		return nil, fmt.Errorf("%w: when creating network holder container: %v", ErrNetworkFailed, err)
+	}
+	taskNetwork.holderID = cont.ID
+
+	if err := backend.ContainerStart(ctx, cont.ID); err != nil {
+		taskNetwork.Close()
+
+		return nil, fmt.Errorf("%w: when starting network holder container: %v", ErrNetworkFailed, err)
+	}
+
+	return taskNetwork, nil
+}
+
+// ContainerNetwork returns a value for the ContainerCreateInput's Network field
+// that makes the container join the task network.
+func (taskNetwork *TaskNetwork) ContainerNetwork() string {
+	return fmt.Sprintf("container:%s", taskNetwork.holderID)
+}
+
+func (taskNetwork *TaskNetwork) Close() {
+	if taskNetwork.noCleanup {
+		if taskNetwork.holderID != "" {
+			taskNetwork.logger.Infof("not cleaning up network holder container %s, don't forget to remove it "+
+				"with \"docker rm -v %s\"", taskNetwork.holderID, taskNetwork.holderID)
+		}
+
+		if taskNetwork.createdNetwork {
+			taskNetwork.logger.Infof("not cleaning up task network %s, don't forget to remove it "+
+				"with \"docker network rm %s\"", taskNetwork.networkName, taskNetwork.networkName)
+		}
+
+		return
+	}
+
+	if taskNetwork.holderID != "" {
+		taskNetwork.logger.Debugf("cleaning up network holder container %s", taskNetwork.holderID)
+
+		if err := taskNetwork.backend.ContainerDelete(context.Background(), taskNetwork.holderID); err != nil {
+			taskNetwork.logger.Warnf("error while removing network holder container: %v", err)
+		}
+	}
+
+	if taskNetwork.createdNetwork {
+		taskNetwork.logger.Debugf("cleaning up task network %s", taskNetwork.networkName)
+
+		if err := taskNetwork.backend.NetworkDelete(context.Background(), taskNetwork.networkName); err != nil {
+			taskNetwork.logger.Warnf("error while removing task network: %v", err)
+		}
+	}
+}
+
+// readinessTimeout returns how long to wait for the additional containers to become ready,
+// which is the time left until the task times out.
+func readinessTimeout(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+
+	return defaultReadinessTimeout
+}
+
+// waitForReadiness repeatedly runs the additional container's readiness command in a short-lived
+// container that uses the same image and shares the task network, until it succeeds.
+func waitForReadiness(
+	ctx context.Context,
+	logger *echelon.Logger,
+	additionalContainer *api.AdditionalContainer,
+	backend containerbackend.ContainerBackend,
+	network string,
+	timeout time.Duration,
+) error {
+	command := strings.Join(additionalContainer.ReadinessCommand, " ")
+
+	logger.Infof("waiting for additional container %s to become ready using %q...",
+		additionalContainer.Name, command)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastOutput []string
+
+	for attempt := 1; ; attempt++ {
+		exitCode, output, err := runReadinessProbe(ctx, additionalContainer, backend, network)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrReadinessFailed, additionalContainer.Name, err)
+		}
+
+		if exitCode == 0 {
+			for _, line := range output {
+				logger.Infof("%s: %s", additionalContainer.Name, line)
+			}
+
+			logger.Infof("additional container %s is ready after %d attempt(s)", additionalContainer.Name, attempt)
+
+			return nil
+		}
+
+		logger.Debugf("readiness command for additional container %s exited with code %d",
+			additionalContainer.Name, exitCode)
+		lastOutput = output
+
+		select {
+		case <-ctx.Done():
+			for _, line := range lastOutput {
+				logger.Warnf("%s: %s", additionalContainer.Name, line)
+			}
+
+			return fmt.Errorf("%w: %s: readiness command %q didn't succeed in %v",
+				ErrReadinessFailed, additionalContainer.Name, command, timeout.Round(time.Second))
+		case <-time.After(readinessInterval):
+		}
+	}
+}
+
+func runReadinessProbe(
+	ctx context.Context,
+	additionalContainer *api.AdditionalContainer,
+	backend containerbackend.ContainerBackend,
+	network string,
+) (int64, []string, error) {
+	input := &containerbackend.ContainerCreateInput{
+		Image:      additionalContainer.Image,
+		Entrypoint: additionalContainer.ReadinessCommand,
+		Env:        additionalContainer.Environment,
+		Network:    network,
+	}
+
+	cont, err := backend.ContainerCreate(ctx, input, "")
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = backend.ContainerDelete(context.Background(), cont.ID)
+	}()
+
+	if err := backend.ContainerStart(ctx, cont.ID); err != nil {
+		return 0, nil, err
+	}
+
+	logChan, err := backend.ContainerLogs(ctx, cont.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	waitChan, errChan := backend.ContainerWait(ctx, cont.ID)
+
+	var exitCode int64
+
+	select {
+	case res := <-waitChan:
+		exitCode = res.StatusCode
+	case err := <-errChan:
+		return 0, nil, err
+	}
+
+	var output []string
+	for line := range logChan {
+		output = append(output, line)
+	}
+
+	return exitCode, output, nil
+}
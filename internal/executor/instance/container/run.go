@@ -18,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -31,7 +32,7 @@ var (
 )
 
 // nolint:gocognit
-func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, params *Params) error {
+func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, params *Params) (err error) {
 	logger := config.Logger()
 	backend, err := config.GetContainerBackend()
 	if err != nil {
@@ -161,6 +162,19 @@ func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, par
 		input.Env["CIRRUS_PORTS_WAIT_FOR"] = commaDelimitedPorts
 	}
 
+	// Isolate the containers of this task in their own network
+	var taskNetwork *TaskNetwork
+
+	if len(params.AdditionalContainers) > 0 {
+		taskNetwork, err = NewTaskNetwork(ctx, config, params, backend, input.Network)
+		if err != nil {
+			return err
+		}
+		defer taskNetwork.Close()
+
+		input.Network = taskNetwork.ContainerNetwork()
+	}
+
 	cont, err := backend.ContainerCreate(ctx, &input, "")
 	if err != nil {
 		return err
@@ -173,11 +187,11 @@ func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, par
 	// and only then all the additional containers will be killed via a separate context
 	// (additionalContainersCtx).
 	var additionalContainersWG sync.WaitGroup
+	var additionalContainersLogs []*AdditionalContainerLogs
 	additionalContainersCtx, additionalContainersCancel := context.WithCancel(context.Background())
 
 	logReaderCtx, cancelLogReaderCtx := context.WithCancel(ctx)
 	var logReaderWg sync.WaitGroup
-	logReaderWg.Add(1)
 
 	// Schedule all containers for removal
 	defer func() {
@@ -186,6 +200,12 @@ func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, par
 		additionalContainersCancel()
 		additionalContainersWG.Wait()
 
+		// Additional containers are gone at this point, so their log streams are finished too
+		taskFailed := err != nil || ctx.Err() != nil || config.TaskFailed()
+		for _, acLogs := range additionalContainersLogs {
+			acLogs.Finish(taskFailed)
+		}
+
 		if config.ContainerOptions.NoCleanup {
 			logger.Infof("not cleaning up container %s, don't forget to remove it with \"docker rm -v %s\"",
 				cont.ID, cont.ID)
@@ -205,18 +225,33 @@ func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, par
 
 	// Start additional containers (if any)
 	additionalContainersErrChan := make(chan error, len(params.AdditionalContainers))
+	var additionalContainersReadyWG sync.WaitGroup
+	var additionalContainersNames []string
 	for _, additionalContainer := range params.AdditionalContainers {
+		additionalContainersNames = append(additionalContainersNames, additionalContainer.Name)
+	}
+	additionalContainersLogFileNames := LogFileNames(additionalContainersNames)
+
+	for i, additionalContainer := range params.AdditionalContainers {
 		additionalContainer := additionalContainer
 
+		acLogs := NewAdditionalContainerLogs(logger, additionalContainer.Name, config.LogsDir,
+			additionalContainersLogFileNames[i], config.ContainerOptions.AdditionalContainersLogsOnFailure)
+		additionalContainersLogs = append(additionalContainersLogs, acLogs)
+
 		additionalContainersWG.Add(1)
+		additionalContainersReadyWG.Add(1)
 		go func() {
 			if err := runAdditionalContainer(
 				additionalContainersCtx,
 				logger,
 				additionalContainer,
 				backend,
-				cont.ID,
+				taskNetwork.ContainerNetwork(),
+				readinessTimeout(ctx),
 				config.ContainerOptions,
+				acLogs,
+				additionalContainersReadyWG.Done,
 			); err != nil {
 				additionalContainersErrChan <- err
 			}
@@ -224,6 +259,23 @@ func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, par
 		}()
 	}
 
+	// Wait for the additional containers to become ready before starting the main container
+	if len(params.AdditionalContainers) > 0 {
+		additionalContainersReady := make(chan struct{})
+		go func() {
+			additionalContainersReadyWG.Wait()
+			close(additionalContainersReady)
+		}()
+
+		select {
+		case <-additionalContainersReady:
+		case acErr := <-additionalContainersErrChan:
+			return acErr
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
 	logger.Debugf("starting container %s", cont.ID)
 	if err := backend.ContainerStart(ctx, cont.ID); err != nil {
 		return err
@@ -233,6 +285,7 @@ func RunContainerizedAgent(ctx context.Context, config *runconfig.RunConfig, par
 	if err != nil {
 		return err
 	}
+	logReaderWg.Add(1)
 	go func() {
 		for logLine := range logChan {
 			logger.Debugf("container: %s", logLine)
@@ -259,9 +312,16 @@ func runAdditionalContainer(
 	logger *echelon.Logger,
 	additionalContainer *api.AdditionalContainer,
 	backend containerbackend.ContainerBackend,
-	connectToContainer string,
+	network string,
+	readinessTimeout time.Duration,
 	containerOptions options.ContainerOptions,
+	acLogs *AdditionalContainerLogs,
+	markReady func(),
 ) error {
+	// Make sure the waiter won't be blocked forever if we fail early
+	var markReadyOnce sync.Once
+	defer markReadyOnce.Do(markReady)
+
 	if err := pullhelper.PullHelper(ctx, additionalContainer.Image, backend, containerOptions, logger); err != nil {
 		return fmt.Errorf("%w: %v", ErrAdditionalContainerFailed, err)
 	}
@@ -275,7 +335,7 @@ func runAdditionalContainer(
 			NanoCPUs: int64(additionalContainer.Cpu * nano),
 			Memory:   int64(additionalContainer.Memory * mebi),
 		},
-		Network: fmt.Sprintf("container:%s", connectToContainer),
+		Network: network,
 	}
 	cont, err := backend.ContainerCreate(ctx, input, "")
 	if err != nil {
@@ -314,6 +374,19 @@ func runAdditionalContainer(
 		return fmt.Errorf("%w: %v", ErrAdditionalContainerFailed, err)
 	}
 
+	logChan, err := backend.ContainerLogs(ctx, cont.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAdditionalContainerFailed, err)
+	}
+	acLogs.Consume(logChan)
+
+	if len(additionalContainer.ReadinessCommand) != 0 {
+		if err := waitForReadiness(ctx, logger, additionalContainer, backend, network, readinessTimeout); err != nil {
+			return err
+		}
+	}
+	markReadyOnce.Do(markReady)
+
 	logger.Debugf("waiting for additional container %s to finish", cont.ID)
 	waitChan, errChan := backend.ContainerWait(ctx, cont.ID)
 	select {
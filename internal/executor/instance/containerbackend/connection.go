@@ -0,0 +1,84 @@
+package containerbackend
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+)
+
+const (
+	SchemeUnix = "unix"
+	SchemeTCP  = "tcp"
+	SchemeSSH  = "ssh"
+)
+
+// Connection describes how to reach the container engine when the defaults
+// (DOCKER_HOST and friends for Docker, a locally spawned service for Podman)
+// are not enough, e.g. when using a shared remote build host or a rootless Podman socket.
+type Connection struct {
+	// Host is a DOCKER_HOST-style URL, for example unix:///run/user/1000/podman/podman.sock,
+	// tcp://build-host:2376 or ssh://user@build-host:22/run/podman/podman.sock.
+	Host string
+
+	// CertPath is a directory containing ca.pem, cert.pem and key.pem
+	// (similarly to DOCKER_CERT_PATH), only used for tcp:// hosts.
+	CertPath string
+
+	// TLSVerify enables the verification of the server's certificate
+	// against the CA from the CertPath.
+	TLSVerify bool
+
+	// Identity is a path to the SSH private key, only used for ssh:// hosts.
+	Identity string
+
+	// PodmanConnection is a name of the connection from containers.conf
+	// (see "podman system connection list"), only used for Podman.
+	PodmanConnection string
+}
+
+type Option func(*options)
+
+type options struct {
+	connection Connection
+}
+
+func WithConnection(connection Connection) Option {
+	return func(opts *options) {
+		opts.connection = connection
+	}
+}
+
+func (connection Connection) validate() error {
+	if connection.TLSVerify && connection.CertPath == "" {
+		return fmt.Errorf("%w: verifying the container engine's TLS certificate requires the path "+
+			"to the directory with the certificates to be specified", ErrNewFailed)
+	}
+
+	return nil
+}
+
+func (connection Connection) URL() (*url.URL, error) {
+	hostURL, err := url.Parse(connection.Host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse container engine host %q: %v",
+			ErrNewFailed, connection.Host, err)
+	}
+
+	switch hostURL.Scheme {
+	case SchemeUnix, SchemeTCP, SchemeSSH:
+		return hostURL, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported container engine host scheme %q, expected %q, %q or %q",
+			ErrNewFailed, hostURL.Scheme, SchemeUnix, SchemeTCP, SchemeSSH)
+	}
+}
+
+func (connection Connection) TLSFiles() (caFile string, certFile string, keyFile string) {
+	if connection.CertPath == "" {
+		return "", "", ""
+	}
+
+	return filepath.Join(connection.CertPath, "ca.pem"),
+		filepath.Join(connection.CertPath, "cert.pem"),
+		filepath.Join(connection.CertPath, "key.pem")
+}
@@ -0,0 +1,21 @@
+package containerbackend_test
+
+import (
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// TestTLSVerifyWithoutCertPath ensures that the TLS verification is not silently ignored
+// when there are no certificates to verify against.
+func TestTLSVerifyWithoutCertPath(t *testing.T) {
+	for _, backendType := range []string{containerbackend.BackendTypeDocker, containerbackend.BackendTypePodman} {
+		_, err := containerbackend.New(backendType, containerbackend.WithConnection(containerbackend.Connection{
+			Host:      "tcp://127.0.0.1:2376",
+			TLSVerify: true,
+		}))
+		require.ErrorIs(t, err, containerbackend.ErrNewFailed, backendType)
+		assert.Contains(t, err.Error(), "TLS certificate", backendType)
+	}
+}
@@ -17,6 +17,7 @@ var (
 	ErrBuildFailed    = errors.New("failed to build image")
 	ErrPushFailed     = errors.New("failed to push container")
 	ErrNotImplemented = errors.New("unimplemented container backend method")
+	ErrUnsupported    = errors.New("unsupported by the container backend")
 )
 
 type ContainerBackend interface {
@@ -32,6 +33,9 @@ type ContainerBackend interface {
 	VolumeInspect(ctx context.Context, name string) error
 	VolumeDelete(ctx context.Context, name string) error
 
+	NetworkCreate(ctx context.Context, name string) error
+	NetworkDelete(ctx context.Context, name string) error
+
 	ContainerCreate(ctx context.Context, input *ContainerCreateInput, name string) (*ContainerCreateOutput, error)
 	ContainerStart(ctx context.Context, id string) error
 	ContainerWait(ctx context.Context, id string) (<-chan ContainerWaitResult, <-chan error)
@@ -55,6 +59,7 @@ type ContainerCreateInput struct {
 	Env            map[string]string
 	Mounts         []ContainerMount
 	Network        string
+	NetworkAliases []string
 	Resources      ContainerResources
 	DisableSELinux bool
 }
@@ -98,29 +103,48 @@ type Version struct {
 }
 
 const (
-	BackendTypeAuto   = "auto"
-	BackendTypeDocker = "docker"
-	BackendTypePodman = "podman"
+	BackendTypeAuto       = "auto"
+	BackendTypeDocker     = "docker"
+	BackendTypePodman     = "podman"
+	BackendTypeContainerd = "containerd"
 )
 
-func New(name string) (ContainerBackend, error) {
+// ResolveName returns the name of the backend that New() would use, which
+// for BackendTypeAuto might be overridden with the CIRRUS_CONTAINER_BACKEND environment variable.
+func ResolveName(name string) string {
 	if name == BackendTypeAuto {
 		if nameFromEnv, ok := os.LookupEnv("CIRRUS_CONTAINER_BACKEND"); ok {
-			name = nameFromEnv
+			return nameFromEnv
 		}
 	}
 
-	switch name {
+	return name
+}
+
+func New(name string, opts ...Option) (ContainerBackend, error) {
+	var backendOpts options
+
+	for _, opt := range opts {
+		opt(&backendOpts)
+	}
+
+	if err := backendOpts.connection.validate(); err != nil {
+		return nil, err
+	}
+
+	switch ResolveName(name) {
 	case BackendTypeDocker:
-		return NewDocker()
+		return NewDocker(backendOpts.connection)
 	case BackendTypePodman:
-		return NewPodman()
+		return NewPodman(backendOpts.connection)
+	case BackendTypeContainerd:
+		return NewContainerd(backendOpts.connection)
 	case BackendTypeAuto:
-		if backend, err := NewDocker(); err == nil {
+		if backend, err := NewDocker(backendOpts.connection); err == nil {
 			return backend, nil
 		}
 
-		if backend, err := NewPodman(); err == nil {
+		if backend, err := NewPodman(backendOpts.connection); err == nil {
 			return backend, nil
 		}
 
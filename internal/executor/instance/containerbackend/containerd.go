@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package containerbackend
+
+import "fmt"
+
+type Containerd struct {
+	Unimplemented
+}
+
+func NewContainerd(connection Connection) (ContainerBackend, error) {
+	return nil, fmt.Errorf("%w: containerd is only supported on Linux", ErrNewFailed)
+}
@@ -0,0 +1,757 @@
+package containerbackend
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/avast/retry-go"
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/oci"
+	refdocker "github.com/containerd/containerd/reference/docker"
+	"github.com/containerd/containerd/remotes"
+	remotesdocker "github.com/containerd/containerd/remotes/docker"
+	"github.com/containerd/containerd/remotes/docker/config"
+	dockerconfig "github.com/docker/cli/cli/config"
+	"github.com/google/uuid"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultContainerdAddress   = "/run/containerd/containerd.sock"
+	defaultContainerdNamespace = "default"
+	containerdCPUPeriod        = 100_000
+	containerdLogPollInterval  = 100 * time.Millisecond
+)
+
+var ErrContainerd = errors.New("containerd error")
+
+// Containerd talks to the containerd daemon directly. Images and containers are created in the same
+// namespace as nerdctl uses by default, so they can be inspected with "nerdctl images" and "nerdctl ps -a".
+// The images are built with "nerdctl build", which requires BuildKit to be running.
+//
+// Since containerd has no notion of volumes, these are emulated with directories bind-mounted into
+// the containers, and since there's no CNI involved, the containers share the host's network namespace
+// (unless asked to join the network namespace of another container).
+//
+// This means that the services started by the concurrently running tasks are not isolated from each other
+// and may collide when listening on the same ports. The network aliases are emulated with an /etc/hosts
+// that points them to the loopback interface.
+type Containerd struct {
+	cli       *containerd.Client
+	address   string
+	namespace string
+	dataDir   string
+
+	exits     map[string]*containerdExit
+	exitsLock sync.Mutex
+}
+
+// containerdExit allows multiple parties (ContainerWait() and ContainerLogs())
+// to be notified about the task's exit, which containerd only reports once.
+type containerdExit struct {
+	done   chan struct{}
+	status containerd.ExitStatus
+}
+
+func NewContainerd(connection Connection) (ContainerBackend, error) {
+	address := defaultContainerdAddress
+	if addressFromEnv, ok := os.LookupEnv("CONTAINERD_ADDRESS"); ok {
+		address = addressFromEnv
+	}
+
+	if connection.Host != "" {
+		hostURL, err := connection.URL()
+		if err != nil {
+			return nil, err
+		}
+
+		if hostURL.Scheme != SchemeUnix {
+			return nil, fmt.Errorf("%w: containerd backend only supports %s:// hosts",
+				ErrNewFailed, SchemeUnix)
+		}
+
+		address = hostURL.Path
+	}
+
+	namespace := defaultContainerdNamespace
+	if namespaceFromEnv, ok := os.LookupEnv("CONTAINERD_NAMESPACE"); ok {
+		namespace = namespaceFromEnv
+	}
+
+	cli, err := containerd.New(address, containerd.WithDefaultNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cli.Version(context.Background()); err != nil {
+		_ = cli.Close()
+
+		return nil, err
+	}
+
+	// Scope the emulated volumes, logs and hosts files to this backend instance
+	// so that the concurrently running tasks don't step on each other
+	dataDir, err := ioutil.TempDir("", "cirrus-containerd-")
+	if err != nil {
+		_ = cli.Close()
+
+		return nil, err
+	}
+
+	return &Containerd{
+		cli:       cli,
+		address:   address,
+		namespace: namespace,
+		dataDir:   dataDir,
+		exits:     map[string]*containerdExit{},
+	}, nil
+}
+
+func (backend *Containerd) Close() error {
+	// Only remove the data directory when nothing was left behind (e.g. due to --debug-no-cleanup)
+	for _, dir := range []string{"volumes", "logs", "hosts", ""} {
+		_ = os.Remove(filepath.Join(backend.dataDir, dir))
+	}
+
+	return backend.cli.Close()
+}
+
+func (backend *Containerd) ImagePull(ctx context.Context, reference string) error {
+	normalizedReference, err := normalizeReference(reference)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.cli.Pull(ctx, normalizedReference,
+		containerd.WithPullUnpack,
+		containerd.WithResolver(containerdResolver()),
+	)
+
+	return err
+}
+
+func (backend *Containerd) ImagePush(ctx context.Context, reference string) error {
+	normalizedReference, err := normalizeReference(reference)
+	if err != nil {
+		return err
+	}
+
+	image, err := backend.cli.GetImage(ctx, normalizedReference)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPushFailed, err)
+	}
+
+	if err := backend.cli.Push(ctx, normalizedReference, image.Target(),
+		containerd.WithResolver(containerdResolver())); err != nil {
+		return fmt.Errorf("%w: %v", ErrPushFailed, err)
+	}
+
+	return nil
+}
+
+func (backend *Containerd) ImageBuild(
+	ctx context.Context,
+	tarball io.Reader,
+	input *ImageBuildInput,
+) (<-chan string, <-chan error) {
+	logChan := make(chan string)
+	errChan := make(chan error)
+
+	go func() {
+		contextDir, err := ioutil.TempDir("", "cirrus-containerd-build-")
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer os.RemoveAll(contextDir)
+
+		if err := extractTarball(tarball, contextDir); err != nil {
+			errChan <- fmt.Errorf("%w: when extracting build context: %v", ErrBuildFailed, err)
+			return
+		}
+
+		// containerd has no image building capabilities on it's own,
+		// so delegate to nerdctl, which talks to BuildKit (the latter
+		// resolves the base images by itself, so input.Pull is not used)
+		args := []string{
+			"--address", backend.address,
+			"--namespace", backend.namespace,
+			"build",
+			"--progress", "plain",
+			"--file", filepath.Join(contextDir, input.Dockerfile),
+		}
+		for _, tag := range input.Tags {
+			args = append(args, "--tag", tag)
+		}
+		for key, value := range input.BuildArgs {
+			args = append(args, "--build-arg", fmt.Sprintf("%s=%s", key, value))
+		}
+		args = append(args, contextDir)
+
+		cmd := exec.CommandContext(ctx, "nerdctl", args...)
+
+		output, err := cmd.StdoutPipe()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		cmd.Stderr = cmd.Stdout
+
+		if err := cmd.Start(); err != nil {
+			errChan <- fmt.Errorf("%w: building images with containerd requires nerdctl and BuildKit: %v",
+				ErrBuildFailed, err)
+			return
+		}
+
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			logChan <- scanner.Text()
+		}
+
+		if err := cmd.Wait(); err != nil {
+			errChan <- fmt.Errorf("%w: %v", ErrBuildFailed, err)
+			return
+		}
+
+		errChan <- ErrDone
+	}()
+
+	return logChan, errChan
+}
+
+// extractTarball unpacks the build context created by instance.CreateTempArchive(),
+// which only contains regular files.
+func extractTarball(tarball io.Reader, dir string) error {
+	archive := tar.NewReader(tarball)
+
+	for {
+		header, err := archive.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		path := filepath.Join(dir, filepath.Clean("/"+header.Name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(file, archive)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (backend *Containerd) ImageInspect(ctx context.Context, reference string) error {
+	normalizedReference, err := normalizeReference(reference)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.cli.GetImage(ctx, normalizedReference)
+	if errdefs.IsNotFound(err) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (backend *Containerd) ImageDelete(ctx context.Context, reference string) error {
+	normalizedReference, err := normalizeReference(reference)
+	if err != nil {
+		return err
+	}
+
+	err = backend.cli.ImageService().Delete(ctx, normalizedReference, images.SynchronousDelete())
+	if errdefs.IsNotFound(err) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (backend *Containerd) VolumeCreate(ctx context.Context, name string) error {
+	return os.MkdirAll(backend.volumePath(name), 0700)
+}
+
+func (backend *Containerd) VolumeInspect(ctx context.Context, name string) error {
+	_, err := os.Stat(backend.volumePath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (backend *Containerd) VolumeDelete(ctx context.Context, name string) error {
+	return os.RemoveAll(backend.volumePath(name))
+}
+
+func (backend *Containerd) volumePath(name string) string {
+	return filepath.Join(backend.dataDir, "volumes", name)
+}
+
+func (backend *Containerd) logPath(id string) string {
+	return filepath.Join(backend.dataDir, "logs", id+".log")
+}
+
+func (backend *Containerd) ContainerCreate(
+	ctx context.Context,
+	input *ContainerCreateInput,
+	name string,
+) (*ContainerCreateOutput, error) {
+	normalizedReference, err := normalizeReference(input.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := backend.cli.GetImage(ctx, normalizedReference)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: no such image: %q", ErrContainerd, input.Image)
+		}
+
+		return nil, err
+	}
+
+	if name == "" {
+		name = uuid.New().String()
+	}
+
+	specOpts := []oci.SpecOpts{
+		oci.WithImageConfig(image),
+		oci.WithEnv(envMapToSlice(input.Env)),
+	}
+
+	if len(input.Entrypoint) != 0 {
+		specOpts = append(specOpts, oci.WithProcessArgs(append(input.Entrypoint, input.Command...)...))
+	} else if len(input.Command) != 0 {
+		specOpts = append(specOpts, oci.WithImageConfigArgs(image, input.Command))
+	}
+
+	var mounts []specs.Mount
+
+	for _, ourMount := range input.Mounts {
+		source := ourMount.Source
+
+		switch ourMount.Type {
+		case MountTypeBind:
+		case MountTypeVolume:
+			source = backend.volumePath(ourMount.Source)
+		default:
+			continue
+		}
+
+		options := []string{"rbind", "rw"}
+		if ourMount.ReadOnly {
+			options = []string{"rbind", "ro"}
+		}
+
+		mounts = append(mounts, specs.Mount{
+			Type:        "bind",
+			Source:      source,
+			Destination: ourMount.Target,
+			Options:     options,
+		})
+	}
+	specOpts = append(specOpts, oci.WithMounts(mounts))
+
+	// Containers that join other container's network namespace are
+	// finalized in ContainerStart(), once the other container runs
+	if !strings.HasPrefix(input.Network, "container:") {
+		specOpts = append(specOpts,
+			oci.WithHostNamespace(specs.NetworkNamespace),
+			oci.WithHostResolvconf,
+		)
+
+		hostsFileOpt, err := backend.hostsFile(name, input.NetworkAliases)
+		if err != nil {
+			return nil, err
+		}
+		specOpts = append(specOpts, hostsFileOpt)
+	}
+
+	if input.Resources.NanoCPUs != 0 {
+		quota := input.Resources.NanoCPUs * containerdCPUPeriod / 1_000_000_000
+		specOpts = append(specOpts, oci.WithCPUCFS(quota, containerdCPUPeriod))
+	}
+
+	if input.Resources.Memory != 0 {
+		specOpts = append(specOpts, oci.WithMemoryLimit(uint64(input.Resources.Memory)))
+	}
+
+	cont, err := backend.cli.NewContainer(ctx, name,
+		containerd.WithImage(image),
+		containerd.WithNewSnapshot(name+"-snapshot", image),
+		containerd.WithNewSpec(specOpts...),
+		containerd.WithContainerLabels(map[string]string{
+			"cirrus-cli/network": input.Network,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContainerCreateOutput{
+		ID: cont.ID(),
+	}, nil
+}
+
+func (backend *Containerd) ContainerStart(ctx context.Context, id string) error {
+	cont, err := backend.cli.LoadContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := backend.joinNetworkIfNeeded(ctx, cont); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(backend.logPath(id)), 0700); err != nil {
+		return err
+	}
+
+	task, err := cont.NewTask(ctx, cio.LogFile(backend.logPath(id)))
+	if err != nil {
+		return err
+	}
+
+	// Subscribe to the exit status before starting the task to avoid missing it
+	exitChan, err := task.Wait(context.Background())
+	if err != nil {
+		return err
+	}
+
+	exit := &containerdExit{
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		exit.status = <-exitChan
+		close(exit.done)
+	}()
+
+	backend.exitsLock.Lock()
+	backend.exits[id] = exit
+	backend.exitsLock.Unlock()
+
+	return task.Start(ctx)
+}
+
+func (backend *Containerd) joinNetworkIfNeeded(ctx context.Context, cont containerd.Container) error {
+	labels, err := cont.Labels(ctx)
+	if err != nil {
+		return err
+	}
+
+	network := labels["cirrus-cli/network"]
+	if !strings.HasPrefix(network, "container:") {
+		return nil
+	}
+
+	// The other container might be started concurrently, so wait for it's task to appear
+	var pid uint32
+
+	err = retry.Do(func() error {
+		otherCont, err := backend.cli.LoadContainer(ctx, strings.TrimPrefix(network, "container:"))
+		if err != nil {
+			return err
+		}
+
+		otherTask, err := otherCont.Task(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		pid = otherTask.Pid()
+
+		return nil
+	}, retry.Context(ctx))
+	if err != nil {
+		return fmt.Errorf("%w: failed to join the network of %s: %v", ErrContainerd, network, err)
+	}
+
+	spec, err := cont.Spec(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Share the /etc/hosts with the other container so that it's network aliases are resolvable
+	hostsFileOpt := oci.WithHostHostsFile
+	otherHostsPath := backend.hostsPath(strings.TrimPrefix(network, "container:"))
+	if _, err := os.Stat(otherHostsPath); err == nil {
+		hostsFileOpt = withHostsFileAt(otherHostsPath)
+	}
+
+	return cont.Update(ctx, func(ctx context.Context, client *containerd.Client, c *containers.Container) error {
+		return containerd.WithSpec(spec,
+			oci.WithLinuxNamespace(specs.LinuxNamespace{
+				Type: specs.NetworkNamespace,
+				Path: fmt.Sprintf("/proc/%d/ns/net", pid),
+			}),
+			oci.WithHostResolvconf,
+			hostsFileOpt,
+		)(ctx, client, c)
+	})
+}
+
+func (backend *Containerd) hostsPath(id string) string {
+	return filepath.Join(backend.dataDir, "hosts", id)
+}
+
+// hostsFile emulates network aliases by generating an /etc/hosts that points them to the loopback
+// interface, which works because all containers of a task share the same network namespace.
+func (backend *Containerd) hostsFile(id string, aliases []string) (oci.SpecOpts, error) {
+	if len(aliases) == 0 {
+		return oci.WithHostHostsFile, nil
+	}
+
+	hostsPath := backend.hostsPath(id)
+
+	if err := os.MkdirAll(filepath.Dir(hostsPath), 0700); err != nil {
+		return nil, err
+	}
+
+	hosts := fmt.Sprintf("127.0.0.1\tlocalhost %s\n::1\tlocalhost\n", strings.Join(aliases, " "))
+
+	if err := ioutil.WriteFile(hostsPath, []byte(hosts), 0600); err != nil {
+		return nil, err
+	}
+
+	return withHostsFileAt(hostsPath), nil
+}
+
+func withHostsFileAt(path string) oci.SpecOpts {
+	return oci.WithMounts([]specs.Mount{
+		{
+			Type:        "bind",
+			Source:      path,
+			Destination: "/etc/hosts",
+			Options:     []string{"rbind", "ro"},
+		},
+	})
+}
+
+func (backend *Containerd) NetworkCreate(ctx context.Context, name string) error {
+	// Containers always run in the host's network namespace (see hostsFile()),
+	// so there's no way to create an isolated network without CNI
+	return fmt.Errorf("%w: task networks require CNI", ErrUnsupported)
+}
+
+func (backend *Containerd) NetworkDelete(ctx context.Context, name string) error {
+	return ErrNotFound
+}
+
+func (backend *Containerd) ContainerWait(ctx context.Context, id string) (<-chan ContainerWaitResult, <-chan error) {
+	waitChan := make(chan ContainerWaitResult)
+	errChan := make(chan error)
+
+	go func() {
+		exit, err := backend.exit(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+
+		select {
+		case <-exit.done:
+			result := ContainerWaitResult{
+				StatusCode: int64(exit.status.ExitCode()),
+			}
+
+			if err := exit.status.Error(); err != nil {
+				result.Error = err.Error()
+			}
+
+			waitChan <- result
+		case <-ctx.Done():
+			errChan <- ctx.Err()
+		}
+	}()
+
+	return waitChan, errChan
+}
+
+func (backend *Containerd) ContainerLogs(ctx context.Context, id string) (<-chan string, error) {
+	logChan := make(chan string, containerLogsChannelSize)
+
+	exit, err := backend.exit(id)
+	if err != nil {
+		return nil, err
+	}
+
+	logFile, err := os.Open(backend.logPath(id))
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer close(logChan)
+		defer logFile.Close()
+
+		reader := bufio.NewReader(logFile)
+		var pending string
+		var exited bool
+
+		// Follow the log file until the container exits and there's nothing left to read
+		for {
+			chunk, err := reader.ReadString('\n')
+			pending += chunk
+
+			if err == nil {
+				logChan <- strings.TrimSuffix(pending, "\n")
+				pending = ""
+
+				continue
+			}
+
+			if !errors.Is(err, io.EOF) || exited {
+				if pending != "" {
+					logChan <- pending
+				}
+
+				return
+			}
+
+			select {
+			case <-exit.done:
+				// Do a final read to catch up with the output written before the exit
+				exited = true
+			case <-ctx.Done():
+				return
+			case <-time.After(containerdLogPollInterval):
+			}
+		}
+	}()
+
+	return logChan, nil
+}
+
+func (backend *Containerd) exit(id string) (*containerdExit, error) {
+	backend.exitsLock.Lock()
+	defer backend.exitsLock.Unlock()
+
+	exit, ok := backend.exits[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: container %s was not started", ErrContainerd, id)
+	}
+
+	return exit, nil
+}
+
+func (backend *Containerd) ContainerDelete(ctx context.Context, id string) error {
+	cont, err := backend.cli.LoadContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	task, err := cont.Task(ctx, nil)
+	if err == nil {
+		_ = task.Kill(ctx, syscall.SIGKILL)
+
+		if _, err := task.Delete(ctx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+	} else if !errdefs.IsNotFound(err) {
+		return err
+	}
+
+	backend.exitsLock.Lock()
+	delete(backend.exits, id)
+	backend.exitsLock.Unlock()
+
+	for _, path := range []string{backend.logPath(id), backend.hostsPath(id)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return cont.Delete(ctx, containerd.WithSnapshotCleanup)
+}
+
+func (backend *Containerd) SystemInfo(ctx context.Context) (*SystemInfo, error) {
+	version, err := backend.cli.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sysinfo unix.Sysinfo_t
+	if err := unix.Sysinfo(&sysinfo); err != nil {
+		return nil, err
+	}
+
+	return &SystemInfo{
+		Version:          version.Version,
+		TotalCPUs:        int64(runtime.NumCPU()),
+		TotalMemoryBytes: int64(sysinfo.Totalram) * int64(sysinfo.Unit),
+	}, nil
+}
+
+func normalizeReference(reference string) (string, error) {
+	named, err := refdocker.ParseDockerRef(reference)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to parse image reference %q: %v", ErrContainerd, reference, err)
+	}
+
+	return named.String(), nil
+}
+
+// containerdResolver returns a resolver that uses the same credentials as the Docker CLI does.
+func containerdResolver() remotes.Resolver {
+	dockerConfig := dockerconfig.LoadDefaultConfigFile(ioutil.Discard)
+
+	return remotesdocker.NewResolver(remotesdocker.ResolverOptions{
+		Hosts: config.ConfigureHosts(context.Background(), config.HostOptions{
+			Credentials: func(host string) (string, string, error) {
+				if host == "registry-1.docker.io" {
+					host = "https://index.docker.io/v1/"
+				}
+
+				authConfig, err := dockerConfig.GetAuthConfig(host)
+				if err != nil {
+					return "", "", err
+				}
+
+				if authConfig.IdentityToken != "" {
+					return "", authConfig.IdentityToken, nil
+				}
+
+				return authConfig.Username, authConfig.Password, nil
+			},
+		}),
+	})
+}
@@ -0,0 +1,139 @@
+package containerbackend_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+const containerdTestImage = "docker.io/library/alpine:latest"
+
+// newContainerd instantiates the containerd backend when it's explicitly selected,
+// since unlike Docker and Podman, it's not the one that's usually available.
+func newContainerd(t *testing.T) containerbackend.ContainerBackend {
+	if os.Getenv("CIRRUS_CONTAINER_BACKEND") != containerbackend.BackendTypeContainerd {
+		t.SkipNow()
+	}
+
+	backend, err := containerbackend.New(containerbackend.BackendTypeContainerd)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = backend.Close()
+	})
+
+	return backend
+}
+
+func TestContainerdContainerLifecycle(t *testing.T) {
+	backend := newContainerd(t)
+	ctx := context.Background()
+
+	require.NoError(t, backend.ImagePull(ctx, containerdTestImage))
+	require.NoError(t, backend.ImageInspect(ctx, containerdTestImage))
+
+	volumeName := "cirrus-test-" + uuid.New().String()
+	require.NoError(t, backend.VolumeCreate(ctx, volumeName))
+	require.NoError(t, backend.VolumeInspect(ctx, volumeName))
+	defer func() {
+		require.NoError(t, backend.VolumeDelete(ctx, volumeName))
+		assert.ErrorIs(t, backend.VolumeInspect(ctx, volumeName), containerbackend.ErrNotFound)
+	}()
+
+	cont, err := backend.ContainerCreate(ctx, &containerbackend.ContainerCreateInput{
+		Image:      containerdTestImage,
+		Entrypoint: []string{"/bin/sh", "-c"},
+		Command:    []string{"echo $GREETING > /volume/greeting && cat /volume/greeting && exit 3"},
+		Env:        map[string]string{"GREETING": "hello"},
+		Mounts: []containerbackend.ContainerMount{
+			{Type: containerbackend.MountTypeVolume, Source: volumeName, Target: "/volume"},
+		},
+	}, "")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, backend.ContainerDelete(ctx, cont.ID))
+	}()
+
+	require.NoError(t, backend.ContainerStart(ctx, cont.ID))
+
+	logChan, err := backend.ContainerLogs(ctx, cont.ID)
+	require.NoError(t, err)
+
+	waitChan, errChan := backend.ContainerWait(ctx, cont.ID)
+	select {
+	case result := <-waitChan:
+		assert.EqualValues(t, 3, result.StatusCode)
+	case err := <-errChan:
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	for line := range logChan {
+		logs.WriteString(line + "\n")
+	}
+	assert.Equal(t, "hello\n", logs.String())
+}
+
+func TestContainerdImageBuild(t *testing.T) {
+	backend := newContainerd(t)
+	ctx := context.Background()
+
+	dockerfile := []byte("FROM " + containerdTestImage + "\nARG GREETING\nRUN echo $GREETING > /greeting\n")
+
+	var tarball bytes.Buffer
+	archive := tar.NewWriter(&tarball)
+	require.NoError(t, archive.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "ci/Dockerfile",
+		Mode:     0600,
+		Size:     int64(len(dockerfile)),
+	}))
+	_, err := archive.Write(dockerfile)
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	image := "cirrus-test-" + uuid.New().String() + ":latest"
+
+	logChan, errChan := backend.ImageBuild(ctx, &tarball, &containerbackend.ImageBuildInput{
+		Tags:       []string{image},
+		Dockerfile: "ci/Dockerfile",
+		BuildArgs:  map[string]string{"GREETING": "hello"},
+	})
+
+Outer:
+	for {
+		select {
+		case line := <-logChan:
+			t.Log(line)
+		case err := <-errChan:
+			require.ErrorIs(t, err, containerbackend.ErrDone)
+			break Outer
+		}
+	}
+
+	require.NoError(t, backend.ImageInspect(ctx, image))
+	require.NoError(t, backend.ImageDelete(ctx, image))
+}
+
+func TestContainerdUnsupported(t *testing.T) {
+	backend := newContainerd(t)
+
+	err := backend.NetworkCreate(context.Background(), "cirrus-task-network")
+	assert.ErrorIs(t, err, containerbackend.ErrUnsupported)
+}
+
+func TestContainerdSystemInfo(t *testing.T) {
+	backend := newContainerd(t)
+
+	info, err := backend.SystemInfo(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, info.Version)
+	assert.NotZero(t, info.TotalCPUs)
+	assert.NotZero(t, info.TotalMemoryBytes)
+}
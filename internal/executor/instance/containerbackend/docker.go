@@ -10,23 +10,36 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend/docker"
+	"github.com/docker/cli/cli/connhelper"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/tlsconfig"
 	"io"
-	"io/ioutil"
+	"net/http"
 )
 
 type Docker struct {
 	cli *client.Client
 }
 
-func NewDocker() (ContainerBackend, error) {
+func NewDocker(connection Connection) (ContainerBackend, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+
+	// Explicitly configured connection takes precedence over the DOCKER_HOST and friends
+	connectionOpts, err := dockerConnectionOpts(connection)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, connectionOpts...)
+
 	// Create Docker client
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,21 +54,121 @@ func NewDocker() (ContainerBackend, error) {
 	}, nil
 }
 
+func dockerConnectionOpts(connection Connection) ([]client.Opt, error) {
+	if connection.Host == "" {
+		return nil, nil
+	}
+
+	hostURL, err := connection.URL()
+	if err != nil {
+		return nil, err
+	}
+
+	if hostURL.Scheme == SchemeSSH {
+		var sshFlags []string
+
+		if connection.Identity != "" {
+			sshFlags = append(sshFlags, "-i", connection.Identity)
+		}
+
+		helper, err := connhelper.GetConnectionHelperWithSSHOpts(connection.Host, sshFlags)
+		if err != nil {
+			return nil, err
+		}
+
+		return []client.Opt{
+			client.WithHTTPClient(&http.Client{
+				Transport: &http.Transport{
+					DialContext: helper.Dialer,
+				},
+			}),
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+		}, nil
+	}
+
+	var opts []client.Opt
+
+	if connection.CertPath != "" {
+		caFile, certFile, keyFile := connection.TLSFiles()
+
+		tlsConfig, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             caFile,
+			CertFile:           certFile,
+			KeyFile:            keyFile,
+			InsecureSkipVerify: !connection.TLSVerify,
+			ExclusiveRootPools: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, client.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+			CheckRedirect: client.CheckRedirect,
+		}))
+	}
+
+	// Should come after the WithHTTPClient() because it configures the client's transport
+	opts = append(opts, client.WithHost(connection.Host))
+
+	return opts, nil
+}
+
 func (backend *Docker) Close() error {
 	return backend.cli.Close()
 }
 
 func (backend *Docker) ImagePull(ctx context.Context, reference string) error {
+	return backend.ImagePullWithProgress(ctx, reference, nil)
+}
+
+func (backend *Docker) ImagePullWithProgress(
+	ctx context.Context,
+	reference string,
+	progress func(ImagePullProgress),
+) error {
 	stream, err := backend.cli.ImagePull(ctx, reference, types.ImagePullOptions{})
 	if err != nil {
 		return err
 	}
+	defer stream.Close()
 
-	if _, err = io.Copy(ioutil.Discard, stream); err != nil {
-		return err
-	}
+	decoder := json.NewDecoder(stream)
 
-	return nil
+	for {
+		var message jsonmessage.JSONMessage
+
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if message.Error != nil {
+			return message.Error
+		}
+
+		// Messages without an ID describe the image as a whole (e.g. "Digest: ...")
+		if progress == nil || message.ID == "" {
+			continue
+		}
+
+		layerProgress := ImagePullProgress{
+			Layer:  message.ID,
+			Status: message.Status,
+		}
+		if message.Progress != nil {
+			layerProgress.Current = message.Progress.Current
+			layerProgress.Total = message.Progress.Total
+		}
+
+		progress(layerProgress)
+	}
 }
 
 func (backend *Docker) ImagePush(ctx context.Context, reference string) error {
@@ -184,6 +297,24 @@ func (backend *Docker) VolumeDelete(ctx context.Context, name string) error {
 	return backend.cli.VolumeRemove(ctx, name, false)
 }
 
+func (backend *Docker) NetworkCreate(ctx context.Context, name string) error {
+	_, err := backend.cli.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+	})
+
+	return err
+}
+
+func (backend *Docker) NetworkDelete(ctx context.Context, name string) error {
+	err := backend.cli.NetworkRemove(ctx, name)
+
+	if client.IsErrNotFound(err) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
 func (backend *Docker) ContainerCreate(
 	ctx context.Context,
 	input *ContainerCreateInput,
@@ -228,7 +359,19 @@ func (backend *Docker) ContainerCreate(
 		hostConfig.SecurityOpt = []string{"label=disable"}
 	}
 
-	cont, err := backend.cli.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, nil, name)
+	var networkingConfig *network.NetworkingConfig
+
+	if len(input.NetworkAliases) != 0 {
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				input.Network: {
+					Aliases: input.NetworkAliases,
+				},
+			},
+		}
+	}
+
+	cont, err := backend.cli.ContainerCreate(ctx, &containerConfig, &hostConfig, networkingConfig, nil, name)
 	if err != nil {
 		return nil, err
 	}
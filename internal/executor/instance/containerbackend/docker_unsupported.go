@@ -9,6 +9,6 @@ type Docker struct {
 	Unimplemented
 }
 
-func NewDocker() (ContainerBackend, error) {
+func NewDocker(connection Connection) (ContainerBackend, error) {
 	return nil, fmt.Errorf("%w: Docker is only supported on Linux, macOS and Windows", ErrNewFailed)
 }
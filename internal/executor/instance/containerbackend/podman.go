@@ -9,6 +9,6 @@ type Podman struct {
 	Unimplemented
 }
 
-func NewPodman() (ContainerBackend, error) {
+func NewPodman(connection Connection) (ContainerBackend, error) {
 	return nil, fmt.Errorf("%w: Podman is only supported on Linux", ErrNewFailed)
 }
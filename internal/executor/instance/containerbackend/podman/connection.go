@@ -0,0 +1,78 @@
+package podman
+
+import (
+	"errors"
+	"fmt"
+	"github.com/pelletier/go-toml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var ErrConnectionNotFound = errors.New("Podman connection not found")
+
+// ServiceDestination mirrors the [engine.service_destinations.<name>] table of containers.conf[1].
+//
+// [1]: https://github.com/containers/common/blob/main/docs/containers.conf.5.md#service-destinations-table
+type ServiceDestination struct {
+	URI      string `toml:"uri"`
+	Identity string `toml:"identity"`
+}
+
+type containersConf struct {
+	Engine struct {
+		ServiceDestinations map[string]ServiceDestination `toml:"service_destinations"`
+	} `toml:"engine"`
+}
+
+// LookupServiceDestination finds a named connection (as shown by "podman system connection list")
+// in the system-wide and user-specific containers.conf files, with the latter taking precedence.
+func LookupServiceDestination(name string) (*ServiceDestination, error) {
+	var result *ServiceDestination
+
+	for _, path := range containersConfPaths() {
+		confBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		var conf containersConf
+
+		if err := toml.Unmarshal(confBytes, &conf); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		if destination, ok := conf.Engine.ServiceDestinations[name]; ok {
+			destinationCopy := destination
+			result = &destinationCopy
+		}
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("%w: no connection named %q in containers.conf", ErrConnectionNotFound, name)
+	}
+
+	return result, nil
+}
+
+func containersConfPaths() []string {
+	// Podman only reads the file specified in CONTAINERS_CONF when it's set
+	if path, ok := os.LookupEnv("CONTAINERS_CONF"); ok {
+		return []string{path}
+	}
+
+	paths := []string{
+		"/usr/share/containers/containers.conf",
+		"/etc/containers/containers.conf",
+	}
+
+	if configDir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(configDir, "containers", "containers.conf"))
+	}
+
+	return paths
+}
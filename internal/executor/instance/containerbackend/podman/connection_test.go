@@ -0,0 +1,35 @@
+package podman_test
+
+import (
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend/podman"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupServiceDestination(t *testing.T) {
+	confPath := filepath.Join(t.TempDir(), "containers.conf")
+
+	conf := `[engine]
+active_service = "build-host"
+
+[engine.service_destinations]
+  [engine.service_destinations.build-host]
+  uri = "ssh://core@build-host:2222/run/user/1000/podman/podman.sock"
+  identity = "/home/user/.ssh/id_ed25519"
+`
+	require.NoError(t, ioutil.WriteFile(confPath, []byte(conf), 0600))
+	t.Setenv("CONTAINERS_CONF", confPath)
+
+	destination, err := podman.LookupServiceDestination("build-host")
+	require.NoError(t, err)
+	assert.Equal(t, &podman.ServiceDestination{
+		URI:      "ssh://core@build-host:2222/run/user/1000/podman/podman.sock",
+		Identity: "/home/user/.ssh/id_ed25519",
+	}, destination)
+
+	_, err = podman.LookupServiceDestination("nonexistent")
+	assert.ErrorIs(t, err, podman.ErrConnectionNotFound)
+}
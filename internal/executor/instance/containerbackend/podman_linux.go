@@ -11,7 +11,9 @@ import (
 	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend/podman"
 	"github.com/cirruslabs/podmanapi/pkg/swagger"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/tlsconfig"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
 	"io"
 	"net"
 	"net/http"
@@ -26,8 +28,11 @@ import (
 
 var ErrPodman = errors.New("Podman error")
 
+const defaultPodmanRemoteSocket = "/run/podman/podman.sock"
+
 type Podman struct {
 	cmd        *exec.Cmd
+	sshClient  *ssh.Client
 	basePath   string
 	httpClient *http.Client
 	cli        *swagger.APIClient
@@ -35,7 +40,82 @@ type Podman struct {
 	usingNumericalContainerState bool
 }
 
-func NewPodman() (ContainerBackend, error) {
+func NewPodman(connection Connection) (ContainerBackend, error) {
+	connection, err := resolvePodmanConnection(connection)
+	if err != nil {
+		return nil, err
+	}
+
+	podman := &Podman{
+		basePath:   "http://d/v1.0.0",
+		httpClient: &http.Client{},
+	}
+
+	if connection.Host == "" {
+		err = podman.startService()
+	} else {
+		err = podman.connect(connection)
+	}
+	if err != nil {
+		_ = podman.Close()
+
+		return nil, err
+	}
+
+	// Create Podman client
+	podman.cli = swagger.NewAPIClient(&swagger.Configuration{
+		BasePath:   podman.basePath,
+		HTTPClient: podman.httpClient,
+	})
+
+	// Query server's version and activate bug workarounds (if applicable)
+	version, err := podman.SystemInfo(context.Background())
+	if err != nil {
+		_ = podman.Close()
+
+		return nil, err
+	}
+	if version.Version == "3.0.0" {
+		podman.usingNumericalContainerState = true
+	}
+
+	return podman, nil
+}
+
+// resolvePodmanConnection fills the connection's host from the environment variables
+// and containers.conf, similarly to what "podman --remote" does.
+func resolvePodmanConnection(connection Connection) (Connection, error) {
+	name := connection.PodmanConnection
+
+	if connection.Host == "" && name == "" {
+		if host, ok := os.LookupEnv("CONTAINER_HOST"); ok {
+			connection.Host = host
+
+			if connection.Identity == "" {
+				connection.Identity = os.Getenv("CONTAINER_SSHKEY")
+			}
+		} else {
+			name = os.Getenv("CONTAINER_CONNECTION")
+		}
+	}
+
+	if name != "" {
+		destination, err := podman.LookupServiceDestination(name)
+		if err != nil {
+			return connection, fmt.Errorf("%w: %v", ErrNewFailed, err)
+		}
+
+		connection.Host = destination.URI
+
+		if connection.Identity == "" {
+			connection.Identity = destination.Identity
+		}
+	}
+
+	return connection, nil
+}
+
+func (backend *Podman) startService() error {
 	socketPath := filepath.Join(os.TempDir(), fmt.Sprintf("podman-%s.sock", uuid.New().String()))
 	socketURI := fmt.Sprintf("unix://%s", socketPath)
 
@@ -47,48 +127,93 @@ func NewPodman() (ContainerBackend, error) {
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, err
+		return err
 	}
+	backend.cmd = cmd
 
 	err := retry.Do(func() error {
 		_, err := os.Stat(socketPath)
 		return err
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	podman := &Podman{
-		cmd:      cmd,
-		basePath: "http://d/v1.0.0",
-		httpClient: &http.Client{
-			Transport: &http.Transport{
-				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-					return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
-				},
-			},
-		},
-	}
+	backend.httpClient.Transport = unixSocketTransport(socketPath)
 
-	// Create Podman client
-	podman.cli = swagger.NewAPIClient(&swagger.Configuration{
-		BasePath:   podman.basePath,
-		HTTPClient: podman.httpClient,
-	})
+	return nil
+}
 
-	// Query server's version and activate bug workarounds (if applicable)
-	version, err := podman.SystemInfo(context.Background())
+func (backend *Podman) connect(connection Connection) error {
+	hostURL, err := connection.URL()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if version.Version == "3.0.0" {
-		podman.usingNumericalContainerState = true
+
+	switch hostURL.Scheme {
+	case SchemeUnix:
+		backend.httpClient.Transport = unixSocketTransport(hostURL.Path)
+	case SchemeTCP:
+		transport := &http.Transport{}
+		scheme := "http"
+
+		if connection.CertPath != "" {
+			caFile, certFile, keyFile := connection.TLSFiles()
+
+			transport.TLSClientConfig, err = tlsconfig.Client(tlsconfig.Options{
+				CAFile:             caFile,
+				CertFile:           certFile,
+				KeyFile:            keyFile,
+				InsecureSkipVerify: !connection.TLSVerify,
+				ExclusiveRootPools: true,
+			})
+			if err != nil {
+				return err
+			}
+
+			scheme = "https"
+		}
+
+		backend.basePath = fmt.Sprintf("%s://%s/v1.0.0", scheme, hostURL.Host)
+		backend.httpClient.Transport = transport
+	case SchemeSSH:
+		backend.sshClient, err = dialSSH(hostURL, connection.Identity)
+		if err != nil {
+			return err
+		}
+
+		remoteSocketPath := hostURL.Path
+		if remoteSocketPath == "" {
+			remoteSocketPath = defaultPodmanRemoteSocket
+		}
+
+		backend.httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return backend.sshClient.Dial("unix", remoteSocketPath)
+			},
+		}
 	}
 
-	return podman, nil
+	return nil
+}
+
+func unixSocketTransport(socketPath string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}
 }
 
 func (backend *Podman) Close() error {
+	if backend.sshClient != nil {
+		return backend.sshClient.Close()
+	}
+
+	if backend.cmd == nil {
+		return nil
+	}
+
 	doneChan := make(chan error)
 
 	go func() {
@@ -171,6 +296,43 @@ func (backend *Podman) VolumeDelete(ctx context.Context, name string) error {
 	return err
 }
 
+func (backend *Podman) NetworkCreate(ctx context.Context, name string) error {
+	// nolint:bodyclose // already closed by Swagger-generated code
+	_, _, err := backend.cli.NetworksApi.LibpodCreateNetwork(ctx, &swagger.NetworksApiLibpodCreateNetworkOpts{
+		Name: optional.NewString(name),
+		Body: optional.NewInterface(swagger.NetworkCreateOptions{}),
+	})
+
+	// Enrich the error with it's cause if possible
+	if err != nil {
+		if cause := swaggerCause(err); cause != "" {
+			return fmt.Errorf("%w: caused by %s", err, swaggerCause(err))
+		}
+	}
+
+	return err
+}
+
+func (backend *Podman) NetworkDelete(ctx context.Context, name string) error {
+	// nolint:bodyclose // already closed by Swagger-generated code
+	_, resp, err := backend.cli.NetworksApi.LibpodRemoveNetwork(ctx, name, &swagger.NetworksApiLibpodRemoveNetworkOpts{
+		Force: optional.NewBool(false),
+	})
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	// Enrich the error with it's cause if possible
+	if err != nil {
+		if cause := swaggerCause(err); cause != "" {
+			return fmt.Errorf("%w: caused by %s", err, swaggerCause(err))
+		}
+	}
+
+	return err
+}
+
 func (backend *Podman) ImagePull(ctx context.Context, reference string) error {
 	// nolint:bodyclose // already closed by Swagger-generated code
 	_, _, err := backend.cli.ImagesApi.LibpodImagesPull(ctx, &swagger.ImagesApiLibpodImagesPullOpts{
@@ -347,19 +509,33 @@ func (backend *Podman) ContainerCreate(
 	input *ContainerCreateInput,
 	name string,
 ) (*ContainerCreateOutput, error) {
-	specGen := swagger.SpecGenerator{
-		Name:       name,
-		Entrypoint: input.Entrypoint,
-		Command:    input.Command,
-		Env:        input.Env,
-		Image:      input.Image,
+	specGen := podmanSpecGenerator{
+		SpecGenerator: swagger.SpecGenerator{
+			Name:       name,
+			Entrypoint: input.Entrypoint,
+			Command:    input.Command,
+			Env:        input.Env,
+			Image:      input.Image,
+		},
 	}
 
-	if strings.HasPrefix(input.Network, "container:") {
+	switch {
+	case strings.HasPrefix(input.Network, "container:"):
 		specGen.Netns = &swagger.Namespace{
 			Nsmode:  "container",
 			String_: strings.TrimPrefix(input.Network, "container:"),
 		}
+	case input.Network != "":
+		specGen.Netns = &swagger.Namespace{
+			Nsmode: "bridge",
+		}
+		specGen.CniNetworks = []string{input.Network}
+
+		if len(input.NetworkAliases) != 0 {
+			specGen.Aliases = map[string][]string{
+				input.Network: input.NetworkAliases,
+			}
+		}
 	}
 
 	for _, ourMount := range input.Mounts {
@@ -408,6 +584,13 @@ func (backend *Podman) ContainerCreate(
 	}, nil
 }
 
+// podmanSpecGenerator adds fields missing in the Swagger-generated swagger.SpecGenerator.
+type podmanSpecGenerator struct {
+	swagger.SpecGenerator
+
+	Aliases map[string][]string `json:"aliases,omitempty"`
+}
+
 func (backend *Podman) ContainerStart(ctx context.Context, id string) error {
 	// nolint:bodyclose // already closed by Swagger-generated code
 	_, err := backend.cli.ContainersApi.LibpodStartContainer(ctx, id, &swagger.ContainersApiLibpodStartContainerOpts{})
@@ -0,0 +1,108 @@
+package containerbackend_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePodman records the requests to the subset of the Podman API used by the task networks.
+type fakePodman struct {
+	mtx             sync.Mutex
+	createdNetworks []string
+	deletedNetworks []string
+	containerSpecs  []map[string]interface{}
+}
+
+func (fake *fakePodman) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	fake.mtx.Lock()
+	defer fake.mtx.Unlock()
+
+	writer.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case request.Method == http.MethodGet && request.URL.Path == "/v1.0.0/libpod/info":
+		_, _ = writer.Write([]byte(`{"host": {"cpus": 2, "memTotal": 1073741824}, "version": {"Version": "3.4.2"}}`))
+	case request.Method == http.MethodPost && request.URL.Path == "/v1.0.0/libpod/networks/create":
+		fake.createdNetworks = append(fake.createdNetworks, request.URL.Query().Get("name"))
+		_, _ = writer.Write([]byte(`{}`))
+	case request.Method == http.MethodDelete && strings.HasPrefix(request.URL.Path, "/v1.0.0/libpod/networks/"):
+		fake.deletedNetworks = append(fake.deletedNetworks,
+			strings.TrimPrefix(request.URL.Path, "/v1.0.0/libpod/networks/"))
+		_, _ = writer.Write([]byte(`{}`))
+	case request.Method == http.MethodPost && request.URL.Path == "/v1.0.0/libpod/containers/create":
+		var spec map[string]interface{}
+		if err := json.NewDecoder(request.Body).Decode(&spec); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		fake.containerSpecs = append(fake.containerSpecs, spec)
+		_, _ = writer.Write([]byte(`{"Id": "some-container"}`))
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakePodman(t *testing.T) (containerbackend.ContainerBackend, *fakePodman) {
+	fake := &fakePodman{}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	backend, err := containerbackend.New(containerbackend.BackendTypePodman,
+		containerbackend.WithConnection(containerbackend.Connection{
+			Host: "tcp://" + strings.TrimPrefix(server.URL, "http://"),
+		}))
+	require.NoError(t, err)
+
+	return backend, fake
+}
+
+// TestPodmanTaskNetwork ensures that the task network is created and deleted, and that the containers
+// are attached to it with the aliases or join the network namespace of another container.
+func TestPodmanTaskNetwork(t *testing.T) {
+	backend, fake := newFakePodman(t)
+	ctx := context.Background()
+
+	require.NoError(t, backend.NetworkCreate(ctx, "cirrus-task-network"))
+
+	_, err := backend.ContainerCreate(ctx, &containerbackend.ContainerCreateInput{
+		Image:          "alpine:latest",
+		Network:        "cirrus-task-network",
+		NetworkAliases: []string{"redis", "postgres"},
+	}, "")
+	require.NoError(t, err)
+
+	_, err = backend.ContainerCreate(ctx, &containerbackend.ContainerCreateInput{
+		Image:   "alpine:latest",
+		Network: "container:network-holder",
+	}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, backend.NetworkDelete(ctx, "cirrus-task-network"))
+
+	assert.Equal(t, []string{"cirrus-task-network"}, fake.createdNetworks)
+	assert.Equal(t, []string{"cirrus-task-network"}, fake.deletedNetworks)
+
+	require.Len(t, fake.containerSpecs, 2)
+
+	holderSpec := fake.containerSpecs[0]
+	assert.Equal(t, map[string]interface{}{"nsmode": "bridge"}, holderSpec["netns"])
+	assert.Equal(t, []interface{}{"cirrus-task-network"}, holderSpec["cni_networks"])
+	assert.Equal(t, map[string]interface{}{
+		"cirrus-task-network": []interface{}{"redis", "postgres"},
+	}, holderSpec["aliases"])
+
+	memberSpec := fake.containerSpecs[1]
+	assert.Equal(t, map[string]interface{}{"nsmode": "container", "value": "network-holder"}, memberSpec["netns"])
+	assert.NotContains(t, memberSpec, "cni_networks")
+	assert.NotContains(t, memberSpec, "aliases")
+}
@@ -0,0 +1,17 @@
+package containerbackend
+
+import "context"
+
+// ImagePullProgress describes the state of a single image layer being pulled.
+type ImagePullProgress struct {
+	Layer   string
+	Status  string
+	Current int64
+	Total   int64
+}
+
+// ProgressReportingImagePuller is implemented by the container backends that are able to report
+// per-layer progress when pulling an image.
+type ProgressReportingImagePuller interface {
+	ImagePullWithProgress(ctx context.Context, reference string, progress func(ImagePullProgress)) error
+}
@@ -0,0 +1,79 @@
+package containerbackend
+
+import (
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+)
+
+const defaultSSHPort = "22"
+
+// dialSSH connects to the remote container engine host using the identity file (if any),
+// the SSH agent (if running) and the user's known_hosts file for server verification.
+func dialSSH(hostURL *url.URL, identity string) (*ssh.Client, error) {
+	username := hostURL.User.Username()
+	if username == "" {
+		currentUser, err := user.Current()
+		if err != nil {
+			return nil, err
+		}
+
+		username = currentUser.Username
+	}
+
+	var authMethods []ssh.AuthMethod
+
+	if identity != "" {
+		keyBytes, err := ioutil.ReadFile(identity)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read SSH identity: %v", ErrNewFailed, err)
+		}
+
+		signer, err := ssh.ParsePrivateKey(keyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse SSH identity: %v", ErrNewFailed, err)
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	if agentSocket, ok := os.LookupEnv("SSH_AUTH_SOCK"); ok {
+		agentConn, err := net.Dial("unix", agentSocket)
+		if err == nil {
+			authMethods = append(authMethods, ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers))
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback, err := knownhosts.New(filepath.Join(homeDir, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load SSH known hosts: %v", ErrNewFailed, err)
+	}
+
+	port := hostURL.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+
+	sshClient, err := ssh.Dial("tcp", net.JoinHostPort(hostURL.Hostname(), port), &ssh.ClientConfig{
+		User:            username,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to connect via SSH: %v", ErrNewFailed, err)
+	}
+
+	return sshClient, nil
+}
@@ -46,6 +46,10 @@ func (*Unimplemented) VolumeInspect(ctx context.Context, name string) error { re
 
 func (*Unimplemented) VolumeDelete(ctx context.Context, name string) error { return ErrNotImplemented }
 
+func (*Unimplemented) NetworkCreate(ctx context.Context, name string) error { return ErrNotImplemented }
+
+func (*Unimplemented) NetworkDelete(ctx context.Context, name string) error { return ErrNotImplemented }
+
 func (*Unimplemented) ContainerCreate(
 	ctx context.Context,
 	input *ContainerCreateInput,
@@ -12,16 +12,19 @@ import (
 
 type RunConfig struct {
 	ContainerBackendType       string
+	ContainerBackendConnection containerbackend.Connection
 	ProjectDir                 string
 	Endpoint                   endpoint.Endpoint
 	ServerSecret, ClientSecret string
 	TaskID                     int64
+	LogsDir                    string
 	logger                     *echelon.Logger
 	DirtyMode                  bool
 	ContainerOptions           options.ContainerOptions
 	TartOptions                options.TartOptions
 	agentVersion               string
 	containerBackend           containerbackend.ContainerBackend
+	taskFailed                 func() bool
 }
 
 func (rc *RunConfig) GetContainerBackend() (containerbackend.ContainerBackend, error) {
@@ -33,7 +36,8 @@ func (rc *RunConfig) GetContainerBackend() (containerbackend.ContainerBackend, e
 		rc.ContainerBackendType = containerbackend.BackendTypeAuto
 	}
 
-	backend, err := containerbackend.New(rc.ContainerBackendType)
+	backend, err := containerbackend.New(rc.ContainerBackendType,
+		containerbackend.WithConnection(rc.ContainerBackendConnection))
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +58,19 @@ func (rc *RunConfig) SetLogger(logger *echelon.Logger) {
 	rc.logger = logger
 }
 
+// TaskFailed reports whether the task has failed according to its reported status.
+func (rc *RunConfig) TaskFailed() bool {
+	if rc.taskFailed == nil {
+		return false
+	}
+
+	return rc.taskFailed()
+}
+
+func (rc *RunConfig) SetTaskFailedFunc(taskFailed func() bool) {
+	rc.taskFailed = taskFailed
+}
+
 func (rc *RunConfig) GetAgentVersion() string {
 	if rc.agentVersion == "" {
 		return platform.DefaultAgentVersion
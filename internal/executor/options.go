@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/options"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/taskfilter"
 	"github.com/cirruslabs/echelon"
@@ -50,6 +51,20 @@ func WithContainerBackendType(containerBackendType string) Option {
 	}
 }
 
+func WithContainerBackendConnection(connection containerbackend.Connection) Option {
+	return func(e *Executor) {
+		e.containerBackendConnection = connection
+	}
+}
+
+// WithLogsDir enables saving the logs that don't fit into the main output (e.g. the logs
+// of the additional containers) to a per-task subdirectory of the specified directory.
+func WithLogsDir(logsDir string) Option {
+	return func(e *Executor) {
+		e.logsDir = logsDir
+	}
+}
+
 func WithTartOptions(tartOptions options.TartOptions) Option {
 	return func(e *Executor) {
 		e.tartOptions = tartOptions
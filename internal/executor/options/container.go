@@ -11,6 +11,9 @@ type ContainerOptions struct {
 	NoPullImages []string
 	NoCleanup    bool
 
+	// Only show and save the logs of the additional containers when the task fails
+	AdditionalContainersLogsOnFailure bool
+
 	DockerfileImageTemplate string
 	DockerfileImagePush     bool
 }
@@ -24,6 +24,7 @@ type CopyCommand struct {
 type Platform interface {
 	ContainerAgentImage(version string) string
 	ContainerCopyCommand(populate bool) *CopyCommand
+	ContainerIdleCommand() []string
 	ContainerAgentPath() string
 	ContainerAgentVolumeDir() string
 
@@ -48,6 +48,10 @@ func (platform *UnixPlatform) ContainerCopyCommand(populate bool) *CopyCommand {
 	return copyCommand
 }
 
+func (platform *UnixPlatform) ContainerIdleCommand() []string {
+	return []string{"/bin/sh", "-c", "trap 'exit 0' INT TERM; while true; do sleep 1; done"}
+}
+
 func (platform *UnixPlatform) GenericWorkingDir() string {
 	return path.Join(platform.CirrusDir(), workingVolumeWorkingDir)
 }
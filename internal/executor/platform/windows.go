@@ -65,6 +65,10 @@ func (platform *WindowsPlatform) ContainerCopyCommand(populate bool) *CopyComman
 	return copyCommand
 }
 
+func (platform *WindowsPlatform) ContainerIdleCommand() []string {
+	return []string{"powershell", "while ($true) { Start-Sleep -Seconds 1 }"}
+}
+
 func (platform *WindowsPlatform) GenericWorkingDir() string {
 	return filepath.Join(platform.CirrusDir(), workingVolumeWorkingDir)
 }
@@ -0,0 +1,145 @@
+package pullhelper
+
+import (
+	"context"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/options"
+	"github.com/cirruslabs/echelon"
+	"github.com/dustin/go-humanize"
+	"sync"
+)
+
+const (
+	maxConcurrentPulls = 4
+
+	// Report the layer download/extraction progress each time it advances by this many percents.
+	progressReportStep = 10
+)
+
+// PrePull concurrently pulls the specified images, skipping the duplicate references and the images
+// that shouldn't be pulled according to the container options.
+//
+// Failing to pull an image is not fatal: a warning is emitted and the image is excluded from the result,
+// so that it will be pulled again by the task that needs it. The successfully pulled images are returned.
+func PrePull(
+	ctx context.Context,
+	references []string,
+	backend containerbackend.ContainerBackend,
+	copts options.ContainerOptions,
+	logger *echelon.Logger,
+) []string {
+	var pulled []string
+	var pulledLock sync.Mutex
+
+	semaphore := make(chan struct{}, maxConcurrentPulls)
+	var wg sync.WaitGroup
+
+	seen := map[string]struct{}{}
+
+	for _, reference := range references {
+		if reference == "" {
+			continue
+		}
+
+		if _, ok := seen[reference]; ok {
+			continue
+		}
+		seen[reference] = struct{}{}
+
+		if !copts.ShouldPullImage(ctx, backend, reference) {
+			continue
+		}
+
+		reference := reference
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := pullWithProgress(ctx, reference, backend, logger); err != nil {
+				return
+			}
+
+			pulledLock.Lock()
+			pulled = append(pulled, reference)
+			pulledLock.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return pulled
+}
+
+func pullWithProgress(
+	ctx context.Context,
+	reference string,
+	backend containerbackend.ContainerBackend,
+	logger *echelon.Logger,
+) error {
+	pullLogger := logger.Scoped(fmt.Sprintf("pull %s", reference))
+	pullLogger.Infof("Pulling image %s...", reference)
+
+	var err error
+
+	if progressReporter, ok := backend.(containerbackend.ProgressReportingImagePuller); ok {
+		err = progressReporter.ImagePullWithProgress(ctx, reference, newLayerProgressReporter(pullLogger))
+	} else {
+		err = backend.ImagePull(ctx, reference)
+	}
+
+	if err != nil {
+		pullLogger.Warnf("Failed to pull %s, will retry when running the task: %v", reference, err)
+		pullLogger.Finish(false)
+
+		return err
+	}
+
+	pullLogger.Finish(true)
+
+	return nil
+}
+
+type layerState struct {
+	status  string
+	percent int64
+}
+
+// newLayerProgressReporter returns a callback that logs the layer status changes
+// and the download/extraction progress at a reasonable rate.
+func newLayerProgressReporter(logger *echelon.Logger) func(containerbackend.ImagePullProgress) {
+	layers := map[string]*layerState{}
+
+	return func(progress containerbackend.ImagePullProgress) {
+		state, ok := layers[progress.Layer]
+		if !ok {
+			state = &layerState{}
+			layers[progress.Layer] = state
+		}
+
+		if progress.Total <= 0 {
+			if state.status != progress.Status {
+				logger.Infof("%s: %s", progress.Layer, progress.Status)
+			}
+			state.status = progress.Status
+			state.percent = 0
+
+			return
+		}
+
+		percent := progress.Current * 100 / progress.Total / progressReportStep * progressReportStep
+
+		if state.status == progress.Status && state.percent == percent {
+			return
+		}
+		state.status = progress.Status
+		state.percent = percent
+
+		logger.Infof("%s: %s %s/%s (%d%%)", progress.Layer, progress.Status,
+			humanize.Bytes(uint64(progress.Current)), humanize.Bytes(uint64(progress.Total)), percent)
+	}
+}
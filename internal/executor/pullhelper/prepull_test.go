@@ -0,0 +1,61 @@
+package pullhelper_test
+
+import (
+	"context"
+	"errors"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/instance/containerbackend"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/options"
+	"github.com/cirruslabs/cirrus-cli/internal/executor/pullhelper"
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+var errPullFailed = errors.New("pull failed")
+
+type fakeBackend struct {
+	containerbackend.Unimplemented
+
+	pulls     map[string]int
+	pullsLock sync.Mutex
+}
+
+func (backend *fakeBackend) ImagePull(ctx context.Context, reference string) error {
+	backend.pullsLock.Lock()
+	defer backend.pullsLock.Unlock()
+
+	backend.pulls[reference]++
+
+	if reference == "nonexistent.invalid/image:latest" {
+		return errPullFailed
+	}
+
+	return nil
+}
+
+func TestPrePull(t *testing.T) {
+	backend := &fakeBackend{pulls: map[string]int{}}
+	logger := echelon.NewLogger(echelon.ErrorLevel, &renderers.StubRenderer{})
+
+	pulled := pullhelper.PrePull(context.Background(), []string{
+		"debian:latest",
+		"redis:latest",
+		"debian:latest",
+		"nonexistent.invalid/image:latest",
+		"dockerfile-built:latest",
+	}, backend, options.ContainerOptions{
+		NoPullImages: []string{"dockerfile-built:latest"},
+	}, logger)
+
+	// Failed and skipped images are not reported as pulled
+	assert.ElementsMatch(t, []string{"debian:latest", "redis:latest"}, pulled)
+
+	// Identical references are pulled only once
+	assert.Equal(t, map[string]int{
+		"debian:latest":                    1,
+		"redis:latest":                     1,
+		"nonexistent.invalid/image:latest": 1,
+	}, backend.pulls)
+}
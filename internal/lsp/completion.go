@@ -0,0 +1,231 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/lestrrat-go/jsschema"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	keyRegex = regexp.MustCompile(`^(\s*)((?:-\s+)*)([A-Za-z0-9_.-]+)\s*:`)
+
+	// Matches patterns like "^(.*)task$" to offer "task" as a completion.
+	suffixPatternRegex = regexp.MustCompile(`^\^\(\.\*\)([A-Za-z0-9_]+)\$$`)
+)
+
+// Complete returns the field names that are valid at the specified position
+// of the YAML configuration according to the schema.
+func Complete(root *schema.Schema, text string, position Position) []CompletionItem {
+	lines := strings.Split(text, "\n")
+	if position.Line >= len(lines) {
+		return []CompletionItem{}
+	}
+
+	// Only offer completions when typing a key
+	line := lines[position.Line]
+	prefix := line[:byteOffset(line, position.Character)]
+	typed := strings.TrimLeft(prefix, " \t-")
+	if strings.ContainsAny(typed, ": ") {
+		return []CompletionItem{}
+	}
+
+	indent := len(prefix) - len(typed)
+	current := resolvePath(root, parentKeys(lines, position.Line, indent))
+	if current == nil {
+		return []CompletionItem{}
+	}
+
+	names := map[string]*schema.Schema{}
+	collectNames(current, names)
+
+	items := []CompletionItem{}
+
+	for name, fieldSchema := range names {
+		if !strings.HasPrefix(name, typed) {
+			continue
+		}
+
+		item := CompletionItem{
+			Label:      name,
+			Kind:       completionItemKindField,
+			InsertText: name + ": ",
+		}
+
+		if fieldSchema != nil {
+			item.Detail = typeOf(fieldSchema)
+
+			if fieldSchema.Description != "" {
+				item.Documentation = &markupContent{Kind: markupKindMarkdown, Value: fieldSchema.Description}
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+
+	return items
+}
+
+// Describe returns the documentation for the field name at the specified position
+// of the YAML configuration according to the schema.
+func Describe(root *schema.Schema, text string, position Position) *Hover {
+	lines := strings.Split(text, "\n")
+	if position.Line >= len(lines) {
+		return nil
+	}
+
+	line := lines[position.Line]
+
+	matches := keyRegex.FindStringSubmatchIndex(line)
+	if matches == nil {
+		return nil
+	}
+
+	keyStart, keyEnd := matches[6], matches[7]
+	character := byteOffset(line, position.Character)
+	if character < keyStart || character > keyEnd {
+		return nil
+	}
+
+	key := line[keyStart:keyEnd]
+
+	parent := resolvePath(root, parentKeys(lines, position.Line, keyStart))
+	if parent == nil {
+		return nil
+	}
+
+	fieldSchema := lookup(parent, key)
+	if fieldSchema == nil || fieldSchema.Description == "" {
+		return nil
+	}
+
+	value := fmt.Sprintf("**%s**", key)
+	if fieldType := typeOf(fieldSchema); fieldType != "" {
+		value += fmt.Sprintf(" (%s)", fieldType)
+	}
+	value += "\n\n" + fieldSchema.Description
+
+	return &Hover{
+		Contents: markupContent{Kind: markupKindMarkdown, Value: value},
+		Range: &Range{
+			Start: Position{Line: position.Line, Character: utf16Offset(line, utf8.RuneCountInString(line[:keyStart]))},
+			End:   Position{Line: position.Line, Character: utf16Offset(line, utf8.RuneCountInString(line[:keyEnd]))},
+		},
+	}
+}
+
+// parentKeys walks up from the specified line and returns the keys of the enclosing mappings,
+// outermost first. Indentation is used instead of the actual YAML parsing since the document
+// is likely to be incomplete while it's being edited.
+//
+// Note that the key on the list item's line (e.g. "- name: redis") starts at the same column
+// as the rest of the item's fields, so it's correctly treated as their sibling.
+func parentKeys(lines []string, lineIdx int, indent int) []string {
+	var keys []string
+
+	for i := lineIdx - 1; i >= 0 && indent > 0; i-- {
+		matches := keyRegex.FindStringSubmatchIndex(lines[i])
+		if matches == nil {
+			continue
+		}
+
+		keyStart, keyEnd := matches[6], matches[7]
+		if keyStart >= indent {
+			continue
+		}
+
+		keys = append([]string{lines[i][keyStart:keyEnd]}, keys...)
+		indent = keyStart
+	}
+
+	return keys
+}
+
+func resolvePath(root *schema.Schema, keys []string) *schema.Schema {
+	current := root
+
+	for _, key := range keys {
+		current = lookup(current, key)
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+// lookup finds the schema of the specified field, descending into
+// the combined schemas and the array items when needed.
+func lookup(current *schema.Schema, key string) *schema.Schema {
+	if current == nil {
+		return nil
+	}
+
+	if fieldSchema, ok := current.Properties[key]; ok {
+		return fieldSchema
+	}
+
+	for pattern, fieldSchema := range current.PatternProperties {
+		if pattern.MatchString(key) && pattern.String() != ".*" {
+			return fieldSchema
+		}
+	}
+
+	for _, subSchema := range subSchemas(current) {
+		if fieldSchema := lookup(subSchema, key); fieldSchema != nil {
+			return fieldSchema
+		}
+	}
+
+	return nil
+}
+
+func collectNames(current *schema.Schema, names map[string]*schema.Schema) {
+	if current == nil {
+		return
+	}
+
+	for name, fieldSchema := range current.Properties {
+		names[name] = fieldSchema
+	}
+
+	for pattern, fieldSchema := range current.PatternProperties {
+		if matches := suffixPatternRegex.FindStringSubmatch(pattern.String()); matches != nil {
+			names[matches[1]] = fieldSchema
+		}
+	}
+
+	for _, subSchema := range subSchemas(current) {
+		collectNames(subSchema, names)
+	}
+}
+
+func subSchemas(current *schema.Schema) []*schema.Schema {
+	var result []*schema.Schema
+
+	result = append(result, current.AllOf...)
+	result = append(result, current.AnyOf...)
+	result = append(result, current.OneOf...)
+
+	if current.Items != nil {
+		result = append(result, current.Items.Schemas...)
+	}
+
+	return result
+}
+
+func typeOf(current *schema.Schema) string {
+	var types []string
+
+	for _, primitiveType := range current.Type {
+		types = append(types, primitiveType.String())
+	}
+
+	return strings.Join(types, " or ")
+}
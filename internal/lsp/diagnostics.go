@@ -0,0 +1,277 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/local"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/node"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	sourceParser   = "cirrus"
+	sourceStarlark = "starlark"
+
+	starlarkEvaluationTimeout = 30 * time.Second
+
+	// The name under which the larker executes the .cirrus.star.
+	starlarkFilename = ".cirrus.star"
+)
+
+// DiagnoseYAML parses the YAML configuration and converts the resulting issues
+// and the parsing error (if any) into diagnostics.
+func DiagnoseYAML(ctx context.Context, projectDir string, env map[string]string, text string) []Diagnostic {
+	p := parser.New(
+		parser.WithEnvironment(env),
+		parser.WithFileSystem(local.New(projectDir)),
+		parser.WithIncludeLocator(loader.FindModuleFS),
+		parser.WithMissingInstancesAllowed(),
+	)
+
+	result, err := p.Parse(ctx, text)
+	if err != nil {
+		return []Diagnostic{errorToDiagnostic(text, err, sourceParser)}
+	}
+
+	var diagnostics []Diagnostic
+
+	for _, issue := range result.Issues {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    pointRange(text, int(issue.Line), int(issue.Column)),
+			Severity: issueLevelToSeverity(issue.Level),
+			Source:   sourceParser,
+			Message:  issue.Message,
+		})
+	}
+
+	return diagnostics
+}
+
+// DiagnoseStarlarkSyntax reports the syntax and name resolution errors of the Starlark configuration
+// without actually executing it.
+func DiagnoseStarlarkSyntax(text string) []Diagnostic {
+	// Same as in the larker
+	resolve.AllowFloat = true
+
+	_, _, err := starlark.SourceProgram(starlarkFilename, text, func(string) bool { return false })
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr syntax.Error
+	if errors.As(err, &syntaxErr) {
+		return []Diagnostic{positionToDiagnostic(text, syntaxErr.Pos, syntaxErr.Msg)}
+	}
+
+	var resolveErrs resolve.ErrorList
+	if errors.As(err, &resolveErrs) {
+		var diagnostics []Diagnostic
+
+		for _, resolveErr := range resolveErrs {
+			diagnostics = append(diagnostics, positionToDiagnostic(text, resolveErr.Pos, resolveErr.Msg))
+		}
+
+		return diagnostics
+	}
+
+	return []Diagnostic{errorToDiagnostic(text, err, sourceStarlark)}
+}
+
+// DiagnoseStarlark evaluates the Starlark configuration and reports the evaluation errors, as well
+// as the errors in the resulting YAML configuration.
+func DiagnoseStarlark(ctx context.Context, projectDir string, env map[string]string, text string) []Diagnostic {
+	if diagnostics := DiagnoseStarlarkSyntax(text); len(diagnostics) != 0 {
+		return diagnostics
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, starlarkEvaluationTimeout)
+	defer cancel()
+
+	lrk := larker.New(larker.WithFileSystem(local.New(projectDir)), larker.WithEnvironment(env))
+
+	result, err := lrk.MainOptional(ctx, text)
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return []Diagnostic{evalErrorToDiagnostic(text, evalErr)}
+		}
+
+		return []Diagnostic{errorToDiagnostic(text, err, sourceStarlark)}
+	}
+
+	if result.YAMLConfig == "" {
+		return nil
+	}
+
+	// Line numbers in the generated configuration don't correspond to the .cirrus.star,
+	// so report the problems at the beginning of the file
+	var diagnostics []Diagnostic
+
+	for _, diagnostic := range DiagnoseYAML(ctx, projectDir, env, result.YAMLConfig) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    pointRange(text, 1, 1),
+			Severity: diagnostic.Severity,
+			Source:   diagnostic.Source,
+			Message:  fmt.Sprintf("generated configuration: %s", diagnostic.Message),
+		})
+	}
+
+	return diagnostics
+}
+
+func evalErrorToDiagnostic(text string, evalErr *starlark.EvalError) Diagnostic {
+	// Point to the innermost frame that belongs to the .cirrus.star
+	for i := len(evalErr.CallStack) - 1; i >= 0; i-- {
+		pos := evalErr.CallStack[i].Pos
+
+		if pos.Filename() == starlarkFilename {
+			return positionToDiagnostic(text, pos, evalErr.Msg)
+		}
+	}
+
+	return Diagnostic{
+		Range:    pointRange(text, 1, 1),
+		Severity: SeverityError,
+		Source:   sourceStarlark,
+		Message:  evalErr.Msg,
+	}
+}
+
+func positionToDiagnostic(text string, pos syntax.Position, message string) Diagnostic {
+	return Diagnostic{
+		Range:    pointRange(text, int(pos.Line), int(pos.Col)),
+		Severity: SeverityError,
+		Source:   sourceStarlark,
+		Message:  message,
+	}
+}
+
+func errorToDiagnostic(text string, err error, source string) Diagnostic {
+	var rich *parsererror.Rich
+	if errors.As(err, &rich) {
+		// The error may be in one of the included files, which is not open
+		// in the editor, so point to the include: directive instead
+		if rich.Path() != "" {
+			line, column := includePosition(text)
+
+			return Diagnostic{
+				Range:    pointRange(text, line, column),
+				Severity: SeverityError,
+				Source:   source,
+				Message:  fmt.Sprintf("%s:%d:%d: %s", rich.Path(), rich.Line(), rich.Column(), rich.Message()),
+			}
+		}
+
+		return Diagnostic{
+			Range:    pointRange(text, rich.Line(), rich.Column()),
+			Severity: SeverityError,
+			Source:   source,
+			Message:  rich.Message(),
+		}
+	}
+
+	return Diagnostic{
+		Range:    pointRange(text, 1, 1),
+		Severity: SeverityError,
+		Source:   source,
+		Message:  err.Error(),
+	}
+}
+
+// includePosition returns the 1-based position of the top-level include: directive in the YAML configuration
+// or zeroes if there's none.
+func includePosition(text string) (int, int) {
+	tree, err := node.NewFromText(text)
+	if err != nil {
+		return 0, 0
+	}
+
+	includeNode := tree.FindChild("include")
+	if includeNode == nil {
+		return 0, 0
+	}
+
+	return includeNode.Line, includeNode.Column
+}
+
+func issueLevelToSeverity(level api.Issue_Level) DiagnosticSeverity {
+	switch level {
+	case api.Issue_ERROR:
+		return SeverityError
+	case api.Issue_WARNING:
+		return SeverityWarning
+	default:
+		return SeverityInformation
+	}
+}
+
+// pointRange converts 1-based line and column into a zero-width LSP range,
+// taking into account that some positions may be unknown (zero).
+//
+// Both the YAML parser and the Starlark interpreter count the columns in Unicode code points,
+// while LSP counts them in UTF-16 code units, so the column is converted using the line's text.
+func pointRange(text string, line, column int) Range {
+	position := Position{}
+
+	if line > 0 {
+		position.Line = line - 1
+	}
+	if column > 0 {
+		position.Character = utf16Offset(lineText(text, line), column-1)
+	}
+
+	return Range{Start: position, End: position}
+}
+
+// lineText returns the text of the 1-based line or an empty string if there's no such line.
+func lineText(text string, line int) string {
+	lines := strings.Split(text, "\n")
+
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return lines[line-1]
+}
+
+// utf16Offset converts the offset in Unicode code points into the offset in UTF-16 code units,
+// assuming that the code points past the end of the line take a single code unit.
+func utf16Offset(line string, offset int) int {
+	var result int
+
+	for _, r := range line {
+		if offset == 0 {
+			break
+		}
+
+		result += len(utf16.Encode([]rune{r}))
+		offset--
+	}
+
+	return result + offset
+}
+
+// byteOffset is the inverse of utf16Offset: it converts the offset in UTF-16 code units (as sent by the client)
+// into the byte offset in the line, clamping it to the line's length.
+func byteOffset(line string, offset int) int {
+	for i, r := range line {
+		if offset <= 0 {
+			return i
+		}
+
+		offset -= len(utf16.Encode([]rune{r}))
+	}
+
+	return len(line)
+}
@@ -0,0 +1,18 @@
+package lsp
+
+type Option func(*Server)
+
+// WithProjectDir overrides the project directory that is otherwise
+// derived from the workspace root provided by the client.
+func WithProjectDir(dir string) Option {
+	return func(server *Server) {
+		server.rootDir = dir
+	}
+}
+
+// WithEnvironment sets the environment to use when parsing YAML and evaluating Starlark configurations.
+func WithEnvironment(env map[string]string) Option {
+	return func(server *Server) {
+		server.env = env
+	}
+}
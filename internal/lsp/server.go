@@ -0,0 +1,316 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/cirruslabs/cirrus-cli/internal/version"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/lestrrat-go/jsschema"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Server is a Language Server Protocol server for the Cirrus CI configuration files
+// (.cirrus.yml and .cirrus.star).
+type Server struct {
+	transport *transport
+	schema    *schema.Schema
+
+	rootDir string
+	env     map[string]string
+
+	documents     map[string]string
+	documentsLock sync.Mutex
+
+	// Incremented on each document change to detect the stale asynchronous diagnostics
+	versions   map[string]uint64
+	generation uint64
+
+	// Ensures that the diagnostics for the newer version of the document
+	// are not published before the stale ones
+	publishLock sync.Mutex
+
+	// Tracks the asynchronous Starlark evaluations
+	evaluations sync.WaitGroup
+}
+
+func New(r io.Reader, w io.Writer, opts ...Option) *Server {
+	server := &Server{
+		transport: newTransport(r, w),
+		schema:    parser.New(parser.WithMissingInstancesAllowed()).Schema(),
+		env:       make(map[string]string),
+		documents: make(map[string]string),
+		versions:  make(map[string]uint64),
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	return server
+}
+
+// Serve processes the client's requests until the "exit" notification is received,
+// the input is closed or the context is cancelled.
+func (server *Server) Serve(ctx context.Context) error {
+	defer server.evaluations.Wait()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		content, err := server.transport.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			if err := server.replyError(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		if err := server.handle(ctx, &req); err != nil {
+			return err
+		}
+	}
+}
+
+func (server *Server) handle(ctx context.Context, req *request) error {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return server.replyError(req.ID, codeInvalidParams, err.Error())
+		}
+
+		if server.rootDir == "" && params.RootURI != "" {
+			server.rootDir = uriToPath(params.RootURI)
+		}
+
+		return server.reply(req.ID, &initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync: textDocumentSyncOptions{
+					OpenClose: true,
+					Change:    textDocumentSyncKindFull,
+					Save:      true,
+				},
+				CompletionProvider: completionOptions{},
+				HoverProvider:      true,
+			},
+			ServerInfo: serverInfo{
+				Name:    "cirrus",
+				Version: version.FullVersion,
+			},
+		})
+	case "shutdown":
+		return server.reply(req.ID, nil)
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+
+		server.setDocument(params.TextDocument.URI, params.TextDocument.Text)
+
+		return server.diagnose(ctx, params.TextDocument.URI, true)
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+
+		// We only support full synchronization, so the last change contains the whole document
+		server.setDocument(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+
+		return server.diagnose(ctx, params.TextDocument.URI, false)
+	case "textDocument/didSave":
+		var params didSaveTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+
+		return server.diagnose(ctx, params.TextDocument.URI, true)
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+
+		server.documentsLock.Lock()
+		delete(server.documents, params.TextDocument.URI)
+		delete(server.versions, params.TextDocument.URI)
+		server.documentsLock.Unlock()
+
+		return server.publishDiagnostics(params.TextDocument.URI, nil)
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return server.replyError(req.ID, codeInvalidParams, err.Error())
+		}
+
+		text, ok := server.document(params.TextDocument.URI)
+		if !ok || isStarlark(params.TextDocument.URI) {
+			return server.reply(req.ID, []CompletionItem{})
+		}
+
+		return server.reply(req.ID, Complete(server.schema, text, params.Position))
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return server.replyError(req.ID, codeInvalidParams, err.Error())
+		}
+
+		text, ok := server.document(params.TextDocument.URI)
+		if !ok || isStarlark(params.TextDocument.URI) {
+			return server.reply(req.ID, nil)
+		}
+
+		return server.reply(req.ID, Describe(server.schema, text, params.Position))
+	default:
+		// Notifications (e.g. "initialized" or "$/cancelRequest") don't need a response
+		if req.ID == nil {
+			return nil
+		}
+
+		return server.replyError(req.ID, codeMethodNotFound, "method not found: "+req.Method)
+	}
+}
+
+// diagnose publishes the diagnostics for the document. Starlark configurations are only
+// evaluated when explicitly asked to (e.g. on open and save), since that might be expensive.
+func (server *Server) diagnose(ctx context.Context, uri string, evaluateStarlark bool) error {
+	text, version, ok := server.documentVersion(uri)
+	if !ok {
+		return nil
+	}
+
+	projectDir := server.rootDir
+	if projectDir == "" {
+		projectDir = filepath.Dir(uriToPath(uri))
+	}
+
+	if !isStarlark(uri) {
+		return server.publishDiagnostics(uri, DiagnoseYAML(ctx, projectDir, server.env, text))
+	}
+
+	if !evaluateStarlark {
+		// Syntax errors are cheap to detect, so report them right away
+		return server.publishDiagnostics(uri, DiagnoseStarlarkSyntax(text))
+	}
+
+	server.evaluations.Add(1)
+	go func() {
+		defer server.evaluations.Done()
+
+		_ = server.publishCurrentDiagnostics(uri, version, DiagnoseStarlark(ctx, projectDir, server.env, text))
+	}()
+
+	return nil
+}
+
+func (server *Server) setDocument(uri string, text string) {
+	server.documentsLock.Lock()
+	defer server.documentsLock.Unlock()
+
+	server.documents[uri] = text
+
+	server.generation++
+	server.versions[uri] = server.generation
+}
+
+func (server *Server) document(uri string) (string, bool) {
+	text, _, ok := server.documentVersion(uri)
+
+	return text, ok
+}
+
+func (server *Server) documentVersion(uri string) (string, uint64, bool) {
+	server.documentsLock.Lock()
+	defer server.documentsLock.Unlock()
+
+	text, ok := server.documents[uri]
+
+	return text, server.versions[uri], ok
+}
+
+func (server *Server) reply(id json.RawMessage, result interface{}) error {
+	return server.transport.write(&response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (server *Server) replyError(id json.RawMessage, code int, message string) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return server.transport.write(&errorResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   responseError{Code: code, Message: message},
+	})
+}
+
+// publishCurrentDiagnostics publishes the diagnostics unless the document
+// has changed (or was closed) since the specified version.
+func (server *Server) publishCurrentDiagnostics(uri string, version uint64, diagnostics []Diagnostic) error {
+	server.publishLock.Lock()
+	defer server.publishLock.Unlock()
+
+	if _, currentVersion, ok := server.documentVersion(uri); !ok || currentVersion != version {
+		return nil
+	}
+
+	return server.writeDiagnostics(uri, diagnostics)
+}
+
+func (server *Server) publishDiagnostics(uri string, diagnostics []Diagnostic) error {
+	server.publishLock.Lock()
+	defer server.publishLock.Unlock()
+
+	return server.writeDiagnostics(uri, diagnostics)
+}
+
+func (server *Server) writeDiagnostics(uri string, diagnostics []Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+
+	return server.transport.write(&notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params: &publishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diagnostics,
+		},
+	})
+}
+
+func isStarlark(uri string) bool {
+	return strings.HasSuffix(uri, ".star")
+}
+
+func uriToPath(uri string) string {
+	parsedURI, err := url.Parse(uri)
+	if err != nil || parsedURI.Scheme != "file" {
+		return ""
+	}
+
+	return filepath.FromSlash(parsedURI.Path)
+}
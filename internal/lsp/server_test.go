@@ -0,0 +1,322 @@
+package lsp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type client struct {
+	t      *testing.T
+	writer io.Writer
+	reader *textproto.Reader
+	nextID int
+}
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+}
+
+func newClient(t *testing.T, projectDir string) *client {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	server := lsp.New(serverReader, serverWriter, lsp.WithProjectDir(projectDir))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(context.Background())
+		_ = serverWriter.Close()
+	}()
+
+	t.Cleanup(func() {
+		_ = clientWriter.Close()
+		require.NoError(t, <-errCh)
+	})
+
+	return &client{
+		t:      t,
+		writer: clientWriter,
+		reader: textproto.NewReader(bufio.NewReader(clientReader)),
+	}
+}
+
+func (c *client) send(method string, params interface{}, isRequest bool) {
+	envelope := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+
+	if isRequest {
+		c.nextID++
+		envelope["id"] = c.nextID
+	}
+
+	content, err := json.Marshal(envelope)
+	require.NoError(c.t, err)
+
+	_, err = fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	require.NoError(c.t, err)
+}
+
+func (c *client) receive() *message {
+	headers, err := c.reader.ReadMIMEHeader()
+	require.NoError(c.t, err)
+
+	contentLength, err := strconv.Atoi(headers.Get("Content-Length"))
+	require.NoError(c.t, err)
+
+	content := make([]byte, contentLength)
+	_, err = io.ReadFull(c.reader.R, content)
+	require.NoError(c.t, err)
+
+	var result message
+	require.NoError(c.t, json.Unmarshal(content, &result))
+
+	return &result
+}
+
+func (c *client) request(method string, params interface{}, result interface{}) {
+	c.send(method, params, true)
+
+	response := c.receive()
+	require.NotNil(c.t, response.ID)
+	require.Equal(c.t, c.nextID, *response.ID)
+	require.NoError(c.t, json.Unmarshal(response.Result, result))
+}
+
+func (c *client) diagnostics() []lsp.Diagnostic {
+	notification := c.receive()
+	require.Equal(c.t, "textDocument/publishDiagnostics", notification.Method)
+
+	var params struct {
+		Diagnostics []lsp.Diagnostic `json:"diagnostics"`
+	}
+	require.NoError(c.t, json.Unmarshal(notification.Params, &params))
+
+	return params.Diagnostics
+}
+
+func (c *client) open(uri string, text string) {
+	c.send("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        uri,
+			"languageId": "yaml",
+			"version":    1,
+			"text":       text,
+		},
+	}, false)
+}
+
+func (c *client) change(uri string, text string) {
+	c.send("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": text}},
+	}, false)
+}
+
+// remainingDiagnostics asks the server to exit and returns all the diagnostics that it has published until then.
+func (c *client) remainingDiagnostics() [][]lsp.Diagnostic {
+	resultCh := make(chan [][]lsp.Diagnostic, 1)
+
+	// Pipes are synchronous, so keep reading while sending the notification
+	go func() {
+		var result [][]lsp.Diagnostic
+
+		for {
+			if _, err := c.reader.R.Peek(1); errors.Is(err, io.EOF) {
+				resultCh <- result
+				return
+			}
+
+			result = append(result, c.diagnostics())
+		}
+	}()
+
+	c.send("exit", nil, false)
+
+	return <-resultCh
+}
+
+func initializedClient(t *testing.T) *client {
+	return initializedClientIn(t, t.TempDir())
+}
+
+func initializedClientIn(t *testing.T, projectDir string) *client {
+	c := newClient(t, projectDir)
+
+	var initializeResult map[string]interface{}
+	c.request("initialize", map[string]interface{}{}, &initializeResult)
+	assert.Contains(t, initializeResult, "capabilities")
+	c.send("initialized", map[string]interface{}{}, false)
+
+	return c
+}
+
+func TestDiagnosticsYAML(t *testing.T) {
+	c := initializedClient(t)
+
+	c.open("file:///project/.cirrus.yml", `task_lint:
+  container:
+    image: debian:latest
+  script: true
+`)
+
+	diagnostics := c.diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, lsp.SeverityWarning, diagnostics[0].Severity)
+	assert.Equal(t, "you've probably meant lint_task", diagnostics[0].Message)
+	assert.Equal(t, lsp.Position{Line: 0, Character: 0}, diagnostics[0].Range.Start)
+
+	c.open("file:///project/.cirrus.yml", `task:
+  container:
+    image: debian:latest
+  node_modules_cache:
+    fingerprint_script: cat package-lock.json
+  script: true
+`)
+
+	diagnostics = c.diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, lsp.SeverityError, diagnostics[0].Severity)
+	assert.Equal(t, "please specify the folders to cache, with either folder: or folders:", diagnostics[0].Message)
+	assert.Equal(t, 3, diagnostics[0].Range.Start.Line)
+}
+
+func TestDiagnosticsStarlark(t *testing.T) {
+	c := initializedClient(t)
+
+	c.open("file:///project/.cirrus.star", `def main(ctx):
+    return [undefined_variable]
+`)
+
+	diagnostics := c.diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, lsp.Position{Line: 1, Character: 12}, diagnostics[0].Range.Start)
+	assert.Contains(t, diagnostics[0].Message, "undefined_variable")
+
+	c.open("file:///project/.cirrus.star", `def main(ctx):
+    fail("no tasks for you")
+`)
+
+	diagnostics = c.diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, 1, diagnostics[0].Range.Start.Line)
+	assert.Contains(t, diagnostics[0].Message, "no tasks for you")
+}
+
+// TestDiagnosticsUTF16 ensures that the columns are reported in UTF-16 code units, as expected by LSP.
+func TestDiagnosticsUTF16(t *testing.T) {
+	c := initializedClient(t)
+
+	// "é" is a single UTF-16 code unit, while "😀" takes two of them
+	c.open("file:///project/.cirrus.star", `def main(ctx):
+    return ["é😀", undefined_variable]
+`)
+
+	diagnostics := c.diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, lsp.Position{Line: 1, Character: 19}, diagnostics[0].Range.Start)
+}
+
+// TestDiagnosticsIncluded ensures that the errors in the included files are reported
+// at the include: directive, since these files are not open in the editor.
+func TestDiagnosticsIncluded(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "lint.yml"), []byte("- broken\n"), 0600))
+
+	c := initializedClientIn(t, projectDir)
+
+	c.open("file:///project/.cirrus.yml", `container:
+  image: debian:latest
+
+include:
+  - lint.yml
+`)
+
+	diagnostics := c.diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, lsp.Position{Line: 3, Character: 0}, diagnostics[0].Range.Start)
+	assert.True(t, strings.HasPrefix(diagnostics[0].Message, "lint.yml:"), diagnostics[0].Message)
+}
+
+// TestDiagnosticsStale ensures that the results of the Starlark evaluation
+// are not published when the document has changed in the meantime.
+func TestDiagnosticsStale(t *testing.T) {
+	c := initializedClient(t)
+
+	// Make sure that the evaluation outlives the change
+	c.open("file:///project/.cirrus.star", `def main(ctx):
+    for _ in range(1000000):
+        pass
+
+    fail("stale")
+`)
+	c.change("file:///project/.cirrus.star", `def main(ctx):
+    return []
+`)
+
+	published := c.remainingDiagnostics()
+	require.NotEmpty(t, published)
+	assert.Empty(t, published[len(published)-1])
+}
+
+func TestCompletion(t *testing.T) {
+	c := initializedClient(t)
+
+	c.open("file:///project/.cirrus.yml", `task:
+  container:
+    im
+`)
+	c.diagnostics()
+
+	var items []lsp.CompletionItem
+	c.request("textDocument/completion", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///project/.cirrus.yml"},
+		"position":     map[string]interface{}{"line": 2, "character": 6},
+	}, &items)
+
+	var labels []string
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	assert.Contains(t, labels, "image")
+	assert.NotContains(t, labels, "cpu")
+}
+
+func TestHover(t *testing.T) {
+	c := initializedClient(t)
+
+	c.open("file:///project/.cirrus.yml", `task:
+  container:
+    image: debian:latest
+  script: true
+`)
+	c.diagnostics()
+
+	var hover lsp.Hover
+	c.request("textDocument/hover", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///project/.cirrus.yml"},
+		"position":     map[string]interface{}{"line": 2, "character": 6},
+	}, &hover)
+
+	require.NotNil(t, hover.Range)
+	assert.Equal(t, lsp.Position{Line: 2, Character: 4}, hover.Range.Start)
+	assert.Contains(t, hover.Contents.Value, "**image**")
+}
@@ -0,0 +1,67 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+var ErrMalformedMessage = errors.New("malformed message")
+
+// transport implements the LSP base protocol: JSON-RPC messages prefixed with
+// the HTTP-like headers, of which only Content-Length is mandatory.
+type transport struct {
+	reader *textproto.Reader
+
+	writer     io.Writer
+	writerLock sync.Mutex
+}
+
+func newTransport(r io.Reader, w io.Writer) *transport {
+	return &transport{
+		reader: textproto.NewReader(bufio.NewReader(r)),
+		writer: w,
+	}
+}
+
+func (t *transport) read() ([]byte, error) {
+	headers, err := t.reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	contentLength, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid Content-Length header: %v", ErrMalformedMessage, err)
+	}
+
+	content := make([]byte, contentLength)
+	if _, err := io.ReadFull(t.reader.R, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (t *transport) write(message interface{}) error {
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	t.writerLock.Lock()
+	defer t.writerLock.Unlock()
+
+	if _, err := fmt.Fprintf(t.writer, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = t.writer.Write(content)
+
+	return err
+}
@@ -0,0 +1,161 @@
+package builtin
+
+import (
+	"errors"
+	"fmt"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"regexp"
+)
+
+var ErrAssertionFailed = errors.New("assertion failed")
+
+// Assert returns the members of the assert module used in the Starlark tests.
+func Assert() starlark.StringDict {
+	return starlark.StringDict{
+		"eq":       assertEq(),
+		"ne":       assertNe(),
+		"true":     assertTrue(),
+		"contains": assertContains(),
+		"fails":    assertFails(),
+	}
+}
+
+func assertEq() starlark.Value {
+	const funcName = "eq"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var actual, expected starlark.Value
+		var msg string
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "actual", &actual, "expected", &expected,
+			"msg?", &msg); err != nil {
+			return nil, err
+		}
+
+		equal, err := starlark.Equal(actual, expected)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal {
+			return nil, assertionFailed(msg, "%s != %s", actual.String(), expected.String())
+		}
+
+		return starlark.None, nil
+	})
+}
+
+func assertNe() starlark.Value {
+	const funcName = "ne"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var actual, unexpected starlark.Value
+		var msg string
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "actual", &actual, "unexpected", &unexpected,
+			"msg?", &msg); err != nil {
+			return nil, err
+		}
+
+		equal, err := starlark.Equal(actual, unexpected)
+		if err != nil {
+			return nil, err
+		}
+
+		if equal {
+			return nil, assertionFailed(msg, "%s == %s", actual.String(), unexpected.String())
+		}
+
+		return starlark.None, nil
+	})
+}
+
+func assertTrue() starlark.Value {
+	const funcName = "true"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var condition starlark.Value
+		var msg string
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "condition", &condition, "msg?", &msg); err != nil {
+			return nil, err
+		}
+
+		if !condition.Truth() {
+			return nil, assertionFailed(msg, "%s is not true", condition.String())
+		}
+
+		return starlark.None, nil
+	})
+}
+
+func assertContains() starlark.Value {
+	const funcName = "contains"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var container, item starlark.Value
+		var msg string
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "container", &container, "item", &item,
+			"msg?", &msg); err != nil {
+			return nil, err
+		}
+
+		// Use the semantics of the "in" operator, so that strings, lists and dicts are supported
+		contains, err := starlark.Binary(syntax.IN, item, container)
+		if err != nil {
+			return nil, err
+		}
+
+		if !contains.Truth() {
+			return nil, assertionFailed(msg, "%s does not contain %s", container.String(), item.String())
+		}
+
+		return starlark.None, nil
+	})
+}
+
+func assertFails() starlark.Value {
+	const funcName = "fails"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var callable starlark.Callable
+		var pattern string
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "fn", &callable, "pattern?", &pattern); err != nil {
+			return nil, err
+		}
+
+		_, err := starlark.Call(thread, callable, nil, nil)
+		if err == nil {
+			return nil, assertionFailed("", "%s did not fail", callable.Name())
+		}
+
+		errorMessage := err.Error()
+
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			errorMessage = evalErr.Msg
+		}
+
+		if pattern != "" {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+
+			if !re.MatchString(errorMessage) {
+				return nil, assertionFailed("", "%s failed with %q, which doesn't match %q",
+					callable.Name(), errorMessage, pattern)
+			}
+		}
+
+		return starlark.String(errorMessage), nil
+	})
+}
+
+func assertionFailed(msg string, format string, args ...interface{}) error {
+	description := fmt.Sprintf(format, args...)
+
+	if msg != "" {
+		description = msg + ": " + description
+	}
+
+	return fmt.Errorf("%w: %s", ErrAssertionFailed, description)
+}
@@ -3,8 +3,10 @@ package builtin
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
 	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
 	"os"
 )
 
@@ -14,6 +16,8 @@ func FS(ctx context.Context, fs fs.FileSystem) starlark.StringDict {
 		"read":    read(ctx, fs),
 		"readdir": readdir(ctx, fs),
 		"isdir":   isdir(ctx, fs),
+		"glob":    glob(ctx, fs),
+		"stat":    stat(ctx, fs),
 	}
 }
 
@@ -109,3 +113,56 @@ func isdir(ctx context.Context, fs fs.FileSystem) starlark.Value {
 		return starlark.Bool(fileInfo.IsDir), nil
 	})
 }
+
+func glob(ctx context.Context, fileSystem fs.FileSystem) starlark.Value {
+	const funcName = "glob"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var pattern string
+		if err := starlark.UnpackPositionalArgs(funcName, args, kwargs, 1, &pattern); err != nil {
+			return nil, err
+		}
+
+		matches, err := fs.Glob(ctx, fileSystem, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		var starlarkMatches []starlark.Value
+		for _, match := range matches {
+			starlarkMatches = append(starlarkMatches, starlark.String(match))
+		}
+
+		return starlark.NewList(starlarkMatches), nil
+	})
+}
+
+func stat(ctx context.Context, fs fs.FileSystem) starlark.Value {
+	const funcName = "stat"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var path string
+		if err := starlark.UnpackPositionalArgs(funcName, args, kwargs, 1, &path); err != nil {
+			return nil, err
+		}
+
+		fileInfo, err := fs.Stat(ctx, path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return starlark.None, nil
+			}
+
+			return nil, err
+		}
+
+		fileType := "file"
+		if fileInfo.IsDir {
+			fileType = "dir"
+		}
+
+		return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"type": starlark.String(fileType),
+			"size": starlark.MakeInt64(fileInfo.Size),
+		}), nil
+	})
+}
@@ -0,0 +1,314 @@
+package builtin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrGitUnavailable = errors.New("git repository is not available")
+
+const defaultCommitsLimit = 10
+
+// gitRepository lazily opens the repository, so that the evaluation doesn't pay
+// for it (or fail) unless the git module is actually used.
+type gitRepository struct {
+	dir string
+
+	once sync.Once
+	repo *git.Repository
+	err  error
+}
+
+// Git returns the members of the git module, which provides the metadata of the local Git repository
+// that contains the directory. The directory can be empty when the configuration is not evaluated locally.
+func Git(dir string) starlark.StringDict {
+	repository := &gitRepository{dir: dir}
+
+	return starlark.StringDict{
+		"branch":        gitBranch(repository),
+		"changed_files": gitChangedFiles(repository),
+		"commits":       gitCommits(repository),
+	}
+}
+
+func (repository *gitRepository) open() (*git.Repository, error) {
+	repository.once.Do(func() {
+		if repository.dir == "" {
+			repository.err = fmt.Errorf("%w: the configuration is not evaluated from a local directory",
+				ErrGitUnavailable)
+
+			return
+		}
+
+		repo, err := git.PlainOpenWithOptions(repository.dir, &git.PlainOpenOptions{DetectDotGit: true})
+		if err != nil {
+			repository.err = fmt.Errorf("%w: %v", ErrGitUnavailable, err)
+
+			return
+		}
+
+		repository.repo = repo
+	})
+
+	return repository.repo, repository.err
+}
+
+func (repository *gitRepository) headCommit() (*git.Repository, *object.Commit, error) {
+	repo, err := repository.open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repo, commit, nil
+}
+
+// projectPrefix returns the path of the directory relative to the repository root with a trailing slash,
+// or an empty string if the directory is the repository root.
+func (repository *gitRepository) projectPrefix(repo *git.Repository) (string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	root, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := filepath.Abs(repository.dir)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	relativeDir, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", err
+	}
+
+	if relativeDir == "." {
+		return "", nil
+	}
+
+	return filepath.ToSlash(relativeDir) + "/", nil
+}
+
+func gitBranch(repository *gitRepository) starlark.Value {
+	const funcName = "branch"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := starlark.UnpackPositionalArgs(funcName, args, kwargs, 0); err != nil {
+			return nil, err
+		}
+
+		repo, err := repository.open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		head, err := repo.Head()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		// Detached HEAD
+		if !head.Name().IsBranch() {
+			return starlark.None, nil
+		}
+
+		return starlark.String(head.Name().Short()), nil
+	})
+}
+
+func gitChangedFiles(repository *gitRepository) starlark.Value {
+	const funcName = "changed_files"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var base string
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "base?", &base); err != nil {
+			return nil, err
+		}
+
+		changedFiles, err := changedFiles(repository, base)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		var starlarkChangedFiles []starlark.Value
+		for _, changedFile := range changedFiles {
+			starlarkChangedFiles = append(starlarkChangedFiles, starlark.String(changedFile))
+		}
+
+		return starlark.NewList(starlarkChangedFiles), nil
+	})
+}
+
+// changedFiles returns the files changed by the HEAD commit or, when the base revision is specified,
+// by all the commits since HEAD diverged from it. The paths are relative to the project directory.
+func changedFiles(repository *gitRepository, base string) ([]string, error) {
+	repo, headCommit, err := repository.headCommit()
+	if err != nil {
+		return nil, err
+	}
+
+	var fromCommit *object.Commit
+
+	if base == "" {
+		if headCommit.NumParents() != 0 {
+			fromCommit, err = headCommit.Parent(0)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		baseHash, err := repo.ResolveRevision(plumbing.Revision(base))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %q: %w", base, err)
+		}
+
+		baseCommit, err := repo.CommitObject(*baseHash)
+		if err != nil {
+			return nil, err
+		}
+
+		mergeBases, err := headCommit.MergeBase(baseCommit)
+		if err != nil {
+			return nil, err
+		}
+		if len(mergeBases) == 0 {
+			return nil, fmt.Errorf("HEAD has no common history with %q", base)
+		}
+
+		fromCommit = mergeBases[0]
+	}
+
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	// A root commit is compared against an empty tree
+	var fromTree *object.Tree
+
+	if fromCommit != nil {
+		fromTree, err = fromCommit.Tree()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTree(fromTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+
+	// Git reports the paths relative to the repository root, while the other builtins
+	// (e.g. fs.glob() and fs.stat()) use the paths relative to the project directory
+	projectPrefix, err := repository.projectPrefix(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueChangedFiles := map[string]struct{}{}
+
+	for _, change := range changes {
+		// Renames have both names set
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			// Skip the files outside of the project directory
+			if name == "" || !strings.HasPrefix(name, projectPrefix) {
+				continue
+			}
+
+			uniqueChangedFiles[strings.TrimPrefix(name, projectPrefix)] = struct{}{}
+		}
+	}
+
+	var result []string
+
+	for changedFile := range uniqueChangedFiles {
+		result = append(result, changedFile)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+func gitCommits(repository *gitRepository) starlark.Value {
+	const funcName = "commits"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		limit := defaultCommitsLimit
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "limit?", &limit); err != nil {
+			return nil, err
+		}
+
+		repo, headCommit, err := repository.headCommit()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		commitIter, err := repo.Log(&git.LogOptions{From: headCommit.Hash})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+		defer commitIter.Close()
+
+		var commits []starlark.Value
+
+		err = commitIter.ForEach(func(commit *object.Commit) error {
+			if len(commits) >= limit {
+				return storer.ErrStop
+			}
+
+			commits = append(commits, gitCommitValue(&GitCommit{
+				SHA:     commit.Hash.String(),
+				Message: strings.TrimSpace(commit.Message),
+				Author:  commit.Author.Name,
+				Email:   commit.Author.Email,
+				Time:    commit.Author.When.Unix(),
+			}))
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		return starlark.NewList(commits), nil
+	})
+}
+
+func gitCommitValue(commit *GitCommit) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"sha":     starlark.String(commit.SHA),
+		"message": starlark.String(commit.Message),
+		"author":  starlark.String(commit.Author),
+		"email":   starlark.String(commit.Email),
+		"time":    starlark.MakeInt64(commit.Time),
+	})
+}
@@ -0,0 +1,99 @@
+package builtin
+
+import (
+	"fmt"
+	starlibhttp "github.com/qri-io/starlib/http"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"io/ioutil"
+	gohttp "net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTP wraps the request methods of the http module so that the requests
+// are served from the HTTP mocks once they're enabled.
+func HTTP(httpModule *starlarkstruct.Struct, mocks *Mocks) starlark.Value {
+	members := starlark.StringDict{}
+
+	for _, name := range httpModule.AttrNames() {
+		realMethod, err := httpModule.Attr(name)
+		if err != nil {
+			continue
+		}
+
+		members[name] = mockableRequest(name, realMethod, mocks)
+	}
+
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, members)
+}
+
+func mockableRequest(method string, realMethod starlark.Value, mocks *Mocks) starlark.Value {
+	return starlark.NewBuiltin(method, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if !mocks.httpMocked() {
+			return starlark.Call(thread, realMethod, args, kwargs)
+		}
+
+		var rawURL string
+		var params *starlark.Dict
+		var headers, body, formBody, formEncoding, jsonBody, auth starlark.Value
+		if err := starlark.UnpackArgs(method, args, kwargs, "url", &rawURL, "params?", &params,
+			"headers?", &headers, "body?", &body, "form_body?", &formBody, "form_encoding?", &formEncoding,
+			"json_body?", &jsonBody, "auth?", &auth); err != nil {
+			return nil, err
+		}
+
+		requestURL, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if params != nil {
+			query := requestURL.Query()
+
+			for _, item := range params.Items() {
+				key, keyOk := starlark.AsString(item[0])
+				value, valueOk := starlark.AsString(item[1])
+				if !keyOk || !valueOk {
+					return nil, fmt.Errorf("%s: params should be a dict of strings", method)
+				}
+
+				query.Add(key, value)
+			}
+
+			requestURL.RawQuery = query.Encode()
+		}
+
+		request, err := gohttp.NewRequest(strings.ToUpper(method), requestURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		mock, err := mocks.matchHTTP(request.Method, request.URL.String())
+		if err != nil {
+			return nil, err
+		}
+
+		status := mock.Status
+		if status == 0 {
+			status = gohttp.StatusOK
+		}
+
+		header := gohttp.Header{}
+		for key, value := range mock.Headers {
+			header.Set(key, value)
+		}
+
+		response := &starlibhttp.Response{
+			Response: gohttp.Response{
+				Status:     fmt.Sprintf("%d %s", status, gohttp.StatusText(status)),
+				StatusCode: status,
+				Header:     header,
+				Body:       ioutil.NopCloser(strings.NewReader(mock.Body)),
+				Request:    request,
+			},
+		}
+
+		return response.Struct(), nil
+	})
+}
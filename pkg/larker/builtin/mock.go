@@ -0,0 +1,485 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/memory"
+	"go.starlark.net/starlark"
+	"regexp"
+	"strings"
+)
+
+var ErrUnexpectedHTTPRequest = errors.New("unexpected HTTP request")
+
+// Mocks holds the replacements for the env, fs, git and http members of the cirrus module
+// that the tests can set at runtime through the mock module.
+type Mocks struct {
+	env  *starlark.Dict
+	fs   fs.FileSystem
+	git  *GitMock
+	http []*HTTPMock
+
+	httpRequired           bool
+	unexpectedHTTPRequests []string
+}
+
+// HTTPMock is a canned response to the HTTP requests matching the method and the URL.
+type HTTPMock struct {
+	// Method matches any method when empty.
+	Method string
+	// URL is a regular expression that should match the whole request URL.
+	URL     string
+	Status  int
+	Headers map[string]string
+	Body    string
+
+	urlRegexp *regexp.Regexp
+}
+
+// GitMock is the metadata of the local Git repository returned by the git module.
+type GitMock struct {
+	// Branch is reported as a detached HEAD when empty.
+	Branch       string
+	ChangedFiles []string
+	Commits      []*GitCommit
+}
+
+// GitCommit is a commit as returned by git.commits().
+type GitCommit struct {
+	SHA     string
+	Message string
+	Author  string
+	Email   string
+	Time    int64
+}
+
+func NewMocks() *Mocks {
+	return &Mocks{}
+}
+
+// Fork returns a copy of the mocks without the recorded requests, so that the changes made
+// to it (e.g. by a test calling the mock module) don't affect the original.
+func (mocks *Mocks) Fork() *Mocks {
+	return &Mocks{
+		env:          mocks.env,
+		fs:           mocks.fs,
+		git:          mocks.git,
+		http:         append([]*HTTPMock{}, mocks.http...),
+		httpRequired: mocks.httpRequired,
+	}
+}
+
+// AddHTTP adds a canned HTTP response, after which only the requests matching one of the mocks are allowed.
+func (mocks *Mocks) AddHTTP(mock *HTTPMock) error {
+	urlRegexp, err := regexp.Compile("^(?:" + mock.URL + ")$")
+	if err != nil {
+		return err
+	}
+	mock.urlRegexp = urlRegexp
+
+	mocks.http = append(mocks.http, mock)
+
+	return nil
+}
+
+// RequireHTTPMocks makes the requests fail unless they match one of the HTTP mocks, even if none are set.
+func (mocks *Mocks) RequireHTTPMocks() {
+	mocks.httpRequired = true
+}
+
+func (mocks *Mocks) httpMocked() bool {
+	return mocks.httpRequired || len(mocks.http) != 0
+}
+
+// UnexpectedHTTPRequests returns the requests that didn't match any of the HTTP mocks in the "METHOD URL" form.
+func (mocks *Mocks) UnexpectedHTTPRequests() []string {
+	return mocks.unexpectedHTTPRequests
+}
+
+func (mocks *Mocks) matchHTTP(method string, url string) (*HTTPMock, error) {
+	for _, mock := range mocks.http {
+		if mock.Method != "" && !strings.EqualFold(mock.Method, method) {
+			continue
+		}
+
+		if mock.urlRegexp.MatchString(url) {
+			return mock, nil
+		}
+	}
+
+	mocks.unexpectedHTTPRequests = append(mocks.unexpectedHTTPRequests, method+" "+url)
+
+	return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedHTTPRequest, method, url)
+}
+
+// Mock returns the members of the mock module used in the Starlark tests.
+func Mock(mocks *Mocks) starlark.StringDict {
+	return starlark.StringDict{
+		"env":  mockEnv(mocks),
+		"fs":   mockFS(mocks),
+		"git":  mockGit(mocks),
+		"http": mockHTTP(mocks),
+	}
+}
+
+func mockEnv(mocks *Mocks) starlark.Value {
+	const funcName = "env"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var env *starlark.Dict
+		if err := starlark.UnpackPositionalArgs(funcName, args, kwargs, 1, &env); err != nil {
+			return nil, err
+		}
+
+		mockedEnv := starlark.NewDict(env.Len())
+
+		for _, item := range env.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("%s: environment variable name %s is not a string", funcName, item[0])
+			}
+
+			value, ok := starlark.AsString(item[1])
+			if !ok {
+				return nil, fmt.Errorf("%s: environment variable %s value is not a string", funcName, key)
+			}
+
+			if err := mockedEnv.SetKey(starlark.String(key), starlark.String(value)); err != nil {
+				return nil, err
+			}
+		}
+
+		mockedEnv.Freeze()
+		mocks.env = mockedEnv
+
+		return starlark.None, nil
+	})
+}
+
+func mockFS(mocks *Mocks) starlark.Value {
+	const funcName = "fs"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var files *starlark.Dict
+		if err := starlark.UnpackPositionalArgs(funcName, args, kwargs, 1, &files); err != nil {
+			return nil, err
+		}
+
+		fileContents := map[string][]byte{}
+
+		for _, item := range files.Items() {
+			path, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("%s: path %s is not a string", funcName, item[0])
+			}
+
+			contents, ok := starlark.AsString(item[1])
+			if !ok {
+				return nil, fmt.Errorf("%s: %s contents is not a string", funcName, path)
+			}
+
+			fileContents[path] = []byte(contents)
+		}
+
+		memoryFS, err := memory.New(fileContents)
+		if err != nil {
+			return nil, err
+		}
+		mocks.fs = memoryFS
+
+		return starlark.None, nil
+	})
+}
+
+func mockGit(mocks *Mocks) starlark.Value {
+	const funcName = "git"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var branch string
+		var changedFiles, commits *starlark.List
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "branch?", &branch,
+			"changed_files?", &changedFiles, "commits?", &commits); err != nil {
+			return nil, err
+		}
+
+		mock := &GitMock{Branch: branch}
+
+		if changedFiles != nil {
+			for i := 0; i < changedFiles.Len(); i++ {
+				changedFile, ok := starlark.AsString(changedFiles.Index(i))
+				if !ok {
+					return nil, fmt.Errorf("%s: changed file %s is not a string", funcName, changedFiles.Index(i))
+				}
+
+				mock.ChangedFiles = append(mock.ChangedFiles, changedFile)
+			}
+		}
+
+		if commits != nil {
+			for i := 0; i < commits.Len(); i++ {
+				commit, err := unpackGitCommit(commits.Index(i))
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", funcName, err)
+				}
+
+				mock.Commits = append(mock.Commits, commit)
+			}
+		}
+
+		mocks.git = mock
+
+		return starlark.None, nil
+	})
+}
+
+func unpackGitCommit(value starlark.Value) (*GitCommit, error) {
+	dict, ok := value.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("commit %s is not a dict", value)
+	}
+
+	// Unpack the dict as if its items were passed as keyword arguments
+	var commit GitCommit
+	if err := starlark.UnpackArgs("commit", nil, dict.Items(), "sha?", &commit.SHA,
+		"message?", &commit.Message, "author?", &commit.Author, "email?", &commit.Email,
+		"time?", &commit.Time); err != nil {
+		return nil, err
+	}
+
+	return &commit, nil
+}
+
+func mockHTTP(mocks *Mocks) starlark.Value {
+	const funcName = "http"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var url, method, body string
+		var headers *starlark.Dict
+		status := 200
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "url", &url, "method?", &method,
+			"status?", &status, "headers?", &headers, "body?", &body); err != nil {
+			return nil, err
+		}
+
+		mock := &HTTPMock{
+			Method:  method,
+			URL:     url,
+			Status:  status,
+			Headers: map[string]string{},
+			Body:    body,
+		}
+
+		if headers != nil {
+			for _, item := range headers.Items() {
+				key, keyOk := starlark.AsString(item[0])
+				value, valueOk := starlark.AsString(item[1])
+				if !keyOk || !valueOk {
+					return nil, fmt.Errorf("%s: headers should be a dict of strings", funcName)
+				}
+
+				mock.Headers[key] = value
+			}
+		}
+
+		if err := mocks.AddHTTP(mock); err != nil {
+			return nil, fmt.Errorf("%s: invalid URL pattern: %v", funcName, err)
+		}
+
+		return starlark.None, nil
+	})
+}
+
+// MockableFS is a file system that is replaced by the fs mock once it's set.
+func MockableFS(base fs.FileSystem, mocks *Mocks) fs.FileSystem {
+	return &mockableFS{base: base, mocks: mocks}
+}
+
+type mockableFS struct {
+	base  fs.FileSystem
+	mocks *Mocks
+}
+
+func (mfs *mockableFS) current() fs.FileSystem {
+	if mfs.mocks.fs != nil {
+		return mfs.mocks.fs
+	}
+
+	return mfs.base
+}
+
+func (mfs *mockableFS) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
+	return mfs.current().Stat(ctx, path)
+}
+
+func (mfs *mockableFS) Get(ctx context.Context, path string) ([]byte, error) {
+	return mfs.current().Get(ctx, path)
+}
+
+func (mfs *mockableFS) ReadDir(ctx context.Context, path string) ([]string, error) {
+	return mfs.current().ReadDir(ctx, path)
+}
+
+func (mfs *mockableFS) Join(elem ...string) string {
+	return mfs.current().Join(elem...)
+}
+
+// MockableEnv returns a read-only dict-like value that reflects the env mock once it's set.
+func MockableEnv(base *starlark.Dict, mocks *Mocks) starlark.Value {
+	base.Freeze()
+
+	return &mockableEnv{base: base, mocks: mocks}
+}
+
+type mockableEnv struct {
+	base  *starlark.Dict
+	mocks *Mocks
+}
+
+var (
+	_ starlark.IterableMapping = (*mockableEnv)(nil)
+	_ starlark.Sequence        = (*mockableEnv)(nil)
+	_ starlark.HasAttrs        = (*mockableEnv)(nil)
+)
+
+func (env *mockableEnv) current() *starlark.Dict {
+	if env.mocks.env != nil {
+		return env.mocks.env
+	}
+
+	return env.base
+}
+
+func (env *mockableEnv) String() string {
+	return env.current().String()
+}
+
+// Type pretends to be a dict, so that the modules checking the type of env keep working in tests.
+func (env *mockableEnv) Type() string {
+	return "dict"
+}
+
+func (env *mockableEnv) Freeze() {}
+
+func (env *mockableEnv) Truth() starlark.Bool {
+	return env.current().Truth()
+}
+
+func (env *mockableEnv) Hash() (uint32, error) {
+	return env.current().Hash()
+}
+
+func (env *mockableEnv) Get(key starlark.Value) (starlark.Value, bool, error) {
+	return env.current().Get(key)
+}
+
+func (env *mockableEnv) Iterate() starlark.Iterator {
+	return env.current().Iterate()
+}
+
+func (env *mockableEnv) Items() []starlark.Tuple {
+	return env.current().Items()
+}
+
+func (env *mockableEnv) Len() int {
+	return env.current().Len()
+}
+
+func (env *mockableEnv) Attr(name string) (starlark.Value, error) {
+	return env.current().Attr(name)
+}
+
+func (env *mockableEnv) AttrNames() []string {
+	return env.current().AttrNames()
+}
+
+// MockableGit returns the members of the git module that serve the metadata from the git mock,
+// failing until it's set, so that the tests don't depend on the repository they're run from.
+func MockableGit(mocks *Mocks) starlark.StringDict {
+	return starlark.StringDict{
+		"branch":        mockableGitBranch(mocks),
+		"changed_files": mockableGitChangedFiles(mocks),
+		"commits":       mockableGitCommits(mocks),
+	}
+}
+
+func (mocks *Mocks) gitMock() (*GitMock, error) {
+	if mocks == nil || mocks.git == nil {
+		return nil, fmt.Errorf("%w: use mock.git() in tests", ErrGitUnavailable)
+	}
+
+	return mocks.git, nil
+}
+
+func mockableGitBranch(mocks *Mocks) starlark.Value {
+	const funcName = "branch"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := starlark.UnpackPositionalArgs(funcName, args, kwargs, 0); err != nil {
+			return nil, err
+		}
+
+		mock, err := mocks.gitMock()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		// Detached HEAD
+		if mock.Branch == "" {
+			return starlark.None, nil
+		}
+
+		return starlark.String(mock.Branch), nil
+	})
+}
+
+func mockableGitChangedFiles(mocks *Mocks) starlark.Value {
+	const funcName = "changed_files"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var base string
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "base?", &base); err != nil {
+			return nil, err
+		}
+
+		mock, err := mocks.gitMock()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		var starlarkChangedFiles []starlark.Value
+		for _, changedFile := range mock.ChangedFiles {
+			starlarkChangedFiles = append(starlarkChangedFiles, starlark.String(changedFile))
+		}
+
+		return starlark.NewList(starlarkChangedFiles), nil
+	})
+}
+
+func mockableGitCommits(mocks *Mocks) starlark.Value {
+	const funcName = "commits"
+
+	return starlark.NewBuiltin(funcName, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		limit := defaultCommitsLimit
+		if err := starlark.UnpackArgs(funcName, args, kwargs, "limit?", &limit); err != nil {
+			return nil, err
+		}
+
+		mock, err := mocks.gitMock()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+
+		var commits []starlark.Value
+		for _, commit := range mock.Commits {
+			if len(commits) >= limit {
+				break
+			}
+
+			commits = append(commits, gitCommitValue(commit))
+		}
+
+		return starlark.NewList(commits), nil
+	})
+}
@@ -131,6 +131,8 @@ func convertPrimitive(value starlark.Value) interface{} {
 	case starlark.Int:
 		res, _ := typedValue.Int64()
 		return res
+	case starlark.NoneType:
+		return nil
 	default:
 		return typedValue
 	}
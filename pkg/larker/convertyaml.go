@@ -0,0 +1,327 @@
+package larker
+
+import (
+	"errors"
+	"fmt"
+	"go.starlark.net/starlark"
+	"gopkg.in/yaml.v3"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotAMapping  = errors.New("YAML configuration should be a mapping")
+	ErrDuplicateKey = errors.New("duplicate key cannot be represented in a Starlark dict")
+)
+
+const starlarkIndent = "    "
+
+// ConvertYAML converts the YAML configuration into an equivalent .cirrus.star,
+// whose main() returns the same configuration.
+//
+// Anchors, aliases and merge keys are expanded and the comments preceding the keys are carried over.
+func ConvertYAML(source []byte) (string, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(source, &document); err != nil {
+		return "", err
+	}
+
+	builder := &strings.Builder{}
+
+	var root *yaml.Node
+
+	if len(document.Content) != 0 {
+		root = resolveAlias(document.Content[0])
+
+		writeComment(builder, "", document.HeadComment)
+		writeComment(builder, "", root.HeadComment)
+
+		if builder.Len() != 0 {
+			builder.WriteString("\n")
+		}
+
+		if root.Kind != yaml.MappingNode && root.ShortTag() != "!!null" {
+			return "", fmt.Errorf("%w, found %s", ErrNotAMapping, root.ShortTag())
+		}
+	}
+
+	builder.WriteString("def main(ctx):\n")
+
+	// Top-level keys can repeat (e.g. several "task:"), so unless all of them are tasks,
+	// use the [(key, value)] syntax supported by the main() instead of dicts
+	var entries []*yamlEntry
+
+	if root != nil && root.Kind == yaml.MappingNode {
+		var err error
+
+		entries, err = mappingEntries(root, true)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(entries) == 0 {
+		builder.WriteString(starlarkIndent + "return []\n")
+
+		return builder.String(), nil
+	}
+
+	onlyTasks := true
+
+	for _, entry := range entries {
+		if entry.key != "task" || resolveAlias(entry.value).Kind != yaml.MappingNode {
+			onlyTasks = false
+		}
+	}
+
+	builder.WriteString(starlarkIndent + "return [\n")
+
+	for _, entry := range entries {
+		indent := strings.Repeat(starlarkIndent, 2)
+
+		writeComment(builder, indent, entry.comment)
+		builder.WriteString(indent)
+
+		if !onlyTasks {
+			builder.WriteString("(" + starlark.String(entry.key).String() + ", ")
+		}
+
+		if err := writeValue(builder, 2, entry.value); err != nil {
+			return "", err
+		}
+
+		if !onlyTasks {
+			builder.WriteString(")")
+		}
+
+		builder.WriteString("," + entry.lineComment + "\n")
+	}
+
+	builder.WriteString(starlarkIndent + "]\n")
+
+	return builder.String(), nil
+}
+
+type yamlEntry struct {
+	key         string
+	value       *yaml.Node
+	comment     string
+	lineComment string
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	return node
+}
+
+// mappingEntries returns the key-value pairs of the mapping with the merge keys ("<<") expanded,
+// where the explicitly specified keys take precedence over the merged ones.
+func mappingEntries(mapping *yaml.Node, allowDuplicates bool) ([]*yamlEntry, error) {
+	explicit := map[string]bool{}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := resolveAlias(mapping.Content[i])
+
+		if key.ShortTag() == "!!merge" {
+			continue
+		}
+
+		if explicit[key.Value] && !allowDuplicates {
+			return nil, fmt.Errorf("%w: %q on line %d", ErrDuplicateKey, key.Value, key.Line)
+		}
+
+		explicit[key.Value] = true
+	}
+
+	var result []*yamlEntry
+	merged := map[string]bool{}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := resolveAlias(mapping.Content[i])
+		value := mapping.Content[i+1]
+
+		if key.ShortTag() != "!!merge" {
+			result = append(result, &yamlEntry{
+				key:         key.Value,
+				value:       value,
+				comment:     key.HeadComment,
+				lineComment: lineComment(value),
+			})
+
+			continue
+		}
+
+		// The value of the merge key is either a mapping or a sequence of mappings
+		sources := []*yaml.Node{resolveAlias(value)}
+		if sources[0].Kind == yaml.SequenceNode {
+			sources = sources[0].Content
+		}
+
+		for _, source := range sources {
+			source = resolveAlias(source)
+
+			if source.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%w: merge key on line %d should reference a mapping",
+					ErrNotAMapping, key.Line)
+			}
+
+			sourceEntries, err := mappingEntries(source, false)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, sourceEntry := range sourceEntries {
+				if explicit[sourceEntry.key] || merged[sourceEntry.key] {
+					continue
+				}
+
+				merged[sourceEntry.key] = true
+
+				result = append(result, &yamlEntry{
+					key:   sourceEntry.key,
+					value: sourceEntry.value,
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func writeValue(builder *strings.Builder, level int, node *yaml.Node) error {
+	node = resolveAlias(node)
+	indent := strings.Repeat(starlarkIndent, level+1)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		entries, err := mappingEntries(node, false)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) == 0 {
+			builder.WriteString("{}")
+
+			return nil
+		}
+
+		builder.WriteString("{\n")
+
+		for _, entry := range entries {
+			writeComment(builder, indent, entry.comment)
+			builder.WriteString(indent + starlark.String(entry.key).String() + ": ")
+
+			if err := writeValue(builder, level+1, entry.value); err != nil {
+				return err
+			}
+
+			builder.WriteString("," + entry.lineComment + "\n")
+		}
+
+		builder.WriteString(strings.Repeat(starlarkIndent, level) + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			builder.WriteString("[]")
+
+			return nil
+		}
+
+		builder.WriteString("[\n")
+
+		for _, item := range node.Content {
+			writeComment(builder, indent, item.HeadComment)
+			builder.WriteString(indent)
+
+			if err := writeValue(builder, level+1, item); err != nil {
+				return err
+			}
+
+			builder.WriteString("," + lineComment(item) + "\n")
+		}
+
+		builder.WriteString(strings.Repeat(starlarkIndent, level) + "]")
+	default:
+		builder.WriteString(scalarToStarlark(node))
+	}
+
+	return nil
+}
+
+func scalarToStarlark(node *yaml.Node) string {
+	switch node.ShortTag() {
+	case "!!null":
+		return "None"
+	case "!!bool":
+		var value bool
+
+		if err := node.Decode(&value); err == nil {
+			if value {
+				return "True"
+			}
+
+			return "False"
+		}
+	case "!!int":
+		var value int64
+
+		if err := node.Decode(&value); err == nil {
+			return strconv.FormatInt(value, 10)
+		}
+	case "!!float":
+		var value float64
+
+		if err := node.Decode(&value); err == nil {
+			return floatToStarlark(value)
+		}
+	}
+
+	return starlark.String(node.Value).String()
+}
+
+func floatToStarlark(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return `float("nan")`
+	case math.IsInf(value, 1):
+		return `float("inf")`
+	case math.IsInf(value, -1):
+		return `float("-inf")`
+	}
+
+	result := strconv.FormatFloat(value, 'g', -1, 64)
+
+	// Make sure that the literal isn't mistaken for an integer
+	if !strings.ContainsAny(result, ".e") {
+		result += ".0"
+	}
+
+	return result
+}
+
+func writeComment(builder *strings.Builder, indent string, comment string) {
+	if comment == "" {
+		return
+	}
+
+	for _, line := range strings.Split(comment, "\n") {
+		if line == "" {
+			continue
+		}
+
+		builder.WriteString(indent + line + "\n")
+	}
+}
+
+// lineComment returns the comment following the scalar on the same line, prefixed with the spacing.
+func lineComment(node *yaml.Node) string {
+	if node.LineComment == "" || node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		return ""
+	}
+
+	return "  " + node.LineComment
+}
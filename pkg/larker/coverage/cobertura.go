@@ -0,0 +1,124 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"time"
+)
+
+const coberturaDoctype = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      int                `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity int              `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity int             `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// WriteCobertura writes the coverage in the Cobertura XML format, with a package per directory.
+func (coverage *Coverage) WriteCobertura(w io.Writer) error {
+	report := &coberturaCoverage{
+		BranchRate: formatRate(0),
+		Timestamp:  time.Now().Unix(),
+		Sources:    []string{"."},
+	}
+
+	packages := map[string]*coberturaPackage{}
+	packageCovered := map[string]int{}
+	packageTotal := map[string]int{}
+
+	var packageNames []string
+
+	for _, file := range coverage.Files() {
+		covered, total := file.Summary()
+
+		class := coberturaClass{
+			Name:       path.Base(file.Path),
+			Filename:   file.Path,
+			LineRate:   formatRate(rate(covered, total)),
+			BranchRate: formatRate(0),
+		}
+
+		for _, line := range file.Lines {
+			class.Lines = append(class.Lines, coberturaLine{Number: line.Line, Hits: line.Hits})
+		}
+
+		packageName := path.Dir(file.Path)
+
+		pkg, ok := packages[packageName]
+		if !ok {
+			pkg = &coberturaPackage{Name: packageName, BranchRate: formatRate(0)}
+			packages[packageName] = pkg
+			packageNames = append(packageNames, packageName)
+		}
+
+		pkg.Classes = append(pkg.Classes, class)
+		packageCovered[packageName] += covered
+		packageTotal[packageName] += total
+
+		report.LinesCovered += covered
+		report.LinesValid += total
+	}
+
+	// Files are sorted by their paths, so are the packages
+	for _, packageName := range packageNames {
+		pkg := packages[packageName]
+		pkg.LineRate = formatRate(rate(packageCovered[packageName], packageTotal[packageName]))
+
+		report.Packages = append(report.Packages, *pkg)
+	}
+
+	report.LineRate = formatRate(rate(report.LinesCovered, report.LinesValid))
+
+	if _, err := io.WriteString(w, xml.Header+coberturaDoctype+"\n"); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', 4, 64)
+}
@@ -0,0 +1,122 @@
+package coverage
+
+import (
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"path/filepath"
+	"sort"
+)
+
+// Coverage is an instrument.Tracer that collects the line coverage of the Starlark files
+// residing on the host, which is aggregated across all the executions it was used in.
+type Coverage struct {
+	// Hit counts of the lines with statements keyed by the file's path
+	files map[string]map[int]int
+}
+
+// FileCoverage is the line coverage of a single file.
+type FileCoverage struct {
+	// Path uses the forward slashes regardless of the platform.
+	Path  string
+	Lines []LineCoverage
+}
+
+type LineCoverage struct {
+	Line int
+	Hits int
+}
+
+func New() *Coverage {
+	return &Coverage{
+		files: map[string]map[int]int{},
+	}
+}
+
+// FileInstrumented implements the instrument.Tracer interface.
+func (coverage *Coverage) FileInstrumented(file *instrument.File) {
+	// Remote modules are not a part of the project
+	if file.Path == "" {
+		return
+	}
+
+	lines, ok := coverage.files[file.Path]
+	if !ok {
+		lines = map[int]int{}
+		coverage.files[file.Path] = lines
+	}
+
+	for _, line := range file.StatementLines {
+		if _, ok := lines[line]; !ok {
+			lines[line] = 0
+		}
+	}
+}
+
+// BeforeStatement implements the instrument.Tracer interface.
+func (coverage *Coverage) BeforeStatement(_ *starlark.Thread, file *instrument.File, pos syntax.Position) error {
+	if lines, ok := coverage.files[file.Path]; ok {
+		lines[int(pos.Line)]++
+	}
+
+	return nil
+}
+
+// Files returns the coverage of the files sorted by their paths.
+func (coverage *Coverage) Files() []*FileCoverage {
+	var result []*FileCoverage
+
+	for path, lines := range coverage.files {
+		fileCoverage := &FileCoverage{Path: filepath.ToSlash(path)}
+
+		for line, hits := range lines {
+			fileCoverage.Lines = append(fileCoverage.Lines, LineCoverage{Line: line, Hits: hits})
+		}
+
+		sort.Slice(fileCoverage.Lines, func(i, j int) bool {
+			return fileCoverage.Lines[i].Line < fileCoverage.Lines[j].Line
+		})
+
+		result = append(result, fileCoverage)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result
+}
+
+// Summary returns the number of the lines that were executed and the total number of lines with statements.
+func (coverage *Coverage) Summary() (int, int) {
+	var covered, total int
+
+	for _, file := range coverage.Files() {
+		fileCovered, fileTotal := file.Summary()
+		covered += fileCovered
+		total += fileTotal
+	}
+
+	return covered, total
+}
+
+// Summary returns the number of the lines that were executed and the total number of lines with statements.
+func (fileCoverage *FileCoverage) Summary() (int, int) {
+	var covered int
+
+	for _, line := range fileCoverage.Lines {
+		if line.Hits != 0 {
+			covered++
+		}
+	}
+
+	return covered, len(fileCoverage.Lines)
+}
+
+func rate(covered int, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+
+	return float64(covered) / float64(total)
+}
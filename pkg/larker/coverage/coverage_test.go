@@ -0,0 +1,57 @@
+package coverage_test
+
+import (
+	"bytes"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/coverage"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/starlark"
+	"testing"
+)
+
+const source = `def sign(x):
+    if x < 0:
+        return -1
+    elif x == 0:
+        return 0
+
+    return 1
+
+RESULTS = [sign(x) for x in [1, 2, 0]]
+`
+
+func TestCoverage(t *testing.T) {
+	cov := coverage.New()
+
+	// Coverage should be aggregated across the executions
+	for i := 0; i < 2; i++ {
+		_, err := instrument.ExecFile(&starlark.Thread{}, "sign.star", "lib/sign.star", []byte(source),
+			[]instrument.Tracer{cov})
+		require.NoError(t, err)
+	}
+
+	// Files that don't reside on the host are ignored
+	_, err := instrument.ExecFile(&starlark.Thread{}, "remote.star", "", []byte("x = 1\n"),
+		[]instrument.Tracer{cov})
+	require.NoError(t, err)
+
+	covered, total := cov.Summary()
+	assert.Equal(t, 5, covered)
+	assert.Equal(t, 6, total)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, cov.WriteLCOV(buf))
+	assert.Equal(t, `TN:
+SF:lib/sign.star
+DA:2,6
+DA:3,0
+DA:4,6
+DA:5,2
+DA:7,4
+DA:9,2
+LF:6
+LH:5
+end_of_record
+`, buf.String())
+}
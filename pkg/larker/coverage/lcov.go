@@ -0,0 +1,25 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteLCOV writes the coverage in the LCOV tracefile format.
+func (coverage *Coverage) WriteLCOV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, file := range coverage.Files() {
+		_, _ = fmt.Fprintf(bw, "TN:\nSF:%s\n", file.Path)
+
+		for _, line := range file.Lines {
+			_, _ = fmt.Fprintf(bw, "DA:%d,%d\n", line.Line, line.Hits)
+		}
+
+		covered, total := file.Summary()
+		_, _ = fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", total, covered)
+	}
+
+	return bw.Flush()
+}
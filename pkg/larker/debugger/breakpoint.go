@@ -0,0 +1,53 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidBreakpoint = errors.New("invalid breakpoint")
+
+// Breakpoint pauses the execution before the statement on the specified line of the file.
+type Breakpoint struct {
+	// File is matched against the base name of the Starlark file, e.g. ".cirrus.star" or "lib.star".
+	File string
+	Line int
+}
+
+// ParseBreakpoint parses the breakpoint in the FILE:LINE form.
+func ParseBreakpoint(s string) (*Breakpoint, error) {
+	idx := strings.LastIndex(s, ":")
+	if idx <= 0 {
+		return nil, fmt.Errorf("%w: %q, expected FILE:LINE", ErrInvalidBreakpoint, s)
+	}
+
+	line, err := strconv.Atoi(s[idx+1:])
+	if err != nil || line < 1 {
+		return nil, fmt.Errorf("%w: %q, line should be a positive number", ErrInvalidBreakpoint, s)
+	}
+
+	return &Breakpoint{File: s[:idx], Line: line}, nil
+}
+
+func (breakpoint *Breakpoint) String() string {
+	return fmt.Sprintf("%s:%d", breakpoint.File, breakpoint.Line)
+}
+
+// matches returns true if the breakpoint refers to the line of the file. Breakpoints on the lines
+// that have no statements (e.g. comments or def's) are moved to the next line that has one.
+func (breakpoint *Breakpoint) matches(filename string, line int, statementLines []int) bool {
+	if filepath.Base(breakpoint.File) != filepath.Base(filename) {
+		return false
+	}
+
+	idx := sort.SearchInts(statementLines, breakpoint.Line)
+	if idx == len(statementLines) {
+		return false
+	}
+
+	return statementLines[idx] == line
+}
@@ -0,0 +1,376 @@
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"io"
+	"strings"
+)
+
+var ErrQuit = errors.New("debugging session was terminated")
+
+type mode int
+
+const (
+	// modeContinue only stops on the breakpoints.
+	modeContinue mode = iota
+	// modeStep stops on the next statement.
+	modeStep
+	// modeNext stops on the next statement of the current frame or the frames that called it.
+	modeNext
+	// modeOut stops on the next statement of the frames that called the current frame.
+	modeOut
+)
+
+const helpText = `Commands:
+  c, continue          resume the execution until the next breakpoint
+  s, step              execute the current statement, stepping into the called functions
+  n, next              execute the current statement, stepping over the called functions
+  o, out               resume the execution until the current function returns
+  l, locals            print the local variables of the selected frame
+  g, globals           print the global variables of the selected frame
+  p, print EXPR        evaluate the expression in the selected frame and print the result
+  bt, backtrace        print the call stack
+  up, down             select the calling or the called frame
+  b, break [FILE:LINE] set a breakpoint or list them when no argument is given
+  d, delete FILE:LINE  remove the breakpoint
+  q, quit              terminate the execution
+  h, help              print this help
+An empty line repeats the previous command.`
+
+// Debugger is an instrument.Tracer that pauses the execution of the Starlark code on the breakpoints and when stepping
+// through the statements, and lets the user inspect the call stack and the variables interactively.
+type Debugger struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	breakpoints []*Breakpoint
+
+	// The following is collected from the executed files
+	files      map[string]*instrument.File
+	localNames map[syntax.Position][]string
+
+	mode      mode
+	stepDepth int
+	detached  bool
+
+	// The following is only valid while paused
+	selectedFrame int
+	lastCommand   string
+}
+
+// New creates a debugger that reads the commands from in and writes the output to out.
+//
+// Without the breakpoints the debugger pauses before the first statement.
+func New(in io.Reader, out io.Writer, breakpoints ...*Breakpoint) *Debugger {
+	debugger := &Debugger{
+		in:          bufio.NewScanner(in),
+		out:         out,
+		breakpoints: breakpoints,
+		files:       map[string]*instrument.File{},
+		localNames:  map[syntax.Position][]string{},
+	}
+
+	if len(breakpoints) == 0 {
+		debugger.mode = modeStep
+	}
+
+	return debugger
+}
+
+// FileInstrumented implements the instrument.Tracer interface.
+func (debugger *Debugger) FileInstrumented(file *instrument.File) {
+	debugger.files[file.Syntax.Path] = file
+
+	for pos, names := range localNames(file.Syntax) {
+		debugger.localNames[pos] = names
+	}
+}
+
+// BeforeStatement implements the instrument.Tracer interface.
+func (debugger *Debugger) BeforeStatement(thread *starlark.Thread, _ *instrument.File, pos syntax.Position) error {
+	// Includes the hook's frame, but that doesn't matter when comparing the depths
+	depth := thread.CallStackDepth()
+
+	if !debugger.shouldStop(pos, depth) {
+		return nil
+	}
+
+	debugger.stepDepth = depth
+	debugger.selectedFrame = 1
+	debugger.printLocation(thread)
+
+	for {
+		_, _ = fmt.Fprint(debugger.out, "(debug) ")
+
+		if !debugger.in.Scan() {
+			// Nobody's going to resume the execution (e.g. the input was closed), so let it finish
+			_, _ = fmt.Fprintln(debugger.out)
+			debugger.detached = true
+
+			return nil
+		}
+
+		line := strings.TrimSpace(debugger.in.Text())
+		if line == "" {
+			line = debugger.lastCommand
+		}
+		debugger.lastCommand = line
+
+		resume, err := debugger.execute(thread, line)
+		if err != nil {
+			return err
+		}
+
+		if resume {
+			return nil
+		}
+	}
+}
+
+func (debugger *Debugger) shouldStop(pos syntax.Position, depth int) bool {
+	if debugger.detached {
+		return false
+	}
+
+	for _, breakpoint := range debugger.breakpoints {
+		file, ok := debugger.files[pos.Filename()]
+		if ok && breakpoint.matches(pos.Filename(), int(pos.Line), file.StatementLines) {
+			return true
+		}
+	}
+
+	switch debugger.mode {
+	case modeStep:
+		return true
+	case modeNext:
+		return depth <= debugger.stepDepth
+	case modeOut:
+		return depth < debugger.stepDepth
+	default:
+		return false
+	}
+}
+
+// execute runs the debugger command and returns true if the execution should be resumed.
+func (debugger *Debugger) execute(thread *starlark.Thread, line string) (bool, error) {
+	command, argument := line, ""
+	if idx := strings.IndexAny(line, " \t"); idx != -1 {
+		command, argument = line[:idx], strings.TrimSpace(line[idx+1:])
+	}
+
+	switch command {
+	case "":
+		return false, nil
+	case "c", "continue":
+		debugger.mode = modeContinue
+
+		return true, nil
+	case "s", "step":
+		debugger.mode = modeStep
+
+		return true, nil
+	case "n", "next":
+		debugger.mode = modeNext
+
+		return true, nil
+	case "o", "out":
+		debugger.mode = modeOut
+
+		return true, nil
+	case "l", "locals":
+		debugger.printVariables(debugger.frameLocals(thread.DebugFrame(debugger.selectedFrame)))
+	case "g", "globals":
+		debugger.printVariables(frameGlobals(thread.DebugFrame(debugger.selectedFrame)))
+	case "p", "print":
+		debugger.evaluate(thread, argument)
+	case "bt", "backtrace":
+		debugger.printBacktrace(thread)
+	case "up":
+		if debugger.selectedFrame+1 >= thread.CallStackDepth() {
+			debugger.printf("Already at the outermost frame\n")
+		} else {
+			debugger.selectedFrame++
+			debugger.printLocation(thread)
+		}
+	case "down":
+		if debugger.selectedFrame == 1 {
+			debugger.printf("Already at the innermost frame\n")
+		} else {
+			debugger.selectedFrame--
+			debugger.printLocation(thread)
+		}
+	case "b", "break":
+		debugger.addBreakpoint(argument)
+	case "d", "delete":
+		debugger.deleteBreakpoint(argument)
+	case "q", "quit":
+		return false, ErrQuit
+	case "h", "help":
+		debugger.printf("%s\n", helpText)
+	default:
+		debugger.printf("Unknown command %q, type \"help\" for the list of commands\n", command)
+	}
+
+	return false, nil
+}
+
+func (debugger *Debugger) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(debugger.out, format, args...)
+}
+
+func (debugger *Debugger) printLocation(thread *starlark.Thread) {
+	frame := thread.DebugFrame(debugger.selectedFrame)
+	pos := frame.Position()
+
+	debugger.printf("> %s in %s\n", pos, frameName(frame))
+
+	if file, ok := debugger.files[pos.Filename()]; ok && int(pos.Line) >= 1 && int(pos.Line) <= len(file.Lines) {
+		debugger.printf("%5d | %s\n", pos.Line, file.Lines[pos.Line-1])
+	}
+}
+
+func (debugger *Debugger) printBacktrace(thread *starlark.Thread) {
+	for depth := 1; depth < thread.CallStackDepth(); depth++ {
+		marker := " "
+		if depth == debugger.selectedFrame {
+			marker = "*"
+		}
+
+		frame := thread.DebugFrame(depth)
+		debugger.printf("%s %d: %s at %s\n", marker, depth, frameName(frame), frame.Position())
+	}
+}
+
+func frameName(frame starlark.DebugFrame) string {
+	name := frame.Callable().Name()
+
+	// E.g. <toplevel>
+	if strings.HasPrefix(name, "<") {
+		return name
+	}
+
+	return name + "()"
+}
+
+func (debugger *Debugger) printVariables(variables starlark.StringDict) {
+	if len(variables) == 0 {
+		debugger.printf("No variables\n")
+
+		return
+	}
+
+	for _, name := range variables.Keys() {
+		debugger.printf("%s = %s\n", name, variables[name])
+	}
+}
+
+func (debugger *Debugger) frameLocals(frame starlark.DebugFrame) starlark.StringDict {
+	result := starlark.StringDict{}
+
+	function, ok := frame.Callable().(*starlark.Function)
+	if !ok {
+		return result
+	}
+
+	for i, name := range debugger.localNames[function.Position()] {
+		value := frame.Local(i)
+
+		// Skip the variables that are not yet assigned and the ones captured
+		// by the nested functions, which values are not accessible
+		if value == nil || value.Type() == "cell" {
+			continue
+		}
+
+		result[name] = value
+	}
+
+	return result
+}
+
+func frameGlobals(frame starlark.DebugFrame) starlark.StringDict {
+	function, ok := frame.Callable().(*starlark.Function)
+	if !ok {
+		return starlark.StringDict{}
+	}
+
+	return function.Globals()
+}
+
+func (debugger *Debugger) evaluate(thread *starlark.Thread, expr string) {
+	if expr == "" {
+		debugger.printf("Expression is required\n")
+
+		return
+	}
+
+	frame := thread.DebugFrame(debugger.selectedFrame)
+
+	env := frameGlobals(frame)
+	for name, value := range debugger.frameLocals(frame) {
+		env[name] = value
+	}
+
+	value, err := starlark.Eval(thread, "<debug>", expr, env)
+	if err != nil {
+		debugger.printf("%v\n", err)
+
+		return
+	}
+
+	debugger.printf("%s\n", value)
+}
+
+func (debugger *Debugger) addBreakpoint(argument string) {
+	if argument == "" {
+		if len(debugger.breakpoints) == 0 {
+			debugger.printf("No breakpoints\n")
+		}
+
+		for _, breakpoint := range debugger.breakpoints {
+			debugger.printf("%s\n", breakpoint)
+		}
+
+		return
+	}
+
+	breakpoint, err := ParseBreakpoint(argument)
+	if err != nil {
+		debugger.printf("%v\n", err)
+
+		return
+	}
+
+	debugger.breakpoints = append(debugger.breakpoints, breakpoint)
+	debugger.printf("Breakpoint set at %s\n", breakpoint)
+}
+
+func (debugger *Debugger) deleteBreakpoint(argument string) {
+	breakpoint, err := ParseBreakpoint(argument)
+	if err != nil {
+		debugger.printf("%v\n", err)
+
+		return
+	}
+
+	var remaining []*Breakpoint
+
+	for _, existing := range debugger.breakpoints {
+		if existing.String() != breakpoint.String() {
+			remaining = append(remaining, existing)
+		}
+	}
+
+	if len(remaining) == len(debugger.breakpoints) {
+		debugger.printf("No breakpoint at %s\n", breakpoint)
+
+		return
+	}
+
+	debugger.breakpoints = remaining
+	debugger.printf("Breakpoint at %s deleted\n", breakpoint)
+}
@@ -0,0 +1,97 @@
+package debugger_test
+
+import (
+	"bytes"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/debugger"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/starlark"
+	"strings"
+	"testing"
+)
+
+const source = `def double(x):
+    """Doubles the x."""
+    result = x * 2
+    return result
+
+def main():
+    values = []
+
+    for i in range(2):
+        values.append(double(i))
+
+    return values
+
+RESULT = main()
+`
+
+func run(t *testing.T, commands string, breakpoints ...*debugger.Breakpoint) (starlark.StringDict, string, error) {
+	t.Helper()
+
+	output := &bytes.Buffer{}
+	dbg := debugger.New(strings.NewReader(commands), output, breakpoints...)
+
+	globals, err := instrument.ExecFile(&starlark.Thread{}, "test.star", "", []byte(source),
+		[]instrument.Tracer{dbg})
+
+	return globals, output.String(), err
+}
+
+func TestStepping(t *testing.T) {
+	globals, output, err := run(t, "step\nnext\nnext\nnext\nstep\nout\ncontinue\n")
+	require.NoError(t, err)
+
+	// Instrumentation should not change the results
+	assert.Equal(t, "[0, 2]", globals["RESULT"].String())
+
+	var locations []string
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "> ") {
+			locations = append(locations, line[strings.Index(line, "> ")+2:])
+		}
+	}
+
+	assert.Equal(t, []string{
+		"test.star:14:1 in <toplevel>",
+		"test.star:7:5 in main()",
+		"test.star:9:5 in main()",
+		"test.star:10:9 in main()",
+		"test.star:10:9 in main()",
+		"test.star:3:5 in double()",
+		"test.star:12:5 in main()",
+	}, locations)
+}
+
+func TestBreakpoint(t *testing.T) {
+	// The breakpoint on the def line should be moved to the first statement after the docstring
+	_, output, err := run(t, "locals\nbacktrace\nup\nprint values + [i]\ncontinue\nprint x\nquit\n",
+		&debugger.Breakpoint{File: "test.star", Line: 1})
+	require.ErrorIs(t, err, debugger.ErrQuit)
+
+	assert.Contains(t, output, "> test.star:3:5 in double()\n    3 |     result = x * 2\n")
+	assert.Contains(t, output, "x = 0\n")
+	assert.Contains(t, output, "* 1: double() at test.star:3:5\n  2: main() at test.star:10:29\n")
+	assert.Contains(t, output, "[0]\n")
+	assert.Contains(t, output, "1\n")
+}
+
+func TestClosedInput(t *testing.T) {
+	globals, _, err := run(t, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "[0, 2]", globals["RESULT"].String())
+}
+
+func TestParseBreakpoint(t *testing.T) {
+	breakpoint, err := debugger.ParseBreakpoint("lib/build.star:12")
+	require.NoError(t, err)
+	assert.Equal(t, &debugger.Breakpoint{File: "lib/build.star", Line: 12}, breakpoint)
+
+	for _, invalid := range []string{"build.star", ":12", "build.star:0", "build.star:x"} {
+		_, err := debugger.ParseBreakpoint(invalid)
+		assert.ErrorIs(t, err, debugger.ErrInvalidBreakpoint, invalid)
+	}
+}
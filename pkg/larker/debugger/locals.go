@@ -0,0 +1,47 @@
+package debugger
+
+import (
+	"go.starlark.net/resolve"
+	"go.starlark.net/syntax"
+)
+
+// localNames returns the names of the local variables of the file's functions (including the top-level one)
+// keyed by the functions' positions, in the order used by starlark.DebugFrame's Local().
+//
+// Should be called after the file is resolved.
+func localNames(f *syntax.File) map[syntax.Position][]string {
+	result := map[syntax.Position][]string{}
+
+	bindingNames := func(bindings []*resolve.Binding) []string {
+		var names []string
+
+		for _, binding := range bindings {
+			names = append(names, binding.First.Name)
+		}
+
+		return names
+	}
+
+	if module, ok := f.Module.(*resolve.Module); ok && len(f.Stmts) != 0 {
+		result[syntax.Start(f.Stmts[0])] = bindingNames(module.Locals)
+	}
+
+	syntax.Walk(f, func(node syntax.Node) bool {
+		var function interface{}
+
+		switch typedNode := node.(type) {
+		case *syntax.DefStmt:
+			function = typedNode.Function
+		case *syntax.LambdaExpr:
+			function = typedNode.Function
+		}
+
+		if function, ok := function.(*resolve.Function); ok {
+			result[function.Pos] = bindingNames(function.Locals)
+		}
+
+		return true
+	})
+
+	return result
+}
@@ -0,0 +1,323 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	lru "github.com/hashicorp/golang-lru"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var ErrAPI = errors.New("failed to communicate with the Bitbucket API")
+
+const (
+	DefaultAPIURL = "https://api.bitbucket.org/2.0"
+
+	directoryEntriesPerPage = 100
+
+	typeFile      = "commit_file"
+	typeDirectory = "commit_directory"
+)
+
+type Bitbucket struct {
+	apiURL    string
+	token     string
+	username  string
+	password  string
+	workspace string
+	repo      string
+	reference string
+
+	contentsCache  *lru.Cache
+	fileInfosCache *lru.Cache
+
+	apiCallCount uint64
+}
+
+type Option func(*Bitbucket)
+
+// WithAPIURL overrides the Bitbucket API URL.
+func WithAPIURL(apiURL string) Option {
+	return func(bb *Bitbucket) {
+		bb.apiURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
+// WithToken authenticates the API requests using the repository, project or workspace access token.
+func WithToken(token string) Option {
+	return func(bb *Bitbucket) {
+		bb.token = token
+	}
+}
+
+// WithBasicAuth authenticates the API requests using the username and the app password.
+func WithBasicAuth(username, password string) Option {
+	return func(bb *Bitbucket) {
+		bb.username = username
+		bb.password = password
+	}
+}
+
+// New creates a file system backed by the Bitbucket repository at the specified reference.
+func New(workspace, repo, reference string, opts ...Option) (*Bitbucket, error) {
+	contentsCache, err := lru.New(16)
+	if err != nil {
+		return nil, err
+	}
+	fileInfosCache, err := lru.New(1024)
+	if err != nil {
+		return nil, err
+	}
+
+	bb := &Bitbucket{
+		apiURL:    DefaultAPIURL,
+		workspace: workspace,
+		repo:      repo,
+		reference: reference,
+
+		contentsCache:  contentsCache,
+		fileInfosCache: fileInfosCache,
+	}
+
+	for _, opt := range opts {
+		opt(bb)
+	}
+
+	return bb, nil
+}
+
+func (bb *Bitbucket) APICallCount() uint64 {
+	return bb.apiCallCount
+}
+
+type entry struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type directoryPage struct {
+	Values []entry `json:"values"`
+	Next   string  `json:"next"`
+}
+
+func (bb *Bitbucket) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
+	path = cleanPath(path)
+
+	cachedFileInfo, ok := bb.fileInfosCache.Get(path)
+	if ok {
+		return cachedFileInfo.(*fs.FileInfo), nil
+	}
+
+	var meta entry
+
+	query := url.Values{"format": {"meta"}}
+	if err := bb.getJSON(ctx, bb.srcURL(path, query), &meta); err != nil {
+		return nil, err
+	}
+
+	fileInfo, ok := toFileInfo(meta)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported entry type %q", ErrAPI, meta.Type)
+	}
+
+	bb.fileInfosCache.Add(path, fileInfo)
+
+	return fileInfo, nil
+}
+
+func (bb *Bitbucket) Get(ctx context.Context, path string) ([]byte, error) {
+	path = cleanPath(path)
+
+	fileInfo, err := bb.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Simulate os.Read() behavior in case the supplied path points to a directory
+	if fileInfo.IsDir {
+		return nil, fs.ErrNormalizedIsADirectory
+	}
+
+	resp, err := bb.request(ctx, bb.srcURL(path, nil))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	fileBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	return fileBytes, nil
+}
+
+func (bb *Bitbucket) ReadDir(ctx context.Context, path string) ([]string, error) {
+	path = cleanPath(path)
+
+	cachedEntries, ok := bb.contentsCache.Get(path)
+	if ok {
+		return cachedEntries.([]string), nil
+	}
+
+	fileInfo, err := bb.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Simulate ioutil.ReadDir() behavior in case the supplied path points to a file
+	if !fileInfo.IsDir {
+		return nil, syscall.ENOTDIR
+	}
+
+	var entries []string
+
+	// The trailing slash makes the API list the directory even if it's named like a file
+	directoryPath := path
+	if directoryPath != "" {
+		directoryPath += "/"
+	}
+
+	query := url.Values{"pagelen": {strconv.Itoa(directoryEntriesPerPage)}}
+	nextURL := bb.srcURL(directoryPath, query)
+
+	for nextURL != "" {
+		var page directoryPage
+
+		if err := bb.getJSON(ctx, nextURL, &page); err != nil {
+			return nil, err
+		}
+
+		for _, value := range page.Values {
+			entries = append(entries, pathBase(value.Path))
+
+			if fileInfo, ok := toFileInfo(value); ok {
+				bb.fileInfosCache.ContainsOrAdd(value.Path, fileInfo)
+			}
+		}
+
+		nextURL = page.Next
+	}
+
+	bb.contentsCache.Add(path, entries)
+
+	return entries, nil
+}
+
+func (bb *Bitbucket) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// ResolveCommit returns the SHA of the commit that the file system's reference currently points to.
+func (bb *Bitbucket) ResolveCommit(ctx context.Context) (string, error) {
+	var commit struct {
+		Hash string `json:"hash"`
+	}
+
+	if err := bb.getJSON(ctx, bb.repositoryURL("commit", bb.reference), &commit); err != nil {
+		return "", err
+	}
+
+	return commit.Hash, nil
+}
+
+func (bb *Bitbucket) repositoryURL(elem ...string) string {
+	result := bb.apiURL + "/repositories/" + url.PathEscape(bb.workspace) + "/" + url.PathEscape(bb.repo)
+
+	for _, e := range elem {
+		result += "/" + url.PathEscape(e)
+	}
+
+	return result
+}
+
+func (bb *Bitbucket) srcURL(path string, query url.Values) string {
+	result := bb.repositoryURL("src", bb.reference) + "/" + (&url.URL{Path: path}).EscapedPath()
+
+	if len(query) != 0 {
+		result += "?" + query.Encode()
+	}
+
+	return result
+}
+
+func (bb *Bitbucket) getJSON(ctx context.Context, requestURL string, result interface{}) error {
+	resp, err := bb.request(ctx, requestURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	return nil
+}
+
+func (bb *Bitbucket) request(ctx context.Context, requestURL string) (*http.Response, error) {
+	bb.apiCallCount++
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	switch {
+	case bb.token != "":
+		req.Header.Set("Authorization", "Bearer "+bb.token)
+	case bb.username != "":
+		req.SetBasicAuth(bb.username, bb.password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = resp.Body.Close()
+
+		return nil, os.ErrNotExist
+	case resp.StatusCode != http.StatusOK:
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf("%w: %s returned %s: %s", ErrAPI, req.URL.Path, resp.Status,
+			strings.TrimSpace(string(body)))
+	}
+
+	return resp, nil
+}
+
+func toFileInfo(value entry) (*fs.FileInfo, bool) {
+	switch value.Type {
+	case typeFile:
+		return &fs.FileInfo{IsDir: false, Size: value.Size}, true
+	case typeDirectory:
+		return &fs.FileInfo{IsDir: true}, true
+	default:
+		// E.g. submodules
+		return nil, false
+	}
+}
+
+// cleanPath converts the path to the form used by the API, where the repository root is an empty string.
+func cleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func pathBase(name string) string {
+	return path.Base(strings.TrimSuffix(name, "/"))
+}
@@ -0,0 +1,202 @@
+package bitbucket_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/bitbucket"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/githubfixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	fixtureWorkspace = "cirruslabs"
+	fixtureRepo      = "cirrus-cli"
+	fixtureCommit    = "0123456789abcdef0123456789abcdef01234567"
+	fixtureUsername  = "some-user"
+	fixturePassword  = "some-app-password"
+
+	fixturePageLen = 5
+)
+
+// newFixtureServer serves the files of this repository's working tree
+// using the subset of the Bitbucket API that the file system relies on.
+func newFixtureServer(t *testing.T) *httptest.Server {
+	root, err := filepath.Abs(filepath.Join("..", "..", "..", ".."))
+	require.NoError(t, err)
+
+	repositoryPrefix := "/2.0/repositories/" + fixtureWorkspace + "/" + fixtureRepo + "/"
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != fixtureUsername || password != fixturePassword {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		endpoint := strings.TrimPrefix(r.URL.Path, repositoryPrefix)
+		if endpoint == r.URL.Path || strings.Contains(endpoint, "..") {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		switch {
+		case endpoint == "commit/"+githubfixture.Reference:
+			writeJSON(w, map[string]string{"hash": fixtureCommit})
+		case strings.HasPrefix(endpoint, "src/"+githubfixture.Reference+"/"):
+			path := strings.TrimPrefix(endpoint, "src/"+githubfixture.Reference+"/")
+			serveSrc(w, r, server.URL, root, path)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func serveSrc(w http.ResponseWriter, r *http.Request, serverURL string, root string, path string) {
+	hostPath := filepath.Join(root, filepath.FromSlash(path))
+
+	info, err := os.Stat(hostPath)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	if r.URL.Query().Get("format") == "meta" {
+		writeJSON(w, toEntry(strings.TrimSuffix(path, "/"), info))
+
+		return
+	}
+
+	if !info.IsDir() {
+		fileBytes, err := ioutil.ReadFile(hostPath)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		_, _ = w.Write(fileBytes)
+
+		return
+	}
+
+	infos, err := ioutil.ReadDir(hostPath)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	page := 1
+	if pageParameter := r.URL.Query().Get("page"); pageParameter != "" {
+		page, _ = strconv.Atoi(pageParameter)
+	}
+
+	result := map[string]interface{}{}
+	values := []map[string]interface{}{}
+
+	for i := (page - 1) * fixturePageLen; i < len(infos) && i < page*fixturePageLen; i++ {
+		values = append(values, toEntry(path+infos[i].Name(), infos[i]))
+	}
+	result["values"] = values
+
+	if page*fixturePageLen < len(infos) {
+		nextURL := *r.URL
+		query := nextURL.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		nextURL.RawQuery = query.Encode()
+		result["next"] = serverURL + nextURL.String()
+	}
+
+	writeJSON(w, result)
+}
+
+func toEntry(path string, info os.FileInfo) map[string]interface{} {
+	if info.IsDir() {
+		return map[string]interface{}{"type": "commit_directory", "path": path}
+	}
+
+	return map[string]interface{}{"type": "commit_file", "path": path, "size": info.Size()}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func newFixtureFS(t *testing.T, opts ...bitbucket.Option) *bitbucket.Bitbucket {
+	server := newFixtureServer(t)
+
+	opts = append([]bitbucket.Option{bitbucket.WithAPIURL(server.URL + "/2.0")}, opts...)
+
+	bbFS, err := bitbucket.New(fixtureWorkspace, fixtureRepo, githubfixture.Reference, opts...)
+	require.NoError(t, err)
+
+	return bbFS
+}
+
+func TestGitHubFixture(t *testing.T) {
+	githubfixture.Run(t, newFixtureFS(t, bitbucket.WithBasicAuth(fixtureUsername, fixturePassword)))
+}
+
+func TestReadDirPagination(t *testing.T) {
+	bbFS := newFixtureFS(t, bitbucket.WithBasicAuth(fixtureUsername, fixturePassword))
+
+	expectedEntries, err := ioutil.ReadDir(filepath.Join("..", "..", "..", "..", "internal", "commands"))
+	require.NoError(t, err)
+
+	entries, err := bbFS.ReadDir(context.Background(), "internal/commands")
+	require.NoError(t, err)
+	assert.Len(t, entries, len(expectedEntries))
+}
+
+func TestStatUsesFileInfosCache(t *testing.T) {
+	bbFS := newFixtureFS(t, bitbucket.WithBasicAuth(fixtureUsername, fixturePassword))
+
+	_, err := bbFS.ReadDir(context.Background(), ".")
+	require.NoError(t, err)
+	apiCallCount := bbFS.APICallCount()
+
+	fileInfo, err := bbFS.Stat(context.Background(), "go.mod")
+	require.NoError(t, err)
+	require.False(t, fileInfo.IsDir)
+	require.NotZero(t, fileInfo.Size)
+
+	fileInfo, err = bbFS.Stat(context.Background(), "pkg")
+	require.NoError(t, err)
+	require.True(t, fileInfo.IsDir)
+
+	require.Equal(t, apiCallCount, bbFS.APICallCount(),
+		"Stat() calls in the root directory should've triggered no additional API calls")
+}
+
+func TestResolveCommit(t *testing.T) {
+	bbFS := newFixtureFS(t, bitbucket.WithBasicAuth(fixtureUsername, fixturePassword))
+
+	commit, err := bbFS.ResolveCommit(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, fixtureCommit, commit)
+}
+
+func TestUnauthorized(t *testing.T) {
+	bbFS := newFixtureFS(t, bitbucket.WithToken("some-token"))
+
+	_, err := bbFS.Get(context.Background(), "go.mod")
+	require.ErrorIs(t, err, bitbucket.ErrAPI)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+}
@@ -2,6 +2,7 @@ package cachinglayer
 
 import (
 	"context"
+	"fmt"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
 	"github.com/hashicorp/golang-lru"
 )
@@ -103,3 +104,13 @@ func (cl *CachingLayer) ReadDir(ctx context.Context, path string) ([]string, err
 func (cl *CachingLayer) Join(elem ...string) string {
 	return cl.fs.Join(elem...)
 }
+
+// Pivot returns the host's path of the wrapped file system's path, if it's backed by the host's file system.
+func (cl *CachingLayer) Pivot(path string) (string, error) {
+	pivoter, ok := cl.fs.(fs.Pivoter)
+	if !ok {
+		return "", fmt.Errorf("%w: %T", fs.ErrNotOnHost, cl.fs)
+	}
+
+	return pivoter.Pivot(path)
+}
@@ -2,8 +2,11 @@ package fs
 
 import (
 	"context"
+	"errors"
 )
 
+var ErrNotOnHost = errors.New("file system is not backed by the host's file system")
+
 type FileSystem interface {
 	Stat(ctx context.Context, path string) (*FileInfo, error)
 	Get(ctx context.Context, path string) ([]byte, error)
@@ -13,4 +16,28 @@ type FileSystem interface {
 
 type FileInfo struct {
 	IsDir bool
+	// Size of the file in bytes, not applicable to the directories.
+	Size int64
+}
+
+// Pivoter is implemented by the file systems backed by the host's file system (e.g. the local one).
+type Pivoter interface {
+	// Pivot returns the host's path of the file system's path.
+	Pivot(path string) (string, error)
+}
+
+// HostPath returns the host's path of the file system's path
+// or an empty string if the file system is not backed by the host's file system.
+func HostPath(fileSystem FileSystem, path string) string {
+	pivoter, ok := fileSystem.(Pivoter)
+	if !ok {
+		return ""
+	}
+
+	hostPath, err := pivoter.Pivot(path)
+	if err != nil {
+		return ""
+	}
+
+	return hostPath
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/filesystem"
 	"io/ioutil"
 	"path"
@@ -23,9 +24,25 @@ var (
 
 type Git struct {
 	worktree *git.Worktree
+	commit   string
 }
 
-func New(ctx context.Context, url string, revision string) (*Git, error) {
+type options struct {
+	auth transport.AuthMethod
+}
+
+type Option func(*options)
+
+// WithAuth sets the credentials used to clone the private repositories.
+//
+// Without it, the SSH URLs (e.g. git@example.com:org/repo.git) are authenticated using the SSH agent.
+func WithAuth(auth transport.AuthMethod) Option {
+	return func(opts *options) {
+		opts.auth = auth
+	}
+}
+
+func New(ctx context.Context, url string, revision string, opts ...Option) (*Git, error) {
 	const (
 		cacheBytes = 1 * units.MiB
 
@@ -36,13 +53,20 @@ func New(ctx context.Context, url string, revision string) (*Git, error) {
 		filesystemFiles = 4096
 	)
 
+	var cloneOpts options
+
+	for _, opt := range opts {
+		opt(&cloneOpts)
+	}
+
 	boundedCache := cache.NewObjectLRU(cacheBytes)
 	boundedStorage := filesystem.NewStorage(bounded.NewFilesystem(storageBytes, storageFiles), boundedCache)
 	boundedFilesystem := bounded.NewFilesystem(filesystemBytes, filesystemFiles)
 
 	// Clone the repository
 	repo, err := git.CloneContext(ctx, boundedStorage, boundedFilesystem, &git.CloneOptions{
-		URL: url,
+		URL:  url,
+		Auth: cloneOpts.auth,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrRetrievalFailed, err)
@@ -57,6 +81,7 @@ func New(ctx context.Context, url string, revision string) (*Git, error) {
 	// Without this ResolveRevision() would only work for default branch (e.g. master)
 	if err := repo.Fetch(&git.FetchOptions{
 		RefSpecs: []config.RefSpec{"refs/*:refs/*"},
+		Auth:     cloneOpts.auth,
 	}); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrRetrievalFailed, err)
 	}
@@ -72,7 +97,12 @@ func New(ctx context.Context, url string, revision string) (*Git, error) {
 		return nil, fmt.Errorf("%w: %v", ErrRetrievalFailed, err)
 	}
 
-	return &Git{worktree: worktree}, nil
+	return &Git{worktree: worktree, commit: hash.String()}, nil
+}
+
+// Commit returns the SHA of the commit that the revision was resolved to.
+func (g Git) Commit() string {
+	return g.commit
 }
 
 func (g Git) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
@@ -81,7 +111,7 @@ func (g Git) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
 		return nil, err
 	}
 
-	return &fs.FileInfo{IsDir: stat.IsDir()}, nil
+	return &fs.FileInfo{IsDir: stat.IsDir(), Size: stat.Size()}, nil
 }
 
 func (g Git) Get(ctx context.Context, path string) ([]byte, error) {
@@ -65,7 +65,7 @@ func (gh *GitHub) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
 		return cachedFileInfo.(*fs.FileInfo), nil
 	}
 
-	_, directoryContent, err := gh.getContentsWrapper(ctx, path)
+	fileContent, directoryContent, err := gh.getContentsWrapper(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +74,7 @@ func (gh *GitHub) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
 		return &fs.FileInfo{IsDir: true}, nil
 	}
 
-	return &fs.FileInfo{IsDir: false}, nil
+	return &fs.FileInfo{IsDir: false, Size: int64(fileContent.GetSize())}, nil
 }
 
 func (gh *GitHub) Get(ctx context.Context, path string) ([]byte, error) {
@@ -119,6 +119,22 @@ func (gh *GitHub) Join(elem ...string) string {
 	return path.Join(elem...)
 }
 
+// ResolveCommit returns the SHA of the commit that the file system's reference currently points to.
+func (gh *GitHub) ResolveCommit(ctx context.Context) (string, error) {
+	gh.apiCallCount++
+
+	sha, resp, err := gh.client(ctx).Repositories.GetCommitSHA1(ctx, gh.owner, gh.repo, gh.reference, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", os.ErrNotExist
+		}
+
+		return "", fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	return sha, nil
+}
+
 func (gh *GitHub) client(ctx context.Context) *github.Client {
 	var client *http.Client
 
@@ -157,7 +173,7 @@ func (gh *GitHub) getContentsWrapper(
 	}
 
 	if fileContent != nil {
-		gh.fileInfosCache.ContainsOrAdd(path, &fs.FileInfo{IsDir: false})
+		gh.fileInfosCache.ContainsOrAdd(path, &fs.FileInfo{IsDir: false, Size: int64(fileContent.GetSize())})
 	}
 	for _, directoryEntry := range directoryContent {
 		if directoryEntry.Type == nil || directoryEntry.Path == nil {
@@ -169,6 +185,7 @@ func (gh *GitHub) getContentsWrapper(
 		switch *directoryEntry.Type {
 		case "file":
 			fileInfo.IsDir = false
+			fileInfo.Size = int64(directoryEntry.GetSize())
 		case "dir":
 			fileInfo.IsDir = true
 		default:
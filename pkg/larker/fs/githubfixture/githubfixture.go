@@ -84,4 +84,27 @@ func Run(t *testing.T, fs fspkg.FileSystem) {
 		require.Error(t, err)
 		assert.True(t, errors.Is(err, os.ErrNotExist))
 	})
+
+	t.Run("TestGlob", func(t *testing.T) {
+		matches, err := fspkg.Glob(ctx, fs, "pkg/larker/fs/*/local.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []string{"pkg/larker/fs/local/local.go"}, matches)
+	})
+
+	t.Run("TestStatFileSize", func(t *testing.T) {
+		stat, err := fs.Stat(ctx, "go.mod")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fileBytes, err := fs.Get(ctx, "go.mod")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.EqualValues(t, len(fileBytes), stat.Size)
+	})
 }
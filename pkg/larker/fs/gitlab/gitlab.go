@@ -0,0 +1,335 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	lru "github.com/hashicorp/golang-lru"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var ErrAPI = errors.New("failed to communicate with the GitLab API")
+
+const (
+	DefaultAPIURL = "https://gitlab.com/api/v4"
+
+	treeEntriesPerPage = 100
+)
+
+type GitLab struct {
+	apiURL    string
+	token     string
+	project   string
+	reference string
+
+	contentsCache  *lru.Cache
+	fileInfosCache *lru.Cache
+
+	apiCallCount uint64
+}
+
+type Option func(*GitLab)
+
+// WithAPIURL overrides the GitLab API URL, e.g. to use a self-hosted GitLab instance.
+func WithAPIURL(apiURL string) Option {
+	return func(gl *GitLab) {
+		gl.apiURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
+// WithToken authenticates the API requests using the personal, project or OAuth access token.
+func WithToken(token string) Option {
+	return func(gl *GitLab) {
+		gl.token = token
+	}
+}
+
+// New creates a file system backed by the GitLab repository of the project
+// (e.g. "group/subgroup/name") at the specified reference.
+func New(project, reference string, opts ...Option) (*GitLab, error) {
+	contentsCache, err := lru.New(16)
+	if err != nil {
+		return nil, err
+	}
+	fileInfosCache, err := lru.New(1024)
+	if err != nil {
+		return nil, err
+	}
+
+	gl := &GitLab{
+		apiURL:    DefaultAPIURL,
+		project:   project,
+		reference: reference,
+
+		contentsCache:  contentsCache,
+		fileInfosCache: fileInfosCache,
+	}
+
+	for _, opt := range opts {
+		opt(gl)
+	}
+
+	return gl, nil
+}
+
+func (gl *GitLab) APICallCount() uint64 {
+	return gl.apiCallCount
+}
+
+func (gl *GitLab) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
+	path = cleanPath(path)
+
+	cachedFileInfo, ok := gl.fileInfosCache.Get(path)
+	if ok {
+		return cachedFileInfo.(*fs.FileInfo), nil
+	}
+
+	// Repository root always exists
+	if path == "" {
+		return &fs.FileInfo{IsDir: true}, nil
+	}
+
+	size, err := gl.fileSize(ctx, path)
+	if err == nil {
+		fileInfo := &fs.FileInfo{IsDir: false, Size: size}
+		gl.fileInfosCache.Add(path, fileInfo)
+
+		return fileInfo, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// Git has no empty directories, so the path is a directory only if it has some entries
+	entries, err := gl.tree(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, os.ErrNotExist
+	}
+
+	fileInfo := &fs.FileInfo{IsDir: true}
+	gl.fileInfosCache.Add(path, fileInfo)
+
+	return fileInfo, nil
+}
+
+func (gl *GitLab) Get(ctx context.Context, path string) ([]byte, error) {
+	path = cleanPath(path)
+
+	fileInfo, err := gl.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Simulate os.Read() behavior in case the supplied path points to a directory
+	if fileInfo.IsDir {
+		return nil, fs.ErrNormalizedIsADirectory
+	}
+
+	var file struct {
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+
+	query := url.Values{"ref": {gl.reference}}
+	if err := gl.getJSON(ctx, "repository/files/"+url.PathEscape(path), query, &file); err != nil {
+		return nil, err
+	}
+
+	if file.Encoding != "base64" {
+		return nil, fmt.Errorf("%w: unsupported file encoding %q", ErrAPI, file.Encoding)
+	}
+
+	fileBytes, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	return fileBytes, nil
+}
+
+func (gl *GitLab) ReadDir(ctx context.Context, path string) ([]string, error) {
+	path = cleanPath(path)
+
+	fileInfo, err := gl.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Simulate ioutil.ReadDir() behavior in case the supplied path points to a file
+	if !fileInfo.IsDir {
+		return nil, syscall.ENOTDIR
+	}
+
+	entries, err := gl.tree(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, entry := range entries {
+		result = append(result, entry.Name)
+	}
+
+	return result, nil
+}
+
+func (gl *GitLab) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// ResolveCommit returns the SHA of the commit that the file system's reference currently points to.
+func (gl *GitLab) ResolveCommit(ctx context.Context) (string, error) {
+	var commit struct {
+		ID string `json:"id"`
+	}
+
+	if err := gl.getJSON(ctx, "repository/commits/"+url.PathEscape(gl.reference), nil, &commit); err != nil {
+		return "", err
+	}
+
+	return commit.ID, nil
+}
+
+type treeEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+func (gl *GitLab) tree(ctx context.Context, path string) ([]treeEntry, error) {
+	cachedEntries, ok := gl.contentsCache.Get(path)
+	if ok {
+		return cachedEntries.([]treeEntry), nil
+	}
+
+	var entries []treeEntry
+
+	for page := "1"; page != ""; {
+		var pageEntries []treeEntry
+
+		query := url.Values{
+			"ref":      {gl.reference},
+			"path":     {path},
+			"per_page": {strconv.Itoa(treeEntriesPerPage)},
+			"page":     {page},
+		}
+
+		resp, err := gl.request(ctx, http.MethodGet, "repository/tree", query)
+		if err != nil {
+			// Non-existent directories are reported as "404 Tree Not Found"
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&pageEntries)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAPI, err)
+		}
+
+		entries = append(entries, pageEntries...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	// Files are not cached since their size is not known from the tree listing
+	for _, entry := range entries {
+		if entry.Type == "tree" {
+			gl.fileInfosCache.ContainsOrAdd(entry.Path, &fs.FileInfo{IsDir: true})
+		}
+	}
+
+	gl.contentsCache.Add(path, entries)
+
+	return entries, nil
+}
+
+// fileSize uses the HEAD request, which avoids retrieving the file's contents.
+func (gl *GitLab) fileSize(ctx context.Context, path string) (int64, error) {
+	query := url.Values{"ref": {gl.reference}}
+
+	resp, err := gl.request(ctx, http.MethodHead, "repository/files/"+url.PathEscape(path), query)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+
+	size, err := strconv.ParseInt(resp.Header.Get("X-Gitlab-Size"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to parse the file size: %v", ErrAPI, err)
+	}
+
+	return size, nil
+}
+
+func (gl *GitLab) getJSON(ctx context.Context, endpoint string, query url.Values, result interface{}) error {
+	resp, err := gl.request(ctx, http.MethodGet, endpoint, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	return nil
+}
+
+func (gl *GitLab) request(ctx context.Context, method string, endpoint string, query url.Values) (*http.Response, error) {
+	gl.apiCallCount++
+
+	requestURL := gl.apiURL + "/projects/" + url.PathEscape(gl.project) + "/" + endpoint
+	if len(query) != 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	if gl.token != "" {
+		req.Header.Set("Authorization", "Bearer "+gl.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPI, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = resp.Body.Close()
+
+		return nil, os.ErrNotExist
+	case resp.StatusCode != http.StatusOK:
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf("%w: %s %s returned %s: %s", ErrAPI, method, endpoint, resp.Status,
+			strings.TrimSpace(string(body)))
+	}
+
+	return resp, nil
+}
+
+// cleanPath converts the path to the form used by the API, where the repository root is an empty string.
+func cleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
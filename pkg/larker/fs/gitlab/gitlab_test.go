@@ -0,0 +1,230 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/githubfixture"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/gitlab"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	fixtureProject = "cirruslabs/cirrus-cli"
+	fixtureCommit  = "0123456789abcdef0123456789abcdef01234567"
+	fixtureToken   = "some-token"
+
+	fixtureMaxPerPage = 5
+)
+
+// newFixtureServer serves the files of this repository's working tree
+// using the subset of the GitLab API that the file system relies on.
+func newFixtureServer(t *testing.T) *httptest.Server {
+	root, err := filepath.Abs(filepath.Join("..", "..", "..", ".."))
+	require.NoError(t, err)
+
+	projectPrefix := "/api/v4/projects/" + url.PathEscape(fixtureProject) + "/repository/"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+fixtureToken {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		endpoint := strings.TrimPrefix(r.URL.EscapedPath(), projectPrefix)
+		if endpoint == r.URL.EscapedPath() {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(endpoint, "files/"):
+			serveFile(w, r, root, strings.TrimPrefix(endpoint, "files/"))
+		case endpoint == "tree":
+			serveTree(w, r, root)
+		case strings.HasPrefix(endpoint, "commits/"):
+			if ref, _ := url.PathUnescape(strings.TrimPrefix(endpoint, "commits/")); ref != githubfixture.Reference {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			writeJSON(w, map[string]string{"id": fixtureCommit})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func serveFile(w http.ResponseWriter, r *http.Request, root string, escapedPath string) {
+	path, err := url.PathUnescape(escapedPath)
+	if err != nil || strings.Contains(path, "..") {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	fileBytes, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("X-Gitlab-Size", strconv.Itoa(len(fileBytes)))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"file_path": path,
+		"size":      len(fileBytes),
+		"encoding":  "base64",
+		"content":   base64.StdEncoding.EncodeToString(fileBytes),
+	})
+}
+
+func serveTree(w http.ResponseWriter, r *http.Request, root string) {
+	path := r.URL.Query().Get("path")
+	if strings.Contains(path, "..") {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	dirEntries, err := ioutil.ReadDir(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		// Like GitLab, return an empty tree for the files
+		writeJSON(w, []interface{}{})
+
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if page < 1 || perPage < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	// Use smaller pages than requested to exercise the pagination
+	if perPage > fixtureMaxPerPage {
+		perPage = fixtureMaxPerPage
+	}
+
+	entries := []map[string]string{}
+
+	for i := (page - 1) * perPage; i < len(dirEntries) && i < page*perPage; i++ {
+		entryType := "blob"
+		if dirEntries[i].IsDir() {
+			entryType = "tree"
+		}
+
+		entries = append(entries, map[string]string{
+			"name": dirEntries[i].Name(),
+			"type": entryType,
+			"path": strings.TrimPrefix(path+"/"+dirEntries[i].Name(), "/"),
+		})
+	}
+
+	if page*perPage < len(dirEntries) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+
+	writeJSON(w, entries)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func TestGitHubFixture(t *testing.T) {
+	server := newFixtureServer(t)
+
+	glFS, err := gitlab.New(fixtureProject, githubfixture.Reference,
+		gitlab.WithAPIURL(server.URL+"/api/v4"), gitlab.WithToken(fixtureToken))
+	require.NoError(t, err)
+
+	githubfixture.Run(t, glFS)
+}
+
+func TestReadDirPagination(t *testing.T) {
+	server := newFixtureServer(t)
+
+	glFS, err := gitlab.New(fixtureProject, githubfixture.Reference,
+		gitlab.WithAPIURL(server.URL+"/api/v4"), gitlab.WithToken(fixtureToken))
+	require.NoError(t, err)
+
+	expectedEntries, err := ioutil.ReadDir(filepath.Join("..", "..", "..", "..", "internal", "commands"))
+	require.NoError(t, err)
+
+	entries, err := glFS.ReadDir(context.Background(), "internal/commands")
+	require.NoError(t, err)
+	assert.Len(t, entries, len(expectedEntries))
+}
+
+func TestStatUsesFileInfosCache(t *testing.T) {
+	server := newFixtureServer(t)
+
+	glFS, err := gitlab.New(fixtureProject, githubfixture.Reference,
+		gitlab.WithAPIURL(server.URL+"/api/v4"), gitlab.WithToken(fixtureToken))
+	require.NoError(t, err)
+
+	_, err = glFS.ReadDir(context.Background(), ".")
+	require.NoError(t, err)
+	apiCallCount := glFS.APICallCount()
+
+	fileInfo, err := glFS.Stat(context.Background(), "pkg")
+	require.NoError(t, err)
+	require.True(t, fileInfo.IsDir)
+	require.Equal(t, apiCallCount, glFS.APICallCount(),
+		"Stat() calls for the directories in the root directory should've triggered no additional API calls")
+}
+
+func TestResolveCommit(t *testing.T) {
+	server := newFixtureServer(t)
+
+	glFS, err := gitlab.New(fixtureProject, githubfixture.Reference,
+		gitlab.WithAPIURL(server.URL+"/api/v4"), gitlab.WithToken(fixtureToken))
+	require.NoError(t, err)
+
+	commit, err := glFS.ResolveCommit(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, fixtureCommit, commit)
+}
+
+func TestUnauthorized(t *testing.T) {
+	server := newFixtureServer(t)
+
+	glFS, err := gitlab.New(fixtureProject, githubfixture.Reference, gitlab.WithAPIURL(server.URL+"/api/v4"))
+	require.NoError(t, err)
+
+	_, err = glFS.Get(context.Background(), "go.mod")
+	require.ErrorIs(t, err, gitlab.ErrAPI)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+}
@@ -0,0 +1,157 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// doubleStar matches zero or more directories in the Glob patterns.
+const doubleStar = "**"
+
+// Glob returns the paths of the files and directories matching the pattern, sorted lexically.
+//
+// The pattern consists of the slash-separated segments using the path.Match syntax, additionally
+// supporting the "**" segment that matches zero or more directories. The "**" segment
+// doesn't descend into the hidden directories (e.g. .git).
+func Glob(ctx context.Context, fileSystem FileSystem, pattern string) ([]string, error) {
+	// Validate the pattern syntax upfront, since path.Match only reports it when it gets to the bad part
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	matches := map[string]struct{}{}
+
+	segments := strings.Split(strings.TrimPrefix(path.Clean(pattern), "/"), "/")
+	if err := glob(ctx, fileSystem, "", segments, matches); err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for match := range matches {
+		result = append(result, match)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+func glob(ctx context.Context, fileSystem FileSystem, dir string, segments []string, matches map[string]struct{}) error {
+	if len(segments) == 0 {
+		if dir != "" {
+			matches[dir] = struct{}{}
+		}
+
+		return nil
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	// Avoid listing the directory when there's nothing to match
+	if segment != doubleStar && !hasMeta(segment) {
+		child := joinPath(dir, segment)
+
+		fileInfo, err := fileSystem.Stat(ctx, child)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if len(rest) != 0 && !fileInfo.IsDir {
+			return nil
+		}
+
+		return glob(ctx, fileSystem, child, rest, matches)
+	}
+
+	entries, err := fileSystem.ReadDir(ctx, listPath(dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	if segment == doubleStar {
+		// Match zero directories
+		if err := glob(ctx, fileSystem, dir, rest, matches); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		child := joinPath(dir, entry)
+
+		if segment == doubleStar {
+			fileInfo, err := fileSystem.Stat(ctx, child)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case fileInfo.IsDir && strings.HasPrefix(entry, "."):
+				continue
+			case fileInfo.IsDir:
+				// Match one more directory
+				if err := glob(ctx, fileSystem, child, segments, matches); err != nil {
+					return err
+				}
+			case len(rest) == 0:
+				// The trailing "**" matches the files too
+				matches[child] = struct{}{}
+			}
+
+			continue
+		}
+
+		if matched, _ := path.Match(segment, entry); !matched {
+			continue
+		}
+
+		if len(rest) != 0 {
+			fileInfo, err := fileSystem.Stat(ctx, child)
+			if err != nil {
+				return err
+			}
+
+			if !fileInfo.IsDir {
+				continue
+			}
+		}
+
+		if err := glob(ctx, fileSystem, child, rest, matches); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
+
+func joinPath(dir string, name string) string {
+	if dir == "" {
+		return name
+	}
+
+	return path.Join(dir, name)
+}
+
+func listPath(dir string) string {
+	if dir == "" {
+		return "."
+	}
+
+	return dir
+}
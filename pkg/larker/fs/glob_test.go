@@ -0,0 +1,53 @@
+package fs_test
+
+import (
+	"context"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	memoryFS, err := memory.New(map[string][]byte{
+		"go.mod":                   []byte("module example.com/glob\n"),
+		"main.go":                  []byte("package main\n"),
+		"cmd/tool/main.go":         []byte("package main\n"),
+		"cmd/tool/README.md":       []byte("# Tool\n"),
+		"pkg/lib/lib.go":           []byte("package lib\n"),
+		"pkg/lib/testdata/x.go":    []byte("package testdata\n"),
+		".git/hooks/pre-commit.go": []byte("package hooks\n"),
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"*.go", []string{"main.go"}},
+		{"cmd/*/main.go", []string{"cmd/tool/main.go"}},
+		{"**/*.go", []string{"cmd/tool/main.go", "main.go", "pkg/lib/lib.go", "pkg/lib/testdata/x.go"}},
+		{"pkg/**", []string{"pkg", "pkg/lib", "pkg/lib/lib.go", "pkg/lib/testdata", "pkg/lib/testdata/x.go"}},
+		{"**/testdata", []string{"pkg/lib/testdata"}},
+		{"**/*.[m]d", []string{"cmd/tool/README.md"}},
+		{"go.mod", []string{"go.mod"}},
+		{"go.mod/*", nil},
+		{"missing/**/*.go", nil},
+	}
+
+	for _, testCase := range testCases {
+		matches, err := fs.Glob(context.Background(), memoryFS, testCase.pattern)
+		require.NoError(t, err, testCase.pattern)
+		assert.Equal(t, testCase.expected, matches, testCase.pattern)
+	}
+}
+
+func TestGlobInvalidPattern(t *testing.T) {
+	memoryFS, err := memory.New(map[string][]byte{})
+	require.NoError(t, err)
+
+	_, err = fs.Glob(context.Background(), memoryFS, "[")
+	require.ErrorIs(t, err, path.ErrBadPattern)
+}
@@ -36,7 +36,7 @@ func (lfs *Local) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
 		return nil, err
 	}
 
-	return &fs.FileInfo{IsDir: fileInfo.IsDir()}, nil
+	return &fs.FileInfo{IsDir: fileInfo.IsDir(), Size: fileInfo.Size()}, nil
 }
 
 func (lfs *Local) Get(ctx context.Context, path string) ([]byte, error) {
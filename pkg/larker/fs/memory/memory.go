@@ -37,7 +37,7 @@ func (memory *Memory) Stat(ctx context.Context, path string) (*fs.FileInfo, erro
 		return nil, err
 	}
 
-	return &fs.FileInfo{IsDir: fileInfo.IsDir()}, nil
+	return &fs.FileInfo{IsDir: fileInfo.IsDir(), Size: fileInfo.Size()}, nil
 }
 
 func (memory *Memory) Get(ctx context.Context, path string) ([]byte, error) {
@@ -0,0 +1,167 @@
+// Package instrument executes the Starlark files with the hook calls inserted before their statements,
+// which allows the tracers (e.g. the debugger or the coverage collector) to observe the execution.
+package instrument
+
+import (
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"sort"
+	"strings"
+)
+
+// hookName is the predeclared builtin that is called before each statement of the instrumented file.
+const hookName = "__cirrus_hook__"
+
+// Tracer observes the execution of the instrumented files.
+type Tracer interface {
+	// FileInstrumented is called once the file is instrumented and resolved, but before it's executed.
+	FileInstrumented(file *File)
+
+	// BeforeStatement is called before executing each statement of the instrumented file.
+	BeforeStatement(thread *starlark.Thread, file *File, pos syntax.Position) error
+}
+
+// File is a Starlark file instrumented with the hook calls.
+type File struct {
+	// Syntax is the resolved syntax tree of the file, including the hook calls.
+	Syntax *syntax.File
+
+	// Path is the file's path on the host or an empty string if the file
+	// doesn't reside on the host (e.g. a remote module).
+	Path string
+
+	// Lines of the file's source code.
+	Lines []string
+
+	// StatementLines are the sorted lines that have statements on them.
+	StatementLines []int
+}
+
+// ExecFile is similar to starlark.ExecFile, but lets the tracers observe the execution of the file's statements.
+func ExecFile(
+	thread *starlark.Thread,
+	filename string,
+	path string,
+	src []byte,
+	tracers []Tracer,
+) (starlark.StringDict, error) {
+	f, err := syntax.Parse(filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{
+		Syntax:         f,
+		Path:           path,
+		Lines:          strings.Split(string(src), "\n"),
+		StatementLines: instrument(f),
+	}
+
+	hook := starlark.NewBuiltin(hookName, func(
+		thread *starlark.Thread,
+		_ *starlark.Builtin,
+		_ starlark.Tuple,
+		_ []starlark.Tuple,
+	) (starlark.Value, error) {
+		// The frame at depth 0 is the hook itself
+		pos := thread.DebugFrame(1).Position()
+
+		for _, tracer := range tracers {
+			if err := tracer.BeforeStatement(thread, file, pos); err != nil {
+				return nil, err
+			}
+		}
+
+		return starlark.None, nil
+	})
+	predeclared := starlark.StringDict{hookName: hook}
+
+	program, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tracer := range tracers {
+		tracer.FileInstrumented(file)
+	}
+
+	globals, err := program.Init(thread, predeclared)
+	globals.Freeze()
+
+	return globals, err
+}
+
+// instrument inserts the hook calls before the file's statements and returns
+// the sorted lines of these statements.
+func instrument(f *syntax.File) []int {
+	lines := map[int]struct{}{}
+
+	f.Stmts = instrumentStatements(f.Stmts, lines)
+
+	var result []int
+
+	for line := range lines {
+		result = append(result, line)
+	}
+
+	sort.Ints(result)
+
+	return result
+}
+
+func instrumentStatements(stmts []syntax.Stmt, lines map[int]struct{}) []syntax.Stmt {
+	var result []syntax.Stmt
+
+	for _, stmt := range stmts {
+		switch typedStmt := stmt.(type) {
+		case *syntax.LoadStmt:
+			// There's nothing to inspect before the load
+			result = append(result, stmt)
+
+			continue
+		case *syntax.DefStmt:
+			// The definition itself is not worth stopping at, unlike its body
+			typedStmt.Body = instrumentFunctionBody(typedStmt.Body, lines)
+			result = append(result, stmt)
+
+			continue
+		case *syntax.IfStmt:
+			typedStmt.True = instrumentStatements(typedStmt.True, lines)
+			typedStmt.False = instrumentStatements(typedStmt.False, lines)
+		case *syntax.ForStmt:
+			typedStmt.Body = instrumentStatements(typedStmt.Body, lines)
+		case *syntax.WhileStmt:
+			typedStmt.Body = instrumentStatements(typedStmt.Body, lines)
+		}
+
+		pos := syntax.Start(stmt)
+		lines[int(pos.Line)] = struct{}{}
+
+		result = append(result, hookCall(pos), stmt)
+	}
+
+	return result
+}
+
+func instrumentFunctionBody(body []syntax.Stmt, lines map[int]struct{}) []syntax.Stmt {
+	// Keep the docstring in place
+	if len(body) != 0 {
+		if exprStmt, ok := body[0].(*syntax.ExprStmt); ok {
+			if literal, ok := exprStmt.X.(*syntax.Literal); ok && literal.Token == syntax.STRING {
+				return append([]syntax.Stmt{body[0]}, instrumentStatements(body[1:], lines)...)
+			}
+		}
+	}
+
+	return instrumentStatements(body, lines)
+}
+
+func hookCall(pos syntax.Position) syntax.Stmt {
+	return &syntax.ExprStmt{
+		X: &syntax.CallExpr{
+			Fn:     &syntax.Ident{NamePos: pos, Name: hookName},
+			Lparen: pos,
+			Rparen: pos,
+		},
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/builtin"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/cachinglayer"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/dummy"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
 	"github.com/cirruslabs/cirrus-cli/pkg/yamlhelper"
 	"go.starlark.net/resolve"
@@ -30,6 +32,13 @@ type Larker struct {
 	env           map[string]string
 	affectedFiles []string
 	isTest        bool
+	loaderOpts    []loader.Option
+	mocks         *builtin.Mocks
+	tracers       []instrument.Tracer
+
+	// Zero means no limit
+	maxExecutionSteps uint64
+	maxOutputBytes    int
 }
 
 type HookResult struct {
@@ -81,23 +90,25 @@ func (larker *Larker) MainOptional(ctx context.Context, source string) (*MainRes
 }
 
 func (larker *Larker) Main(ctx context.Context, source string) (*MainResult, error) {
-	outputLogsBuffer := &bytes.Buffer{}
-	capture := func(thread *starlark.Thread, msg string) {
-		_, _ = fmt.Fprintln(outputLogsBuffer, msg)
-	}
+	outputLogs := larker.newOutput()
 
-	thread := &starlark.Thread{
-		Load:  loader.NewLoader(ctx, larker.fs, larker.env, larker.affectedFiles, larker.isTest).LoadFunc(larker.fs),
-		Print: capture,
-	}
+	moduleLoader := loader.NewLoader(ctx, larker.fs, larker.env, larker.affectedFiles, larker.isTest,
+		larker.effectiveLoaderOpts()...)
+
+	thread := larker.newThread(moduleLoader, outputLogs)
 
 	resCh := make(chan starlark.Value)
 	errCh := make(chan error)
 
 	go func() {
 		// Execute the source code for the main() to be visible
-		globals, err := starlark.ExecFile(thread, ".cirrus.star", source, nil)
+		globals, err := larker.execFile(thread, ".cirrus.star", source)
 		if err != nil {
+			if limitErr := larker.exceededLimit(thread, moduleLoader, outputLogs, err); limitErr != nil {
+				errCh <- limitErr
+				return
+			}
+
 			errCh <- fmt.Errorf("%w: %v", ErrLoadFailed, err)
 			return
 		}
@@ -125,6 +136,11 @@ func (larker *Larker) Main(ctx context.Context, source string) (*MainResult, err
 
 		mainResult, err := starlark.Call(thread, main, args, nil)
 		if err != nil {
+			if limitErr := larker.exceededLimit(thread, moduleLoader, outputLogs, err); limitErr != nil {
+				errCh <- limitErr
+				return
+			}
+
 			errCh <- &ErrExecFailed{err: err}
 			return
 		}
@@ -137,7 +153,7 @@ func (larker *Larker) Main(ctx context.Context, source string) (*MainResult, err
 	select {
 	case mainResult = <-resCh:
 	case err := <-errCh:
-		return nil, &ExtendedError{err: err, logs: logsWithErrorAttached(outputLogsBuffer.Bytes(), err)}
+		return nil, &ExtendedError{err: err, logs: logsWithErrorAttached(outputLogs.Bytes(), err)}
 	case <-ctx.Done():
 		thread.Cancel(ctx.Err().Error())
 		return nil, ctx.Err()
@@ -154,12 +170,12 @@ func (larker *Larker) Main(ctx context.Context, source string) (*MainResult, err
 			return nil, err
 		}
 		if tasksNode == nil {
-			return &MainResult{OutputLogs: outputLogsBuffer.Bytes()}, nil
+			return &MainResult{OutputLogs: outputLogs.Bytes()}, nil
 		}
 	case *starlark.Dict:
 		tasksNode = convertDict(typedMainResult)
 		if tasksNode == nil {
-			return &MainResult{OutputLogs: outputLogsBuffer.Bytes()}, nil
+			return &MainResult{OutputLogs: outputLogs.Bytes()}, nil
 		}
 	default:
 		return nil, fmt.Errorf("%w: result is not a list or a dict", ErrMainUnexpectedResult)
@@ -171,7 +187,7 @@ func (larker *Larker) Main(ctx context.Context, source string) (*MainResult, err
 	}
 
 	return &MainResult{
-		OutputLogs: outputLogsBuffer.Bytes(),
+		OutputLogs: outputLogs.Bytes(),
 		YAMLConfig: formattedYaml,
 	}, nil
 }
@@ -186,23 +202,25 @@ func (larker *Larker) Hook(
 		return nil, fmt.Errorf("%w: empty hook name specified", ErrSanity)
 	}
 
-	outputLogsBuffer := &bytes.Buffer{}
-	capture := func(thread *starlark.Thread, msg string) {
-		_, _ = fmt.Fprintln(outputLogsBuffer, msg)
-	}
+	outputLogs := larker.newOutput()
 
-	thread := &starlark.Thread{
-		Load:  loader.NewLoader(ctx, larker.fs, larker.env, []string{}, larker.isTest).LoadFunc(larker.fs),
-		Print: capture,
-	}
+	moduleLoader := loader.NewLoader(ctx, larker.fs, larker.env, []string{}, larker.isTest,
+		larker.effectiveLoaderOpts()...)
+
+	thread := larker.newThread(moduleLoader, outputLogs)
 
 	resCh := make(chan *HookResult)
 	errCh := make(chan error)
 
 	go func() {
 		// Execute the source code for the hook to be visible
-		globals, err := starlark.ExecFile(thread, ".cirrus.star", source, nil)
+		globals, err := larker.execFile(thread, ".cirrus.star", source)
 		if err != nil {
+			if limitErr := larker.exceededLimit(thread, moduleLoader, outputLogs, err); limitErr != nil {
+				errCh <- limitErr
+				return
+			}
+
 			errCh <- fmt.Errorf("%w: %v", ErrLoadFailed, err)
 			return
 		}
@@ -247,6 +265,11 @@ func (larker *Larker) Hook(
 
 		hookResult, err := starlark.Call(thread, hook, args, nil)
 		if err != nil {
+			if limitErr := larker.exceededLimit(thread, moduleLoader, outputLogs, err); limitErr != nil {
+				errCh <- limitErr
+				return
+			}
+
 			errCh <- &ErrExecFailed{err: err}
 			return
 		}
@@ -262,7 +285,7 @@ func (larker *Larker) Hook(
 
 		// All good
 		resCh <- &HookResult{
-			OutputLogs:    outputLogsBuffer.Bytes(),
+			OutputLogs:    outputLogs.Bytes(),
 			DurationNanos: durationNanos,
 			Result:        hookResultStarlark,
 		}
@@ -274,7 +297,7 @@ func (larker *Larker) Hook(
 	case err := <-errCh:
 		return &HookResult{
 			ErrorMessage: err.Error(),
-			OutputLogs:   logsWithErrorAttached(outputLogsBuffer.Bytes(), err),
+			OutputLogs:   logsWithErrorAttached(outputLogs.Bytes(), err),
 		}, nil
 	case <-ctx.Done():
 		thread.Cancel(ctx.Err().Error())
@@ -282,9 +305,23 @@ func (larker *Larker) Hook(
 	}
 }
 
-func logsWithErrorAttached(logs []byte, err error) []byte {
-	fmt.Printf("%T\n", err)
+func (larker *Larker) execFile(thread *starlark.Thread, filename string, source string) (starlark.StringDict, error) {
+	if len(larker.tracers) != 0 {
+		return instrument.ExecFile(thread, filename, fs.HostPath(larker.fs, filename), []byte(source), larker.tracers)
+	}
+
+	return starlark.ExecFile(thread, filename, source, nil)
+}
+
+func (larker *Larker) effectiveLoaderOpts() []loader.Option {
+	if larker.mocks == nil {
+		return larker.loaderOpts
+	}
 
+	return append([]loader.Option{loader.WithMocks(larker.mocks)}, larker.loaderOpts...)
+}
+
+func logsWithErrorAttached(logs []byte, err error) []byte {
 	ee, ok := errors.Unwrap(err).(*starlark.EvalError)
 	if !ok {
 		return logs
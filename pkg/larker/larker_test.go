@@ -2,16 +2,24 @@ package larker_test
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
 	"errors"
 	"github.com/cirruslabs/cirrus-cli/internal/testutil"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/debugger"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/local"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -131,6 +139,97 @@ func TestLoadFileSystemLocal(t *testing.T) {
 	}
 }
 
+// TestDebugger ensures that the debugger can pause the execution of both the main() and the loaded modules.
+func TestDebugger(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/load-fs-local")
+
+	// Read the source code
+	source, err := ioutil.ReadFile(filepath.Join(dir, ".cirrus.star"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run the source code
+	output := &bytes.Buffer{}
+	dbg := debugger.New(strings.NewReader("continue\ncontinue\nprint some_dir\ncontinue\n"), output,
+		&debugger.Breakpoint{File: "lib.star", Line: 1},
+		&debugger.Breakpoint{File: ".cirrus.star", Line: 5},
+	)
+
+	lrk := larker.New(larker.WithFileSystem(local.New(dir)), larker.WithDebugger(dbg))
+	_, err = lrk.Main(context.Background(), string(source))
+	require.NoError(t, err)
+
+	assert.Contains(t, output.String(), "> lib.star:1:1 in <toplevel>\n    1 | some_library_function = 42\n")
+	assert.Contains(t, output.String(), "> lib.star:1:1 in <toplevel>\n    1 | some_dir = \"yes\"\n")
+	assert.Contains(t, output.String(), "> .cirrus.star:5:5 in main()\n")
+	assert.Contains(t, output.String(), "\"yes\"\n")
+}
+
+// TestLimits ensures that exceeding each of the resource limits stops the evaluation with a distinct error.
+func TestLimits(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/limits")
+
+	testCases := []struct {
+		Name     string
+		File     string
+		Option   larker.Option
+		Expected error
+	}{
+		{"execution steps", "steps.star", larker.WithMaxExecutionSteps(10000),
+			larker.ErrExecutionStepsLimitExceeded},
+		{"output", "output.star", larker.WithMaxOutputBytes(1024), larker.ErrOutputLimitExceeded},
+		{"loaded modules", "modules.star", larker.WithMaxLoadedModules(1), loader.ErrLoadedModulesLimitExceeded},
+		{"module source bytes", "modules.star", larker.WithMaxModuleSourceBytes(16),
+			loader.ErrModuleSourceBytesLimitExceeded},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			source, err := ioutil.ReadFile(filepath.Join(dir, testCase.File))
+			require.NoError(t, err)
+
+			lrk := larker.New(larker.WithFileSystem(local.New(dir)), testCase.Option)
+			_, err = lrk.Main(context.Background(), string(source))
+			require.ErrorIs(t, err, testCase.Expected)
+
+			var limitExceededError *larker.LimitExceededError
+			require.ErrorAs(t, err, &limitExceededError)
+
+			// The evaluation should succeed without the limit
+			_, err = larker.New(larker.WithFileSystem(local.New(dir))).Main(context.Background(), string(source))
+			require.NoError(t, err)
+		})
+	}
+
+	// Output that was captured before reaching the limit is preserved
+	source, err := ioutil.ReadFile(filepath.Join(dir, "output.star"))
+	require.NoError(t, err)
+
+	_, err = larker.New(larker.WithMaxOutputBytes(1024)).Main(context.Background(), string(source))
+
+	var extendedError *larker.ExtendedError
+	require.ErrorAs(t, err, &extendedError)
+	assert.True(t, bytes.HasPrefix(extendedError.Logs(), []byte("line 0\nline 1\n")))
+	assert.LessOrEqual(t, bytes.Count(extendedError.Logs(), []byte("line")), 1024/len("line 0\n"))
+}
+
+// TestHookLimits ensures that the resource limits apply to the hooks too.
+func TestHookLimits(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/limits")
+
+	source, err := ioutil.ReadFile(filepath.Join(dir, "steps.star"))
+	require.NoError(t, err)
+
+	lrk := larker.New(larker.WithMaxExecutionSteps(10000))
+	result, err := lrk.Hook(context.Background(), string(source), "on_build_completed", []interface{}{})
+	require.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, larker.ErrExecutionStepsLimitExceeded.Error())
+	assert.Contains(t, string(result.OutputLogs), "in main")
+}
+
 // TestTimeout ensures that context.Context can be used to stop the execution of a potentially long-running script.
 func TestTimeout(t *testing.T) {
 	dir := testutil.TempDirPopulatedWith(t, "testdata/timeout")
@@ -258,6 +357,96 @@ func TestBuiltinFS(t *testing.T) {
 	}
 }
 
+// TestBuiltinGit ensures that we expose the metadata of the local Git repository as the cirrus.git module.
+func TestBuiltinGit(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/builtin-git")
+
+	// Read the source code
+	source, err := ioutil.ReadFile(filepath.Join(dir, ".cirrus.star"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a repository with a feature branch that diverged from the master
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commitFile := func(name string, contents string, message string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{
+				Name:  "John Doe",
+				Email: "john@example.com",
+				When:  time.Unix(1600000000, 0),
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commitFile("base.txt", "base", "Initial commit")
+	if err := worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("feature"),
+		Create: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	commitFile("feature.txt", "feature", "Add feature")
+	commitFile("base.txt", "updated base", "Update base")
+
+	// Run the source code
+	lrk := larker.New(larker.WithFileSystem(local.New(dir)))
+	_, err = lrk.Main(context.Background(), string(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBuiltinGitProjectInSubdirectory ensures that git.changed_files() reports the paths relative
+// to the project directory when it's not the repository root, similarly to the fs module.
+func TestBuiltinGitProjectInSubdirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "project", "ci"), 0700))
+	for _, name := range []string{"other.txt", "project/ci/build.sh"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0600))
+		_, err := worktree.Add(name)
+		require.NoError(t, err)
+	}
+	_, err = worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "John Doe", Email: "john@example.com", When: time.Unix(1600000000, 0)},
+	})
+	require.NoError(t, err)
+
+	lrk := larker.New(larker.WithFileSystem(local.New(filepath.Join(dir, "project"))))
+	_, err = lrk.Main(context.Background(), `load("cirrus", "fs", "git")
+
+def main(ctx):
+    changed = git.changed_files()
+    if changed != ["ci/build.sh"] or fs.stat(changed[0]) == None:
+        fail("unexpected changed files %s" % changed)
+
+    return []
+`)
+	require.NoError(t, err)
+}
+
 // TestBuiltinEnv ensures that we expose the environment passed through options as the cirrus.env dict.
 func TestBuiltinEnv(t *testing.T) {
 	dir := testutil.TempDirPopulatedWith(t, "testdata/builtin-env")
@@ -389,3 +578,139 @@ func TestTestMode(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, string(result.OutputLogs), "testing mode enabled")
 }
+
+// TestLoadOffline ensures that the pinned remote modules can be loaded from the module store
+// without accessing the network.
+func TestLoadOffline(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/load-offline")
+
+	// Read the source code
+	source, err := ioutil.ReadFile(filepath.Join(dir, ".cirrus.star"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		repository = "github.com/some-org/some-repo"
+		commit     = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	)
+
+	store := modules.NewStore(t.TempDir())
+	require.NoError(t, store.Put(repository, commit, "helpers.star",
+		[]byte("load(\"image.star\", \"image\")\n\ndef container():\n    return {\"image\": image}\n")))
+	require.NoError(t, store.Put(repository, commit, "image.star", []byte("image = \"debian:latest\"\n")))
+
+	// Run the source code without the module being pinned
+	lrk := larker.New(larker.WithFileSystem(local.New(dir)), larker.WithModuleStore(store), larker.WithOfflineMode())
+	_, err = lrk.Main(context.Background(), string(source))
+	require.ErrorIs(t, err, larker.ErrLoadFailed)
+	require.Contains(t, err.Error(), "is not pinned in .cirrus.lock")
+
+	// Run the source code with the module pinned
+	lockfile := modules.NewLockfile()
+	lockfile.Pin(repository+"/helpers.star@v1", commit)
+
+	lrk = larker.New(larker.WithFileSystem(local.New(dir)), larker.WithModuleStore(store),
+		larker.WithLockfile(lockfile), larker.WithOfflineMode())
+	result, err := lrk.Main(context.Background(), string(source))
+	require.NoError(t, err)
+	assert.Contains(t, result.YAMLConfig, "image: debian:latest")
+}
+
+// TestUnitTests ensures that the test_* functions are discovered, ran in order and isolated from each other.
+func TestUnitTests(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/unit-test")
+
+	// Read the source code
+	source, err := ioutil.ReadFile(filepath.Join(dir, "test_lib.star"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lrk := larker.New(larker.WithFileSystem(local.New(dir)))
+	results, err := lrk.Test(context.Background(), "test_lib.star", string(source))
+	require.NoError(t, err)
+
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	require.Equal(t, []string{
+		"test_branch_default",
+		"test_branch_mocked",
+		"test_fs_mocked",
+		"test_http_mocked",
+		"test_http_unexpected",
+		"test_git_unavailable",
+		"test_git_mocked",
+		"test_contains",
+		"test_assertion_fails",
+		"test_error",
+	}, names)
+
+	for _, result := range results[:8] {
+		assert.True(t, result.Passed(), "%s: %s", result.Name, result.ErrorMessage)
+	}
+
+	assertionFailed := results[8]
+	assert.False(t, assertionFailed.Passed())
+	assert.True(t, assertionFailed.AssertionFailed)
+	assert.Contains(t, assertionFailed.ErrorMessage, "branch: \"main\" != \"feature\"")
+	assert.Contains(t, string(assertionFailed.OutputLogs), "about to fail")
+	assert.Contains(t, string(assertionFailed.OutputLogs), "test_lib.star:")
+
+	errored := results[9]
+	assert.False(t, errored.Passed())
+	assert.False(t, errored.AssertionFailed)
+	assert.Contains(t, errored.ErrorMessage, "oops")
+}
+
+// TestAssertOnlyInTests ensures that the assert module is only available in tests.
+func TestAssertOnlyInTests(t *testing.T) {
+	lrk := larker.New(larker.WithFileSystem(local.New(t.TempDir())))
+	_, err := lrk.Main(context.Background(), "load(\"cirrus\", \"assert\")\n\ndef main(ctx):\n    return []\n")
+	require.ErrorIs(t, err, larker.ErrLoadFailed)
+
+	lrk = larker.New(larker.WithFileSystem(local.New(t.TempDir())), larker.WithTestMode())
+	_, err = lrk.Main(context.Background(), "load(\"cirrus\", \"assert\")\n\ndef main(ctx):\n    return []\n")
+	require.NoError(t, err)
+}
+
+// TestConvertYAML ensures that the Starlark configuration converted from YAML generates the same configuration.
+func TestConvertYAML(t *testing.T) {
+	dir := testutil.TempDirPopulatedWith(t, "testdata/convert-yaml")
+
+	yamlConfig, err := ioutil.ReadFile(filepath.Join(dir, ".cirrus.yml"))
+	require.NoError(t, err)
+
+	starlarkConfig, err := larker.ConvertYAML(yamlConfig)
+	require.NoError(t, err)
+	assert.Contains(t, starlarkConfig, "# Run the tests\n")
+
+	lrk := larker.New(larker.WithFileSystem(local.New(dir)))
+	result, err := lrk.Main(context.Background(), starlarkConfig)
+	require.NoError(t, err)
+
+	assert.YAMLEq(t, loadExpectedConfig(t, dir), result.YAMLConfig)
+}
+
+func TestConvertYAMLOnlyTasks(t *testing.T) {
+	starlarkConfig, err := larker.ConvertYAML([]byte("task:\n  script: make\ntask:\n  script: make test\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `def main(ctx):
+    return [
+        {
+            "script": "make",
+        },
+        {
+            "script": "make test",
+        },
+    ]
+`, starlarkConfig)
+}
+
+func TestConvertYAMLDuplicateKey(t *testing.T) {
+	_, err := larker.ConvertYAML([]byte("task:\n  script: make\n  script: make test\n"))
+	assert.ErrorIs(t, err, larker.ErrDuplicateKey)
+}
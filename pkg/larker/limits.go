@@ -0,0 +1,106 @@
+package larker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
+	"go.starlark.net/starlark"
+)
+
+var (
+	ErrExecutionStepsLimitExceeded = errors.New("execution steps limit exceeded")
+	ErrOutputLimitExceeded         = errors.New("output size limit exceeded")
+)
+
+// LimitExceededError is returned when the evaluation is stopped because it has exceeded one of the resource limits.
+//
+// Use errors.Is() with ErrExecutionStepsLimitExceeded, ErrOutputLimitExceeded,
+// loader.ErrLoadedModulesLimitExceeded or loader.ErrModuleSourceBytesLimitExceeded to tell which one.
+type LimitExceededError struct {
+	limit error
+	cause error
+}
+
+func (lee *LimitExceededError) Error() string {
+	return lee.limit.Error()
+}
+
+// Unwrap returns the evaluation error, which carries the Starlark backtrace.
+func (lee *LimitExceededError) Unwrap() error {
+	return lee.cause
+}
+
+func (lee *LimitExceededError) Is(err error) bool {
+	return errors.Is(lee.limit, err)
+}
+
+// output captures the print() calls and stops the evaluation once the limit is exceeded.
+type output struct {
+	buf      bytes.Buffer
+	maxBytes int
+	exceeded bool
+}
+
+func (output *output) Print(thread *starlark.Thread, msg string) {
+	if output.exceeded {
+		return
+	}
+
+	if output.maxBytes != 0 && output.buf.Len()+len(msg)+1 > output.maxBytes {
+		output.exceeded = true
+		thread.Cancel(ErrOutputLimitExceeded.Error())
+
+		return
+	}
+
+	_, _ = fmt.Fprintln(&output.buf, msg)
+}
+
+func (output *output) Bytes() []byte {
+	return output.buf.Bytes()
+}
+
+func (larker *Larker) newOutput() *output {
+	return &output{maxBytes: larker.maxOutputBytes}
+}
+
+func (larker *Larker) newThread(moduleLoader *loader.Loader, output *output) *starlark.Thread {
+	thread := &starlark.Thread{
+		Load:  moduleLoader.LoadFunc(larker.fs),
+		Print: output.Print,
+	}
+
+	if larker.maxExecutionSteps != 0 {
+		thread.SetMaxExecutionSteps(larker.maxExecutionSteps)
+	}
+
+	return thread
+}
+
+// exceededLimit returns a LimitExceededError if the evaluation error was caused by exceeding
+// one of the resource limits, otherwise nil.
+func (larker *Larker) exceededLimit(
+	thread *starlark.Thread,
+	moduleLoader *loader.Loader,
+	output *output,
+	err error,
+) error {
+	var limit error
+
+	switch {
+	case output.exceeded:
+		limit = fmt.Errorf("%w: print() output is larger than %d bytes", ErrOutputLimitExceeded, output.maxBytes)
+	case larker.maxExecutionSteps != 0 && thread.ExecutionSteps() >= larker.maxExecutionSteps:
+		limit = fmt.Errorf("%w: evaluation took more than %d steps", ErrExecutionStepsLimitExceeded,
+			larker.maxExecutionSteps)
+	default:
+		limit = moduleLoader.ExceededLimit()
+	}
+
+	if limit == nil {
+		return nil
+	}
+
+	return &LimitExceededError{limit: limit, cause: err}
+}
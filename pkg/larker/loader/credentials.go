@@ -0,0 +1,188 @@
+package loader
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/bitbucket"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/git"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/gitlab"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	gitLabHost    = "gitlab.com"
+	bitbucketHost = "bitbucket.org"
+
+	gitLabTokenVariable    = "GITLAB_TOKEN"
+	bitbucketTokenVariable = "BITBUCKET_TOKEN"
+)
+
+// credentials are used to access the private repositories of the remote modules.
+type credentials struct {
+	// Username is empty when the Password is an access token
+	Username string
+	Password string
+}
+
+// lookupCredentials returns the credentials for the host, preferring the access token
+// from the environment variable over the host's entry in the user's .netrc file (when enabled).
+func lookupCredentials(
+	env map[string]string,
+	host string,
+	tokenVariable string,
+	allowLocalCredentials bool,
+) *credentials {
+	if token := env[tokenVariable]; token != "" {
+		return &credentials{Password: token}
+	}
+
+	if !allowLocalCredentials {
+		return nil
+	}
+
+	return lookupNetrc(host)
+}
+
+func gitLabOptions(env map[string]string, allowLocalCredentials bool) []gitlab.Option {
+	creds := lookupCredentials(env, gitLabHost, gitLabTokenVariable, allowLocalCredentials)
+	if creds == nil {
+		return nil
+	}
+
+	return []gitlab.Option{gitlab.WithToken(creds.Password)}
+}
+
+func bitbucketOptions(env map[string]string, allowLocalCredentials bool) []bitbucket.Option {
+	creds := lookupCredentials(env, bitbucketHost, bitbucketTokenVariable, allowLocalCredentials)
+	if creds == nil {
+		return nil
+	}
+
+	if creds.Username == "" {
+		return []bitbucket.Option{bitbucket.WithToken(creds.Password)}
+	}
+
+	return []bitbucket.Option{bitbucket.WithBasicAuth(creds.Username, creds.Password)}
+}
+
+func gitOptions(repositoryURL string, allowLocalCredentials bool) ([]git.Option, error) {
+	// SSH URLs are authenticated using the SSH agent, so only allow
+	// them when running on behalf of the user
+	parsedURL, err := url.Parse(repositoryURL)
+	if err != nil || parsedURL.Scheme != "https" {
+		if !allowLocalCredentials {
+			return nil, fmt.Errorf("%w: cloning %s over SSH is not allowed in this context, "+
+				"please use an HTTPS URL instead", ErrUnsupportedLocation, repositoryURL)
+		}
+
+		return nil, nil
+	}
+
+	if !allowLocalCredentials {
+		return nil, nil
+	}
+
+	creds := lookupNetrc(parsedURL.Hostname())
+	if creds == nil {
+		return nil, nil
+	}
+
+	return []git.Option{git.WithAuth(&githttp.BasicAuth{
+		Username: creds.Username,
+		Password: creds.Password,
+	})}, nil
+}
+
+// lookupNetrc returns the credentials for the host from the .netrc file
+// pointed to by the NETRC environment variable or located in the user's home directory.
+func lookupNetrc(host string) *credentials {
+	netrcPath := os.Getenv("NETRC")
+
+	if netrcPath == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+
+		netrcName := ".netrc"
+		if runtime.GOOS == "windows" {
+			netrcName = "_netrc"
+		}
+
+		netrcPath = filepath.Join(homeDir, netrcName)
+	}
+
+	file, err := os.Open(netrcPath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Split(bufio.ScanWords)
+
+	var tokens []string
+
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+
+	return parseNetrc(tokens, host)
+}
+
+// parseNetrc finds the credentials for the host in the whitespace-separated .netrc tokens.
+//
+// The "default" entry is deliberately ignored: the hosts are chosen by the configuration,
+// so falling back to it would send the credentials to an arbitrary host.
+func parseNetrc(tokens []string, host string) *credentials {
+	// target is the entry that the login and password tokens currently belong to
+	var matched, target *credentials
+
+	for i := 0; i < len(tokens); i++ {
+		var value string
+		if i+1 < len(tokens) {
+			value = tokens[i+1]
+		}
+
+		switch tokens[i] {
+		case "machine":
+			if matched != nil {
+				return matched
+			}
+
+			target = nil
+			if value == host {
+				matched = &credentials{}
+				target = matched
+			}
+
+			i++
+		case "default":
+			if matched != nil {
+				return matched
+			}
+
+			target = nil
+		case "login":
+			if target != nil {
+				target.Username = value
+			}
+
+			i++
+		case "password":
+			if target != nil {
+				target.Password = value
+			}
+
+			i++
+		case "account":
+			i++
+		}
+	}
+
+	return matched
+}
@@ -0,0 +1,84 @@
+// nolint:testpackage // testing private methods
+package loader
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const netrc = `machine github.com login gh-user password gh-password
+
+machine gitlab.com
+  login gl-user
+  password gl-token
+
+default login default-user password default-password
+`
+
+func TestParseNetrc(t *testing.T) {
+	tokens := strings.Fields(netrc)
+
+	assert.Equal(t, &credentials{Username: "gl-user", Password: "gl-token"}, parseNetrc(tokens, "gitlab.com"))
+	assert.Equal(t, &credentials{Username: "gh-user", Password: "gh-password"}, parseNetrc(tokens, "github.com"))
+	// The "default" entry is never used
+	assert.Nil(t, parseNetrc(tokens, "bitbucket.org"))
+	assert.Nil(t, parseNetrc(strings.Fields("machine github.com login gh-user"), "gitlab.com"))
+}
+
+func TestLookupCredentials(t *testing.T) {
+	netrcPath := filepath.Join(t.TempDir(), ".netrc")
+	require.NoError(t, ioutil.WriteFile(netrcPath, []byte(netrc), 0600))
+	t.Setenv("NETRC", netrcPath)
+
+	// Environment variable takes precedence over the .netrc
+	assert.Equal(t, &credentials{Password: "env-token"},
+		lookupCredentials(map[string]string{gitLabTokenVariable: "env-token"}, gitLabHost, gitLabTokenVariable, true))
+	assert.Equal(t, &credentials{Username: "gl-user", Password: "gl-token"},
+		lookupCredentials(map[string]string{}, gitLabHost, gitLabTokenVariable, true))
+
+	// Generic Git repositories are only authenticated via .netrc when cloned over HTTPS
+	opts, err := gitOptions("https://github.com/some-org/some-repo.git", true)
+	require.NoError(t, err)
+	assert.Len(t, opts, 1)
+	opts, err = gitOptions("git@github.com:some-org/some-repo.git", true)
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+	opts, err = gitOptions("https://example.com/some-org/some-repo.git", true)
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+
+	// The .netrc is not read unless explicitly enabled
+	assert.Nil(t, lookupCredentials(map[string]string{}, gitLabHost, gitLabTokenVariable, false))
+	opts, err = gitOptions("https://github.com/some-org/some-repo.git", false)
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+}
+
+// TestSSHRequiresLocalCredentials ensures that the SSH URLs are rejected unless the local credentials
+// are allowed, since otherwise they'd be authenticated with the SSH agent of whoever runs the evaluation.
+func TestSSHRequiresLocalCredentials(t *testing.T) {
+	for _, url := range []string{
+		"git@github.com:some-org/some-repo.git",
+		"ssh://git@github.com/some-org/some-repo.git",
+	} {
+		_, err := gitOptions(url, false)
+		assert.ErrorIs(t, err, ErrUnsupportedLocation, url)
+	}
+
+	_, _, err := FindModuleFS(context.Background(), nil, map[string]string{},
+		"git@github.com:some-org/some-repo.git/lib.star@main")
+	assert.ErrorIs(t, err, ErrUnsupportedLocation)
+}
+
+func TestNoNetrc(t *testing.T) {
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "does-not-exist"))
+
+	assert.Nil(t, lookupCredentials(map[string]string{}, bitbucketHost, bitbucketTokenVariable, true))
+	assert.Empty(t, bitbucketOptions(map[string]string{}, true))
+	assert.Len(t, bitbucketOptions(map[string]string{bitbucketTokenVariable: "env-token"}, true), 1)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/certifi/gocertifi"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/builtin"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
 	"github.com/qri-io/starlib/encoding/base64"
 	"github.com/qri-io/starlib/encoding/yaml"
 	"github.com/qri-io/starlib/hash"
@@ -24,7 +26,9 @@ import (
 )
 
 var (
-	ErrCycle = errors.New("import cycle detected")
+	ErrCycle                          = errors.New("import cycle detected")
+	ErrLoadedModulesLimitExceeded     = errors.New("loaded modules limit exceeded")
+	ErrModuleSourceBytesLimitExceeded = errors.New("module source bytes limit exceeded")
 )
 
 type CacheEntry struct {
@@ -39,6 +43,23 @@ type Loader struct {
 	env           map[string]string
 	affectedFiles []string
 	isTest        bool
+
+	store                 *modules.Store
+	lockfile              *modules.Lockfile
+	offline               bool
+	allowLocalCredentials bool
+
+	mocks *builtin.Mocks
+
+	tracers []instrument.Tracer
+
+	// Zero means no limit
+	maxLoadedModules     int
+	maxModuleSourceBytes int64
+
+	loadedModules     int
+	moduleSourceBytes int64
+	exceededLimit     error
 }
 
 func NewLoader(
@@ -47,8 +68,9 @@ func NewLoader(
 	env map[string]string,
 	affectedFiles []string,
 	isTest bool,
+	opts ...Option,
 ) *Loader {
-	return &Loader{
+	loader := &Loader{
 		ctx:           ctx,
 		cache:         make(map[string]*CacheEntry),
 		fs:            fs,
@@ -56,6 +78,12 @@ func NewLoader(
 		affectedFiles: affectedFiles,
 		isTest:        isTest,
 	}
+
+	for _, opt := range opts {
+		opt(loader)
+	}
+
+	return loader
 }
 
 func (loader *Loader) LoadFunc(
@@ -81,7 +109,7 @@ func (loader *Loader) LoadFunc(
 			return loader.loadCirrusModule()
 		}
 
-		moduleFS, path, err := findModuleFS(loader.ctx, frameFS, loader.env, module)
+		moduleFS, path, err := loader.findModuleFS(frameFS, module)
 		if err != nil {
 			return nil, err
 		}
@@ -100,13 +128,23 @@ func (loader *Loader) LoadFunc(
 			return nil, err
 		}
 
+		if err := loader.account(len(source)); err != nil {
+			return nil, err
+		}
+
 		// Place a canary to indicate the commencing load and detect cycles
 		loader.cache[module] = nil
 
 		// Load the module and cache results
 		oldLoad := thread.Load
 		thread.Load = loader.LoadFunc(moduleFS)
-		globals, err := starlark.ExecFile(thread, filepath.Base(module), source, nil)
+		var globals starlark.StringDict
+		if len(loader.tracers) != 0 {
+			globals, err = instrument.ExecFile(thread, filepath.Base(module), fs.HostPath(moduleFS, path),
+				source, loader.tracers)
+		} else {
+			globals, err = starlark.ExecFile(thread, filepath.Base(module), source, nil)
+		}
 		thread.Load = oldLoad
 
 		loader.cache[module] = &CacheEntry{
@@ -118,6 +156,27 @@ func (loader *Loader) LoadFunc(
 	}
 }
 
+// ExceededLimit returns the error describing the loading limit that was exceeded, if any.
+func (loader *Loader) ExceededLimit() error {
+	return loader.exceededLimit
+}
+
+func (loader *Loader) account(sourceBytes int) error {
+	loader.loadedModules++
+	loader.moduleSourceBytes += int64(sourceBytes)
+
+	switch {
+	case loader.maxLoadedModules != 0 && loader.loadedModules > loader.maxLoadedModules:
+		loader.exceededLimit = fmt.Errorf("%w: more than %d modules were loaded",
+			ErrLoadedModulesLimitExceeded, loader.maxLoadedModules)
+	case loader.maxModuleSourceBytes != 0 && loader.moduleSourceBytes > loader.maxModuleSourceBytes:
+		loader.exceededLimit = fmt.Errorf("%w: source code of the loaded modules is larger than %d bytes in total",
+			ErrModuleSourceBytesLimitExceeded, loader.maxModuleSourceBytes)
+	}
+
+	return loader.exceededLimit
+}
+
 func (loader *Loader) loadCirrusModule() (starlark.StringDict, error) {
 	result := make(starlark.StringDict)
 
@@ -139,6 +198,16 @@ func (loader *Loader) loadCirrusModule() (starlark.StringDict, error) {
 		Members: builtin.FS(loader.ctx, loader.fs),
 	}
 
+	gitMembers := builtin.Git(fs.HostPath(loader.fs, "."))
+	// The tests shouldn't depend on the repository they're run from
+	if loader.isTest || loader.mocks != nil {
+		gitMembers = builtin.MockableGit(loader.mocks)
+	}
+	result["git"] = &starlarkstruct.Module{
+		Name:    "git",
+		Members: gitMembers,
+	}
+
 	certPool, err := gocertifi.CACerts()
 	if err != nil {
 		http.Client = &gohttp.Client{
@@ -157,6 +226,28 @@ func (loader *Loader) loadCirrusModule() (starlark.StringDict, error) {
 	}
 	result["http"] = httpModule["http"]
 
+	// The assertions are only useful in tests
+	if loader.isTest || loader.mocks != nil {
+		result["assert"] = &starlarkstruct.Module{
+			Name:    "assert",
+			Members: builtin.Assert(),
+		}
+	}
+
+	// Allow the tests to replace the env, fs and http at runtime
+	if loader.mocks != nil {
+		result["env"] = builtin.MockableEnv(starlarkEnv, loader.mocks)
+		result["fs"] = &starlarkstruct.Module{
+			Name:    "fs",
+			Members: builtin.FS(loader.ctx, builtin.MockableFS(loader.fs, loader.mocks)),
+		}
+		result["http"] = builtin.HTTP(httpModule["http"].(*starlarkstruct.Struct), loader.mocks)
+		result["mock"] = &starlarkstruct.Module{
+			Name:    "mock",
+			Members: builtin.Mock(loader.mocks),
+		}
+	}
+
 	hashModule, err := hash.LoadModule()
 	if err != nil {
 		return nil, err
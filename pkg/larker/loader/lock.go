@@ -0,0 +1,80 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"go.starlark.net/syntax"
+)
+
+// Lock resolves the revisions of the remote modules transitively loaded by the entrypoint
+// to their current commits and returns a lockfile that pins them.
+//
+// The modules are discovered by statically inspecting the load() statements, so the entrypoint is never executed.
+// As a side effect, all the loaded files are retrieved into the module store (if any).
+func Lock(
+	ctx context.Context,
+	projectFS fs.FileSystem,
+	env map[string]string,
+	store *modules.Store,
+	entrypoint string,
+	opts ...Option,
+) (*modules.Lockfile, error) {
+	lockfile := modules.NewLockfile()
+
+	opts = append([]Option{WithModuleStore(store), WithLockfile(lockfile)}, opts...)
+	loader := NewLoader(ctx, projectFS, env, nil, false, opts...)
+
+	if err := loader.lockModule(projectFS, entrypoint, nil); err != nil {
+		return nil, err
+	}
+
+	return lockfile, nil
+}
+
+func (loader *Loader) lockModule(frameFS fs.FileSystem, module string, stack []string) error {
+	if module == "cirrus" {
+		return nil
+	}
+
+	moduleFS, path, err := loader.findModuleFS(frameFS, module)
+	if err != nil {
+		return err
+	}
+
+	// Relative paths are only unique within a single repository
+	key := path
+	if remoteFS, ok := moduleFS.(*modules.FS); ok {
+		key = remoteFS.Repository() + "@" + remoteFS.Commit() + "/" + path
+	}
+
+	for _, seen := range stack {
+		if seen == key {
+			return fmt.Errorf("%w: %s", ErrCycle, module)
+		}
+	}
+
+	source, err := moduleFS.Get(loader.ctx, path)
+	if err != nil {
+		return fmt.Errorf("%w: module '%s': %v", ErrRetrievalFailed, module, err)
+	}
+
+	file, err := syntax.Parse(module, source, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range file.Stmts {
+		loadStmt, ok := stmt.(*syntax.LoadStmt)
+		if !ok {
+			continue
+		}
+
+		if err := loader.lockModule(moduleFS, loadStmt.ModuleName(), append(stack, key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
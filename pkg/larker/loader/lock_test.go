@@ -0,0 +1,45 @@
+package loader_test
+
+import (
+	"context"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/memory"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLockLocalModules(t *testing.T) {
+	projectFS, err := memory.New(map[string][]byte{
+		".cirrus.star": []byte("load(\"lib.star\", \"x\")\n"),
+		"lib.star":     []byte("load(\"cirrus\", \"env\")\n\nx = 1\n"),
+	})
+	require.NoError(t, err)
+
+	lockfile, err := loader.Lock(context.Background(), projectFS, nil, modules.NewStore(t.TempDir()), ".cirrus.star")
+	require.NoError(t, err)
+	assert.Empty(t, lockfile.Modules)
+}
+
+func TestLockCycle(t *testing.T) {
+	projectFS, err := memory.New(map[string][]byte{
+		".cirrus.star": []byte("load(\"a.star\", \"a\")\n"),
+		"a.star":       []byte("load(\"b.star\", \"b\")\n"),
+		"b.star":       []byte("load(\"a.star\", \"a\")\n"),
+	})
+	require.NoError(t, err)
+
+	_, err = loader.Lock(context.Background(), projectFS, nil, nil, ".cirrus.star")
+	assert.ErrorIs(t, err, loader.ErrCycle)
+}
+
+func TestLockMissingModule(t *testing.T) {
+	projectFS, err := memory.New(map[string][]byte{
+		".cirrus.star": []byte("load(\"missing.star\", \"x\")\n"),
+	})
+	require.NoError(t, err)
+
+	_, err = loader.Lock(context.Background(), projectFS, nil, nil, ".cirrus.star")
+	assert.ErrorIs(t, err, loader.ErrRetrievalFailed)
+}
@@ -3,9 +3,13 @@ package loader
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/bitbucket"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/git"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/github"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/gitlab"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
 	"regexp"
 )
 
@@ -26,6 +30,19 @@ type gitHubLocation struct {
 	Path     string
 }
 
+type gitLabLocation struct {
+	Project  string
+	Revision string
+	Path     string
+}
+
+type bitbucketLocation struct {
+	Workspace string
+	Name      string
+	Revision  string
+	Path      string
+}
+
 var (
 	ErrRetrievalFailed     = errors.New("failed to retrieve a module")
 	ErrUnsupportedLocation = errors.New("unsupported location")
@@ -44,44 +61,48 @@ var (
 		`^(?P<root>github\.com/(?P<owner>.*?)/(?P<name>.*?))` + optionalPath + optionalRevision + `$`,
 	)
 
+	// GitLab subgroups are ambiguous with the path, so these are only supported with the ".git" hint,
+	// in which case the repository is cloned as a generic Git repository (see genericGitRegexVariant)
+	gitlabRegexVariant = regexp.MustCompile(
+		`^(?P<root>gitlab\.com/(?P<owner>.*?)/(?P<name>.*?))` + optionalPath + optionalRevision + `$`,
+	)
+
+	bitbucketRegexVariant = regexp.MustCompile(
+		`^(?P<root>bitbucket\.org/(?P<owner>.*?)/(?P<name>.*?))` + optionalPath + optionalRevision + `$`,
+	)
+
+	// E.g. git@gitlab.com:some-org/some-repo.git or ssh://git@gitlab.com/some-org/some-repo.git
+	sshGitRegexVariant = regexp.MustCompile(
+		`^(?P<root>(?:ssh://)?[^@/]+@[^:/]+[:/].*?)\.git` + optionalPath + optionalRevision + `$`,
+	)
+
 	genericGitRegexVariant = regexp.MustCompile(`^(?P<root>.*?)\.git` + optionalPath + optionalRevision + `$`)
 )
 
 func parseLocation(module string) interface{} {
-	matches := githubRegexVariant.FindStringSubmatch(module)
-	if matches != nil {
-		owner := matches[githubRegexVariant.SubexpIndex("owner")]
-		name := matches[githubRegexVariant.SubexpIndex("name")]
-
-		revision := matches[githubRegexVariant.SubexpIndex("revision")]
-		if revision == "" {
-			revision = "main"
-		}
-
-		modulePath := matches[githubRegexVariant.SubexpIndex("path")]
-		if modulePath == "" {
-			modulePath = "lib.star"
-		}
+	if matches := githubRegexVariant.FindStringSubmatch(module); matches != nil {
+		revision, modulePath := revisionAndPath(githubRegexVariant, matches)
 
 		return gitHubLocation{
-			Owner:    owner,
-			Name:     name,
+			Owner:    matches[githubRegexVariant.SubexpIndex("owner")],
+			Name:     matches[githubRegexVariant.SubexpIndex("name")],
 			Revision: revision,
 			Path:     modulePath,
 		}
 	}
 
-	matches = genericGitRegexVariant.FindStringSubmatch(module)
-	if matches != nil {
-		revision := matches[genericGitRegexVariant.SubexpIndex("revision")]
-		if revision == "" {
-			revision = "main"
-		}
+	if matches := sshGitRegexVariant.FindStringSubmatch(module); matches != nil {
+		revision, modulePath := revisionAndPath(sshGitRegexVariant, matches)
 
-		modulePath := matches[genericGitRegexVariant.SubexpIndex("path")]
-		if modulePath == "" {
-			modulePath = "lib.star"
+		return gitLocation{
+			URL:      matches[sshGitRegexVariant.SubexpIndex("root")] + ".git",
+			Revision: revision,
+			Path:     modulePath,
 		}
+	}
+
+	if matches := genericGitRegexVariant.FindStringSubmatch(module); matches != nil {
+		revision, modulePath := revisionAndPath(genericGitRegexVariant, matches)
 
 		return gitLocation{
 			URL:      "https://" + matches[genericGitRegexVariant.SubexpIndex("root")] + ".git",
@@ -90,35 +111,109 @@ func parseLocation(module string) interface{} {
 		}
 	}
 
+	if matches := gitlabRegexVariant.FindStringSubmatch(module); matches != nil {
+		revision, modulePath := revisionAndPath(gitlabRegexVariant, matches)
+
+		return gitLabLocation{
+			Project: matches[gitlabRegexVariant.SubexpIndex("owner")] + "/" +
+				matches[gitlabRegexVariant.SubexpIndex("name")],
+			Revision: revision,
+			Path:     modulePath,
+		}
+	}
+
+	if matches := bitbucketRegexVariant.FindStringSubmatch(module); matches != nil {
+		revision, modulePath := revisionAndPath(bitbucketRegexVariant, matches)
+
+		return bitbucketLocation{
+			Workspace: matches[bitbucketRegexVariant.SubexpIndex("owner")],
+			Name:      matches[bitbucketRegexVariant.SubexpIndex("name")],
+			Revision:  revision,
+			Path:      modulePath,
+		}
+	}
+
 	return localLocation{Path: module}
 }
 
-func findModuleFS(
+func revisionAndPath(regex *regexp.Regexp, matches []string) (string, string) {
+	revision := matches[regex.SubexpIndex("revision")]
+	if revision == "" {
+		revision = "main"
+	}
+
+	modulePath := matches[regex.SubexpIndex("path")]
+	if modulePath == "" {
+		modulePath = "lib.star"
+	}
+
+	return revision, modulePath
+}
+
+// FindModuleFS returns the file system where the module is located along with the module's path in it.
+//
+// Besides the Starlark modules, it's also used to locate the files in the YAML configuration's include: directive.
+// Only the access tokens from the env are used to authenticate: the user's .netrc file is never read
+// and the SSH URLs are rejected, since these would've been authenticated using the SSH agent.
+func FindModuleFS(
 	ctx context.Context,
 	currentFS fs.FileSystem,
 	env map[string]string,
 	module string,
 ) (fs.FileSystem, string, error) {
-	return findLocatorFS(ctx, currentFS, env, parseLocation(module))
+	return findLocatorFS(ctx, currentFS, env, false, parseLocation(module))
+}
+
+// FindLocalModuleFS is similar to FindModuleFS, but only supports the modules located in the current file system.
+func FindLocalModuleFS(
+	ctx context.Context,
+	currentFS fs.FileSystem,
+	env map[string]string,
+	module string,
+) (fs.FileSystem, string, error) {
+	location := parseLocation(module)
+
+	if _, ok := location.(localLocation); !ok {
+		return nil, "", fmt.Errorf("%w: %s is not in the current repository and retrieving the remote files "+
+			"is disabled", ErrUnsupportedLocation, module)
+	}
+
+	return findLocatorFS(ctx, currentFS, env, false, location)
 }
 
 func findLocatorFS(
 	ctx context.Context,
 	currentFS fs.FileSystem,
 	env map[string]string,
+	allowLocalCredentials bool,
 	location interface{},
 ) (fs.FileSystem, string, error) {
 	switch l := location.(type) {
 	case gitHubLocation:
-		token := env["CIRRUS_REPO_CLONE_TOKEN"]
-
-		ghFS, err := github.New(l.Owner, l.Name, l.Revision, token)
+		ghFS, err := github.New(l.Owner, l.Name, l.Revision, env["CIRRUS_REPO_CLONE_TOKEN"])
 		if err != nil {
 			return nil, "", err
 		}
 		return ghFS, l.Path, nil
+	case gitLabLocation:
+		glFS, err := gitlab.New(l.Project, l.Revision, gitLabOptions(env, allowLocalCredentials)...)
+		if err != nil {
+			return nil, "", err
+		}
+		return glFS, l.Path, nil
+	case bitbucketLocation:
+		bbFS, err := bitbucket.New(l.Workspace, l.Name, l.Revision, bitbucketOptions(env, allowLocalCredentials)...)
+		if err != nil {
+			return nil, "", err
+		}
+		return bbFS, l.Path, nil
 	case gitLocation:
-		gitFS, err := git.New(ctx, l.URL, l.Revision)
+		opts, err := gitOptions(l.URL, allowLocalCredentials)
+		if err != nil {
+			return nil, "", err
+		}
+
+		gitFS, err := git.New(ctx, l.URL, l.Revision, opts...)
 		if err != nil {
 			return nil, "", err
 		}
@@ -129,3 +224,166 @@ func findLocatorFS(
 		return nil, "", ErrUnsupportedLocation
 	}
 }
+
+// findModuleFS is similar to FindModuleFS, but additionally takes the loader's module store,
+// lockfile and offline mode into account when retrieving the remote modules.
+func (loader *Loader) findModuleFS(currentFS fs.FileSystem, module string) (fs.FileSystem, string, error) {
+	location := parseLocation(module)
+
+	var repository, modulePath string
+
+	switch l := location.(type) {
+	case gitHubLocation:
+		repository, modulePath = "github.com/"+l.Owner+"/"+l.Name, l.Path
+	case gitLabLocation:
+		repository, modulePath = gitLabHost+"/"+l.Project, l.Path
+	case bitbucketLocation:
+		repository, modulePath = bitbucketHost+"/"+l.Workspace+"/"+l.Name, l.Path
+	case gitLocation:
+		repository, modulePath = l.URL, l.Path
+	default:
+		return findLocatorFS(loader.ctx, currentFS, loader.env, loader.allowLocalCredentials, location)
+	}
+
+	if loader.store == nil && loader.lockfile == nil && !loader.offline {
+		return findLocatorFS(loader.ctx, currentFS, loader.env, loader.allowLocalCredentials, location)
+	}
+
+	commit, ok := loader.lockfile.Commit(module)
+	if !ok {
+		if loader.offline {
+			return nil, "", fmt.Errorf("%w: module '%s' is not pinned in %s, run \"cirrus modules update\" "+
+				"to be able to use it in offline mode", modules.ErrNotCached, module, modules.LockfileName)
+		}
+
+		resolvedFS, resolvedCommit, err := resolveCommit(loader.ctx, loader.env, loader.allowLocalCredentials, location)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if loader.lockfile != nil {
+			loader.lockfile.Pin(module, resolvedCommit)
+		}
+
+		return modules.NewFS(loader.store, repository, resolvedCommit, func(ctx context.Context) (fs.FileSystem, error) {
+			return resolvedFS, nil
+		}), modulePath, nil
+	}
+
+	var remoteFunc modules.RemoteFunc
+
+	if !loader.offline {
+		remoteFunc = func(ctx context.Context) (fs.FileSystem, error) {
+			remoteFS, _, err := findLocatorFS(ctx, currentFS, loader.env, loader.allowLocalCredentials, withRevision(location, commit))
+
+			return remoteFS, err
+		}
+	}
+
+	return modules.NewFS(loader.store, repository, commit, remoteFunc), modulePath, nil
+}
+
+// resolveCommit returns the file system of the remote location pinned to the commit
+// that the location's revision currently points to.
+func resolveCommit(
+	ctx context.Context,
+	env map[string]string,
+	allowLocalCredentials bool,
+	location interface{},
+) (fs.FileSystem, string, error) {
+	switch l := location.(type) {
+	case gitHubLocation:
+		token := env["CIRRUS_REPO_CLONE_TOKEN"]
+
+		ghFS, err := github.New(l.Owner, l.Name, l.Revision, token)
+		if err != nil {
+			return nil, "", err
+		}
+
+		commit, err := ghFS.ResolveCommit(ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: failed to resolve revision '%s' of github.com/%s/%s: %v",
+				ErrRetrievalFailed, l.Revision, l.Owner, l.Name, err)
+		}
+
+		pinnedFS, err := github.New(l.Owner, l.Name, commit, token)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return pinnedFS, commit, nil
+	case gitLabLocation:
+		opts := gitLabOptions(env, allowLocalCredentials)
+
+		glFS, err := gitlab.New(l.Project, l.Revision, opts...)
+		if err != nil {
+			return nil, "", err
+		}
+
+		commit, err := glFS.ResolveCommit(ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: failed to resolve revision '%s' of %s/%s: %v",
+				ErrRetrievalFailed, l.Revision, gitLabHost, l.Project, err)
+		}
+
+		pinnedFS, err := gitlab.New(l.Project, commit, opts...)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return pinnedFS, commit, nil
+	case bitbucketLocation:
+		opts := bitbucketOptions(env, allowLocalCredentials)
+
+		bbFS, err := bitbucket.New(l.Workspace, l.Name, l.Revision, opts...)
+		if err != nil {
+			return nil, "", err
+		}
+
+		commit, err := bbFS.ResolveCommit(ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: failed to resolve revision '%s' of %s/%s/%s: %v",
+				ErrRetrievalFailed, l.Revision, bitbucketHost, l.Workspace, l.Name, err)
+		}
+
+		pinnedFS, err := bitbucket.New(l.Workspace, l.Name, commit, opts...)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return pinnedFS, commit, nil
+	case gitLocation:
+		opts, err := gitOptions(l.URL, allowLocalCredentials)
+		if err != nil {
+			return nil, "", err
+		}
+
+		gitFS, err := git.New(ctx, l.URL, l.Revision, opts...)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return gitFS, gitFS.Commit(), nil
+	default:
+		return nil, "", ErrUnsupportedLocation
+	}
+}
+
+func withRevision(location interface{}, revision string) interface{} {
+	switch l := location.(type) {
+	case gitHubLocation:
+		l.Revision = revision
+		return l
+	case gitLabLocation:
+		l.Revision = revision
+		return l
+	case bitbucketLocation:
+		l.Revision = revision
+		return l
+	case gitLocation:
+		l.Revision = revision
+		return l
+	default:
+		return location
+	}
+}
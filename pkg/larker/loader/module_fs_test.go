@@ -45,6 +45,42 @@ func TestParse(t *testing.T) {
 			Path:     "some.star",
 			Revision: "main",
 		}},
+		{"parses .git hint with revision", "git.example.com/some-repo.git/dir/some.star@v1.0.0", gitLocation{
+			URL:      "https://git.example.com/some-repo.git",
+			Path:     "dir/some.star",
+			Revision: "v1.0.0",
+		}},
+		// SSH
+		{"parses SCP-like SSH URL", "git@gitlab.com:some-org/some-repo.git/some.star@v1.0.0", gitLocation{
+			URL:      "git@gitlab.com:some-org/some-repo.git",
+			Path:     "some.star",
+			Revision: "v1.0.0",
+		}},
+		{"parses SSH URL", "ssh://git@example.com:2222/some-org/some-repo.git", gitLocation{
+			URL:      "ssh://git@example.com:2222/some-org/some-repo.git",
+			Path:     "lib.star",
+			Revision: "main",
+		}},
+		// GitLab
+		{"parses GitLab", "gitlab.com/some-org/some-repo/dir/some.star@da39a3ee", gitLabLocation{
+			Project:  "some-org/some-repo",
+			Path:     "dir/some.star",
+			Revision: "da39a3ee",
+		}},
+		{"defaults to lib.star on GitLab", "gitlab.com/some-org/some-repo", gitLabLocation{
+			Project:  "some-org/some-repo",
+			Path:     "lib.star",
+			Revision: "main",
+		}},
+		// Bitbucket
+		{"parses Bitbucket", "bitbucket.org/some-workspace/some-repo/dir/some.star@da39a3ee", bitbucketLocation{
+			Workspace: "some-workspace",
+			Name:      "some-repo",
+			Path:      "dir/some.star",
+			Revision:  "da39a3ee",
+		}},
+		// Local
+		{"parses local", "dir/some.star", localLocation{Path: "dir/some.star"}},
 	}
 
 	for _, testCase := range testCases {
@@ -96,7 +132,8 @@ func TestRetrieve(t *testing.T) {
 	for _, testCase := range testCases {
 		testCase := testCase
 		t.Run(testCase.Name, func(t *testing.T) {
-			filesystem, path, err := findLocatorFS(context.Background(), dummy.New(), make(map[string]string), testCase.Locator)
+			filesystem, path, err := findLocatorFS(context.Background(), dummy.New(), make(map[string]string), false,
+				testCase.Locator)
 			if err != nil {
 				t.Fatal(err)
 			}
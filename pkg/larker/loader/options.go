@@ -0,0 +1,73 @@
+package loader
+
+import (
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/builtin"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+)
+
+type Option func(*Loader)
+
+// WithModuleStore enables the on-disk caching of the remote modules.
+func WithModuleStore(store *modules.Store) Option {
+	return func(loader *Loader) {
+		loader.store = store
+	}
+}
+
+// WithLockfile pins the remote modules to the commits recorded in the lockfile.
+//
+// The modules missing from the lockfile are resolved and pinned in it.
+func WithLockfile(lockfile *modules.Lockfile) Option {
+	return func(loader *Loader) {
+		loader.lockfile = lockfile
+	}
+}
+
+// WithOfflineMode disables the network access, so that only
+// the pinned modules already present in the module store can be loaded.
+func WithOfflineMode() Option {
+	return func(loader *Loader) {
+		loader.offline = true
+	}
+}
+
+// WithLocalCredentials allows the remote modules to be authenticated using the user's own credentials,
+// that is the .netrc file and the SSH agent.
+//
+// Only enable this when running on behalf of the user, as the modules' hosts are chosen by the configuration.
+func WithLocalCredentials() Option {
+	return func(loader *Loader) {
+		loader.allowLocalCredentials = true
+	}
+}
+
+// WithMocks allows the env, fs, git and http members of the cirrus module to be replaced at runtime.
+func WithMocks(mocks *builtin.Mocks) Option {
+	return func(loader *Loader) {
+		loader.mocks = mocks
+	}
+}
+
+// WithTracer lets the tracer (e.g. a debugger) observe the execution of the loaded modules.
+func WithTracer(tracer instrument.Tracer) Option {
+	return func(loader *Loader) {
+		loader.tracers = append(loader.tracers, tracer)
+	}
+}
+
+// WithMaxLoadedModules limits the number of modules (excluding the cirrus builtins) that can be loaded.
+func WithMaxLoadedModules(maxLoadedModules int) Option {
+	return func(loader *Loader) {
+		loader.maxLoadedModules = maxLoadedModules
+	}
+}
+
+// WithMaxModuleSourceBytes limits the total size of the loaded modules' source code.
+//
+// Note that the data read by the modules at runtime (e.g. using the fs and http modules) is not counted.
+func WithMaxModuleSourceBytes(maxModuleSourceBytes int64) Option {
+	return func(loader *Loader) {
+		loader.maxModuleSourceBytes = maxModuleSourceBytes
+	}
+}
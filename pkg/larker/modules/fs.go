@@ -0,0 +1,125 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"path"
+)
+
+// RemoteFunc lazily retrieves the file system of the repository at the pinned commit.
+type RemoteFunc func(ctx context.Context) (fs.FileSystem, error)
+
+// FS serves the files of a remote repository at a specific commit from the Store,
+// only falling back to the remote file system for the files that are not cached yet.
+type FS struct {
+	store      *Store
+	repository string
+	commit     string
+
+	remoteFunc RemoteFunc
+	remote     fs.FileSystem
+}
+
+// NewFS creates a file system backed by the store (which can be nil to disable caching).
+//
+// A nil remoteFunc means that the network access is not allowed, in which case
+// only the cached files will be available.
+func NewFS(store *Store, repository, commit string, remoteFunc RemoteFunc) *FS {
+	return &FS{
+		store:      store,
+		repository: repository,
+		commit:     commit,
+		remoteFunc: remoteFunc,
+	}
+}
+
+func (cfs *FS) Repository() string {
+	return cfs.repository
+}
+
+func (cfs *FS) Commit() string {
+	return cfs.commit
+}
+
+func (cfs *FS) Stat(ctx context.Context, path string) (*fs.FileInfo, error) {
+	if cfs.store != nil {
+		if fileBytes, err := cfs.store.Get(cfs.repository, cfs.commit, cleanPath(path)); err == nil {
+			return &fs.FileInfo{IsDir: false, Size: int64(len(fileBytes))}, nil
+		}
+	}
+
+	remote, err := cfs.getRemote(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.Stat(ctx, path)
+}
+
+func (cfs *FS) Get(ctx context.Context, path string) ([]byte, error) {
+	if cfs.store != nil {
+		content, err := cfs.store.Get(cfs.repository, cfs.commit, cleanPath(path))
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, ErrNotCached) {
+			return nil, err
+		}
+	}
+
+	remote, err := cfs.getRemote(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := remote.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfs.store != nil {
+		if err := cfs.store.Put(cfs.repository, cfs.commit, cleanPath(path), content); err != nil {
+			return nil, err
+		}
+	}
+
+	return content, nil
+}
+
+func (cfs *FS) ReadDir(ctx context.Context, path string) ([]string, error) {
+	remote, err := cfs.getRemote(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.ReadDir(ctx, path)
+}
+
+func (cfs *FS) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (cfs *FS) getRemote(ctx context.Context, path string) (fs.FileSystem, error) {
+	if cfs.remote != nil {
+		return cfs.remote, nil
+	}
+
+	if cfs.remoteFunc == nil {
+		return nil, fmt.Errorf("%w: %s@%s/%s (network access is disabled in offline mode)",
+			ErrNotCached, cfs.repository, cfs.commit, cleanPath(path))
+	}
+
+	remote, err := cfs.remoteFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfs.remote = remote
+
+	return remote, nil
+}
+
+func cleanPath(p string) string {
+	return path.Clean("/" + p)[1:]
+}
@@ -0,0 +1,40 @@
+package modules_test
+
+import (
+	"context"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/memory"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFSPopulatesStore(t *testing.T) {
+	ctx := context.Background()
+	store := modules.NewStore(t.TempDir())
+
+	var remoteCalls int
+
+	remoteFunc := func(ctx context.Context) (fs.FileSystem, error) {
+		remoteCalls++
+
+		return memory.New(map[string][]byte{"lib.star": []byte("x = 1\n")})
+	}
+
+	content, err := modules.NewFS(store, "github.com/some-org/some-repo", "a1b2c3", remoteFunc).
+		Get(ctx, "lib.star")
+	require.NoError(t, err)
+	assert.Equal(t, "x = 1\n", string(content))
+	assert.Equal(t, 1, remoteCalls)
+
+	// The second retrieval is served from the store, even without the network access
+	offlineFS := modules.NewFS(store, "github.com/some-org/some-repo", "a1b2c3", nil)
+
+	content, err = offlineFS.Get(ctx, "./lib.star")
+	require.NoError(t, err)
+	assert.Equal(t, "x = 1\n", string(content))
+
+	_, err = offlineFS.Get(ctx, "other.star")
+	assert.ErrorIs(t, err, modules.ErrNotCached)
+}
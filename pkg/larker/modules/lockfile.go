@@ -0,0 +1,76 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+)
+
+// LockfileName is the name of the file next to the .cirrus.star that pins the remote modules.
+const LockfileName = ".cirrus.lock"
+
+var ErrInvalidLockfile = errors.New("invalid lockfile")
+
+const lockfileHeader = "# This file is generated by \"cirrus modules update\", do not edit it manually.\n"
+
+// Lockfile records the commits that the revisions of the load() targets were resolved to.
+type Lockfile struct {
+	Modules map[string]*LockedModule `yaml:"modules"`
+}
+
+type LockedModule struct {
+	Commit string `yaml:"commit"`
+}
+
+func NewLockfile() *Lockfile {
+	return &Lockfile{
+		Modules: map[string]*LockedModule{},
+	}
+}
+
+func ParseLockfile(data []byte) (*Lockfile, error) {
+	lockfile := NewLockfile()
+
+	if err := yaml.Unmarshal(data, lockfile); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLockfile, err)
+	}
+
+	if lockfile.Modules == nil {
+		lockfile.Modules = map[string]*LockedModule{}
+	}
+
+	for module, lockedModule := range lockfile.Modules {
+		if lockedModule == nil || lockedModule.Commit == "" {
+			return nil, fmt.Errorf("%w: module %q has no commit", ErrInvalidLockfile, module)
+		}
+	}
+
+	return lockfile, nil
+}
+
+func (lockfile *Lockfile) Marshal() ([]byte, error) {
+	body, err := yaml.Marshal(lockfile)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(lockfileHeader), body...), nil
+}
+
+// Commit returns the commit the module is pinned to, if any.
+func (lockfile *Lockfile) Commit(module string) (string, bool) {
+	if lockfile == nil {
+		return "", false
+	}
+
+	lockedModule, ok := lockfile.Modules[module]
+	if !ok {
+		return "", false
+	}
+
+	return lockedModule.Commit, true
+}
+
+func (lockfile *Lockfile) Pin(module string, commit string) {
+	lockfile.Modules[module] = &LockedModule{Commit: commit}
+}
@@ -0,0 +1,41 @@
+package modules_test
+
+import (
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLockfileRoundTrip(t *testing.T) {
+	lockfile := modules.NewLockfile()
+	lockfile.Pin("github.com/some-org/some-repo@main", "a1b2c3")
+
+	lockfileBytes, err := lockfile.Marshal()
+	require.NoError(t, err)
+
+	parsedLockfile, err := modules.ParseLockfile(lockfileBytes)
+	require.NoError(t, err)
+
+	commit, ok := parsedLockfile.Commit("github.com/some-org/some-repo@main")
+	require.True(t, ok)
+	assert.Equal(t, "a1b2c3", commit)
+
+	_, ok = parsedLockfile.Commit("github.com/some-org/other-repo")
+	assert.False(t, ok)
+}
+
+func TestLockfileInvalid(t *testing.T) {
+	_, err := modules.ParseLockfile([]byte("modules:\n  github.com/some-org/some-repo: {}\n"))
+	assert.ErrorIs(t, err, modules.ErrInvalidLockfile)
+
+	_, err = modules.ParseLockfile([]byte("modules: 42\n"))
+	assert.ErrorIs(t, err, modules.ErrInvalidLockfile)
+}
+
+func TestNilLockfileHasNoCommits(t *testing.T) {
+	var lockfile *modules.Lockfile
+
+	_, ok := lockfile.Commit("github.com/some-org/some-repo")
+	assert.False(t, ok)
+}
@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNotCached = errors.New("module is not cached")
+
+// Store is an on-disk content-addressed cache of the remote module files.
+//
+// The file contents are stored under their SHA-256 digest in the "blobs" directory,
+// and the "index" directory maps each repository, commit and path triple to such digest,
+// so that the identical files from different commits are only stored once.
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStoreDir returns the location of the module cache in the user's cache directory.
+func DefaultStoreDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "cirrus", "modules"), nil
+}
+
+func (store *Store) Dir() string {
+	return store.dir
+}
+
+func (store *Store) Get(repository, commit, path string) ([]byte, error) {
+	digest, err := ioutil.ReadFile(store.indexPath(repository, commit, path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s@%s/%s", ErrNotCached, repository, commit, path)
+		}
+
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(store.blobPath(strings.TrimSpace(string(digest))))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s@%s/%s", ErrNotCached, repository, commit, path)
+		}
+
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (store *Store) Put(repository, commit, path string, content []byte) error {
+	digest := sha256.Sum256(content)
+	hexDigest := hex.EncodeToString(digest[:])
+
+	if err := writeFileAtomically(store.blobPath(hexDigest), content); err != nil {
+		return err
+	}
+
+	return writeFileAtomically(store.indexPath(repository, commit, path), []byte(hexDigest))
+}
+
+func (store *Store) blobPath(hexDigest string) string {
+	return filepath.Join(store.dir, "blobs", hexDigest)
+}
+
+func (store *Store) indexPath(repository, commit, path string) string {
+	digest := sha256.Sum256([]byte(repository + "\x00" + commit + "\x00" + path))
+
+	return filepath.Join(store.dir, "index", hex.EncodeToString(digest[:]))
+}
+
+// writeFileAtomically ensures that the concurrent CLI invocations never observe a partially written file.
+func writeFileAtomically(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,39 @@
+package modules_test
+
+import (
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := modules.NewStore(t.TempDir())
+
+	_, err := store.Get("github.com/some-org/some-repo", "a1b2c3", "lib.star")
+	require.ErrorIs(t, err, modules.ErrNotCached)
+
+	require.NoError(t, store.Put("github.com/some-org/some-repo", "a1b2c3", "lib.star", []byte("x = 1\n")))
+
+	content, err := store.Get("github.com/some-org/some-repo", "a1b2c3", "lib.star")
+	require.NoError(t, err)
+	assert.Equal(t, "x = 1\n", string(content))
+
+	// Other commits are distinct cache entries
+	_, err = store.Get("github.com/some-org/some-repo", "d4e5f6", "lib.star")
+	require.ErrorIs(t, err, modules.ErrNotCached)
+}
+
+// TestStoreDeduplicatesContents ensures that the identical files are stored only once.
+func TestStoreDeduplicatesContents(t *testing.T) {
+	store := modules.NewStore(t.TempDir())
+
+	require.NoError(t, store.Put("github.com/some-org/some-repo", "a1b2c3", "lib.star", []byte("x = 1\n")))
+	require.NoError(t, store.Put("github.com/some-org/some-repo", "d4e5f6", "lib.star", []byte("x = 1\n")))
+
+	blobs, err := ioutil.ReadDir(filepath.Join(store.Dir(), "blobs"))
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1)
+}
@@ -1,7 +1,13 @@
 package larker
 
 import (
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/builtin"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/coverage"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/debugger"
 	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/instrument"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/modules"
 )
 
 type Option func(*Larker)
@@ -29,3 +35,84 @@ func WithTestMode() Option {
 		e.isTest = true
 	}
 }
+
+func WithModuleStore(store *modules.Store) Option {
+	return func(e *Larker) {
+		e.loaderOpts = append(e.loaderOpts, loader.WithModuleStore(store))
+	}
+}
+
+func WithLockfile(lockfile *modules.Lockfile) Option {
+	return func(e *Larker) {
+		e.loaderOpts = append(e.loaderOpts, loader.WithLockfile(lockfile))
+	}
+}
+
+func WithOfflineMode() Option {
+	return func(e *Larker) {
+		e.loaderOpts = append(e.loaderOpts, loader.WithOfflineMode())
+	}
+}
+
+// WithLocalCredentials authenticates the remote modules using the user's .netrc file and the SSH agent.
+func WithLocalCredentials() Option {
+	return func(e *Larker) {
+		e.loaderOpts = append(e.loaderOpts, loader.WithLocalCredentials())
+	}
+}
+
+// WithMocks replaces the env, fs, git and http members of the cirrus module with the mocks (if set),
+// for example, to serve the HTTP requests with the canned responses in tests.
+func WithMocks(mocks *builtin.Mocks) Option {
+	return func(e *Larker) {
+		e.mocks = mocks
+	}
+}
+
+// WithDebugger lets the debugger pause the execution of the main() and hooks,
+// as well as the modules they load.
+func WithDebugger(debugger *debugger.Debugger) Option {
+	return withTracer(debugger)
+}
+
+// WithCoverage collects the line coverage of the main() and hooks, as well as the local modules they load.
+func WithCoverage(coverage *coverage.Coverage) Option {
+	return withTracer(coverage)
+}
+
+func withTracer(tracer instrument.Tracer) Option {
+	return func(e *Larker) {
+		e.tracers = append(e.tracers, tracer)
+		e.loaderOpts = append(e.loaderOpts, loader.WithTracer(tracer))
+	}
+}
+
+// WithMaxExecutionSteps stops the evaluation once it executes more than the specified number of
+// Starlark computation steps, e.g. to prevent the runaway loops from hogging the CPU.
+func WithMaxExecutionSteps(maxExecutionSteps uint64) Option {
+	return func(e *Larker) {
+		e.maxExecutionSteps = maxExecutionSteps
+	}
+}
+
+// WithMaxOutputBytes stops the evaluation once the output of the print() calls exceeds the specified size.
+func WithMaxOutputBytes(maxOutputBytes int) Option {
+	return func(e *Larker) {
+		e.maxOutputBytes = maxOutputBytes
+	}
+}
+
+// WithMaxLoadedModules stops the evaluation once it loads more than the specified number of modules.
+func WithMaxLoadedModules(maxLoadedModules int) Option {
+	return func(e *Larker) {
+		e.loaderOpts = append(e.loaderOpts, loader.WithMaxLoadedModules(maxLoadedModules))
+	}
+}
+
+// WithMaxModuleSourceBytes stops the evaluation once the total size of the loaded modules' source code
+// exceeds the specified size. The data read using the fs and http modules is not counted.
+func WithMaxModuleSourceBytes(maxModuleSourceBytes int64) Option {
+	return func(e *Larker) {
+		e.loaderOpts = append(e.loaderOpts, loader.WithMaxModuleSourceBytes(maxModuleSourceBytes))
+	}
+}
@@ -0,0 +1,174 @@
+package larker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/builtin"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/loader"
+	"go.starlark.net/starlark"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TestFunctionPrefix is the prefix of the functions in test_*.star files that are ran as tests.
+const TestFunctionPrefix = "test_"
+
+type TestResult struct {
+	Name string
+	// ErrorMessage is empty if the test has passed.
+	ErrorMessage string
+	// AssertionFailed distinguishes the failed assertions from the other errors.
+	AssertionFailed bool
+	OutputLogs      []byte
+	DurationNanos   int64
+}
+
+func (result *TestResult) Passed() bool {
+	return result.ErrorMessage == ""
+}
+
+// Test runs the test_* functions defined in the source, each in a fresh environment
+// where the env, fs, git and http members of the cirrus module can be mocked with the mock module.
+func (larker *Larker) Test(ctx context.Context, filename string, source string) ([]*TestResult, error) {
+	// Discover the tests
+	thread := larker.newTestThread(ctx, &bytes.Buffer{}, builtin.NewMocks())
+
+	var globals starlark.StringDict
+
+	if err := runThread(ctx, thread, func() (err error) {
+		globals, err = starlark.ExecFile(thread, filename, source, nil)
+
+		return err
+	}); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, fmt.Errorf("%w: %v", ErrLoadFailed, err)
+	}
+
+	var testFuncs []*starlark.Function
+
+	for name, value := range globals {
+		testFunc, ok := value.(*starlark.Function)
+		if ok && strings.HasPrefix(name, TestFunctionPrefix) {
+			testFuncs = append(testFuncs, testFunc)
+		}
+	}
+
+	// Run the tests in the order of their definition
+	sort.Slice(testFuncs, func(i, j int) bool {
+		return testFuncs[i].Position().Line < testFuncs[j].Position().Line
+	})
+
+	var results []*TestResult
+
+	for _, testFunc := range testFuncs {
+		result := larker.runTest(ctx, filename, source, testFunc.Name())
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (larker *Larker) runTest(ctx context.Context, filename string, source string, name string) *TestResult {
+	outputLogsBuffer := &bytes.Buffer{}
+
+	// Tests should never reach the network
+	mocks := builtin.NewMocks()
+	if larker.mocks != nil {
+		mocks = larker.mocks.Fork()
+	}
+	mocks.RequireHTTPMocks()
+
+	thread := larker.newTestThread(ctx, outputLogsBuffer, mocks)
+
+	testStartTime := time.Now()
+
+	err := runThread(ctx, thread, func() error {
+		// Re-execute the file, so that the modules it loads
+		// only pick up the mocks set by this test
+		globals, err := starlark.ExecFile(thread, filename, source, nil)
+		if err != nil {
+			return err
+		}
+
+		testFunc, ok := globals[name].(*starlark.Function)
+		if !ok {
+			return fmt.Errorf("%w: %s is not a function", ErrNotFound, name)
+		}
+
+		if testFunc.NumParams() != 0 {
+			return fmt.Errorf("%w: %s() should take no arguments", ErrSanity, name)
+		}
+
+		_, err = starlark.Call(thread, testFunc, nil, nil)
+
+		return err
+	})
+
+	result := &TestResult{
+		Name:          name,
+		DurationNanos: time.Since(testStartTime).Nanoseconds(),
+	}
+
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		result.AssertionFailed = errors.Is(err, builtin.ErrAssertionFailed)
+
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			result.ErrorMessage = evalErr.Msg
+
+			if outputLogsBuffer.Len() != 0 && !bytes.HasSuffix(outputLogsBuffer.Bytes(), []byte("\n")) {
+				outputLogsBuffer.WriteString("\n")
+			}
+			outputLogsBuffer.WriteString(evalErr.Backtrace())
+		}
+	}
+
+	result.OutputLogs = outputLogsBuffer.Bytes()
+
+	return result
+}
+
+func (larker *Larker) newTestThread(
+	ctx context.Context,
+	outputLogsBuffer *bytes.Buffer,
+	mocks *builtin.Mocks,
+) *starlark.Thread {
+	loaderOpts := append([]loader.Option{loader.WithMocks(mocks)}, larker.loaderOpts...)
+	moduleLoader := loader.NewLoader(ctx, larker.fs, larker.env, larker.affectedFiles, true, loaderOpts...)
+
+	return &starlark.Thread{
+		Load: moduleLoader.LoadFunc(larker.fs),
+		Print: func(thread *starlark.Thread, msg string) {
+			_, _ = fmt.Fprintln(outputLogsBuffer, msg)
+		},
+	}
+}
+
+// runThread runs f in a separate goroutine and cancels the thread once the context is done.
+func runThread(ctx context.Context, thread *starlark.Thread, f func() error) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- f()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		thread.Cancel(ctx.Err().Error())
+
+		return ctx.Err()
+	}
+}
@@ -2,13 +2,16 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/boolevator"
 	"github.com/cirruslabs/go-java-glob"
+	"strconv"
 )
 
 var (
 	ErrBfuncNoArguments         = errors.New("no arguments provided")
 	ErrBfuncArgumentIsNotString = errors.New("argument should be a string")
+	ErrBfuncInvalidThreshold    = errors.New("threshold should be a positive integer")
 )
 
 func (p *Parser) bfuncChangesInclude() boolevator.Function {
@@ -55,6 +58,41 @@ func (p *Parser) bfuncChangesIncludeOnly() boolevator.Function {
 	}
 }
 
+// bfuncChangesIncludeAny is similar to changesInclude(), but optionally accepts a threshold as the first argument,
+// e.g. changesIncludeAny(3, '**.go') evaluates to true only when at least 3 affected files match the patterns.
+func (p *Parser) bfuncChangesIncludeAny() boolevator.Function {
+	return func(arguments ...interface{}) interface{} {
+		if len(arguments) == 0 {
+			return ErrBfuncNoArguments
+		}
+
+		rawPatterns, err := bfuncArgsToStrings(arguments)
+		if err != nil {
+			return err
+		}
+
+		threshold := 1
+
+		if len(rawPatterns) > 1 {
+			if parsedThreshold, err := strconv.Atoi(rawPatterns[0]); err == nil {
+				if parsedThreshold < 1 {
+					return fmt.Errorf("%w, got %d", ErrBfuncInvalidThreshold, parsedThreshold)
+				}
+
+				threshold = parsedThreshold
+				rawPatterns = rawPatterns[1:]
+			}
+		}
+
+		matchedFiles, err := CountMatchingAffectedFiles(p.affectedFiles, rawPatterns)
+		if err != nil {
+			return err
+		}
+
+		return strconv.FormatBool(matchedFiles >= threshold)
+	}
+}
+
 func bfuncArgsToStrings(arguments []interface{}) ([]string, error) {
 	var result []string
 
@@ -63,3 +63,36 @@ negative_match_task:
 
 	assert.Len(t, result.Tasks, 1)
 }
+
+func TestBfuncChangesIncludeAny(t *testing.T) {
+	affectedFiles := []string{"main.go", "go.mod", "dir/file.go", "README.md"}
+
+	config := `container:
+  image: debian:latest
+
+any_task:
+  only_if: "changesIncludeAny('**.md')"
+  script: true
+
+threshold_reached_task:
+  only_if: "changesIncludeAny(2, '**.go')"
+  script: true
+
+threshold_not_reached_task:
+  only_if: "changesIncludeAny(3, '**.go')"
+  script: true
+`
+
+	p := parser.New(parser.WithAffectedFiles(affectedFiles))
+	result, err := p.Parse(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, task := range result.Tasks {
+		names = append(names, task.Name)
+	}
+
+	assert.Equal(t, []string{"any", "threshold_reached"}, names)
+}
@@ -31,6 +31,11 @@ func New(opts ...Option) *Boolevator {
 	return boolevator
 }
 
+func parseNumber(_ context.Context, parser *gval.Parser) (gval.Evaluable, error) {
+	// All the values are strings, numeric operators will parse them as needed
+	return parser.Const(parser.TokenText()), nil
+}
+
 func parseString(_ context.Context, parser *gval.Parser) (gval.Evaluable, error) {
 	unquoted := trimAllQuotes(parser.TokenText())
 
@@ -52,6 +57,26 @@ func trimAllQuotes(text string) string {
 }
 
 func (boolevator *Boolevator) Eval(expr string, env map[string]string) (bool, error) {
+	return boolevator.eval(expr, env, nil)
+}
+
+// EvalWithVariables is like Eval, but additionally returns the variables referenced
+// in the expression along with the (expanded) values that were used during the evaluation.
+func (boolevator *Boolevator) EvalWithVariables(expr string, env map[string]string) (bool, map[string]string, error) {
+	variables := make(map[string]string)
+
+	result, err := boolevator.eval(expr, env, func(name string, value string) {
+		variables[name] = value
+	})
+
+	return result, variables, err
+}
+
+func (boolevator *Boolevator) eval(
+	expr string,
+	env map[string]string,
+	onVariable func(name string, value string),
+) (bool, error) {
 	// Ensure that we keep the env as is
 	localEnv := make(map[string]string)
 	for key, value := range env {
@@ -76,6 +101,10 @@ func (boolevator *Boolevator) Eval(expr string, env map[string]string) (bool, er
 		// Lookup variable
 		expandedVariable := localEnv[variableName]
 
+		if onVariable != nil {
+			onVariable(variableName, expandedVariable)
+		}
+
 		return parser.Const(expandedVariable), nil
 	}
 
@@ -89,6 +118,8 @@ func (boolevator *Boolevator) Eval(expr string, env map[string]string) (bool, er
 		// Prefixes
 		gval.PrefixExtension(scanner.Char, parseString),
 		gval.PrefixExtension(scanner.String, parseString),
+		gval.PrefixExtension(scanner.Int, parseNumber),
+		gval.PrefixExtension(scanner.Float, parseNumber),
 		gval.PrefixExtension('$', expandVariable),
 		// Operators
 		gval.PrefixOperator("!", opNot),
@@ -99,32 +130,64 @@ func (boolevator *Boolevator) Eval(expr string, env map[string]string) (bool, er
 		gval.InfixOperator("!=", opNotEquals),
 		gval.InfixOperator("=~", opRegexEquals),
 		gval.InfixOperator("!=~", opRegexNotEquals),
+		gval.InfixOperator("=*", opGlobEquals),
+		gval.InfixOperator("!=*", opGlobNotEquals),
+		gval.InfixOperator("<", opLess),
+		gval.InfixOperator("<=", opLessOrEquals),
+		gval.InfixOperator(">", opGreater),
+		gval.InfixOperator(">=", opGreaterOrEquals),
 		// Operator precedence
 		//
 		// Identical to https://introcs.cs.princeton.edu/java/11precedence/
-		// except for the "in" and regex operators which have the same precedence
-		// as their non-regex counterparts.
+		// except for the "in", regex and glob operators which have the same precedence
+		// as their non-regex and non-glob counterparts.
 		gval.Precedence("!", 14),
 		gval.Precedence("in", 10),
+		gval.Precedence("<", 9),
+		gval.Precedence("<=", 9),
+		gval.Precedence(">", 9),
+		gval.Precedence(">=", 9),
 		gval.Precedence("==", 8),
 		gval.Precedence("!=", 8),
 		gval.Precedence("=~", 8),
 		gval.Precedence("!=~", 8),
+		gval.Precedence("=*", 8),
+		gval.Precedence("!=*", 8),
 		gval.Precedence("&&", 4),
 		gval.Precedence("||", 3),
 	}
 
-	// Functions
+	// Functions, the user-provided ones take precedence over the built-in ones
+	functions := builtinFunctions()
 	for name, function := range boolevator.functions {
+		functions[name] = function
+	}
+	for name, function := range functions {
 		languageBases = append(languageBases, gval.Function(name, function))
 	}
 
-	result, err := gval.NewLanguage(languageBases...).Evaluate(expr, nil)
+	evaluable, err := gval.NewLanguage(languageBases...).NewEvaluable(expr)
+	if err != nil {
+		return false, newSyntaxError(expr, err)
+	}
+
+	result, err := evaluable(context.Background(), nil)
 	if err != nil {
 		return false, fmt.Errorf("%w: %v", ErrInternal, err)
 	}
 
-	booleanValue, err := strconv.ParseBool(result.(string))
+	// Functions report errors by returning them, which only get
+	// propagated by the operators, so check the top-level result too
+	if err := handleError(result); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+
+	stringValue, ok := result.(string)
+	if !ok {
+		return false, fmt.Errorf("%w: expression evaluated to %v, which is not a string", ErrInternal, result)
+	}
+
+	booleanValue, err := strconv.ParseBool(stringValue)
 	if err != nil {
 		return false, fmt.Errorf("%w: %v", ErrInternal, err)
 	}
@@ -140,3 +140,91 @@ func TestComplexTag(t *testing.T) {
 
 	assert.True(t, evalHelper(t, "$CIRRUS_TAG =~ 'v\\d+(\\.\\d+){2}(-.*)?'", env))
 }
+
+func TestEvalWithVariables(t *testing.T) {
+	env := map[string]string{
+		"CIRRUS_BRANCH": "feature-$NUMBER",
+		"NUMBER":        "42",
+		"UNUSED":        "unused",
+	}
+
+	result, variables, err := boolevator.New().EvalWithVariables("$CIRRUS_BRANCH == 'main' || ${CIRRUS_TAG} != ''", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, result)
+	assert.Equal(t, map[string]string{
+		"CIRRUS_BRANCH": "feature-42",
+		"CIRRUS_TAG":    "",
+	}, variables)
+}
+
+func TestGlob(t *testing.T) {
+	assert.True(t, evalHelper(t, "'release-*' =* 'release-2018.1'", nil))
+	assert.True(t, evalHelper(t, "'release-2018.1' =* 'release-*'", nil))
+	assert.False(t, evalHelper(t, "'release-*' !=* 'release-2018.1'", nil))
+	assert.True(t, evalHelper(t, "'release-*' !=* 'foo'", nil))
+	assert.True(t, evalHelper(t, "$CIRRUS_BRANCH =* 'feature/**'",
+		map[string]string{"CIRRUS_BRANCH": "feature/nested/branch"}))
+	assert.False(t, evalHelper(t, "$CIRRUS_BRANCH =* 'feature/*'",
+		map[string]string{"CIRRUS_BRANCH": "feature/nested/branch"}))
+}
+
+func TestStringFunctions(t *testing.T) {
+	env := map[string]string{"CIRRUS_BRANCH": "release/1.2"}
+
+	assert.True(t, evalHelper(t, "startsWith($CIRRUS_BRANCH, 'release/')", env))
+	assert.False(t, evalHelper(t, "startsWith($CIRRUS_BRANCH, 'feature/')", env))
+	assert.True(t, evalHelper(t, "endsWith($CIRRUS_BRANCH, '.2')", env))
+	assert.False(t, evalHelper(t, "endsWith($CIRRUS_BRANCH, '.3')", env))
+	assert.True(t, evalHelper(t, "contains($CIRRUS_BRANCH, 'se/1')", env))
+	assert.False(t, evalHelper(t, "contains($CIRRUS_BRANCH, 'main')", env))
+	assert.True(t, evalHelper(t, "!contains($CIRRUS_BRANCH, 'main') && startsWith($CIRRUS_BRANCH, 'rel')", env))
+}
+
+func TestStringFunctionsInvalidArguments(t *testing.T) {
+	_, err := boolevator.New().Eval("startsWith('foo')", nil)
+	assert.Error(t, err)
+}
+
+func TestNumericComparison(t *testing.T) {
+	env := map[string]string{"CIRRUS_BUILD_ID": "1234"}
+
+	assert.True(t, evalHelper(t, "$CIRRUS_BUILD_ID >= 1234", env))
+	assert.False(t, evalHelper(t, "$CIRRUS_BUILD_ID > 1234", env))
+	assert.True(t, evalHelper(t, "$CIRRUS_BUILD_ID < 10000", env))
+	assert.False(t, evalHelper(t, "$CIRRUS_BUILD_ID <= 1000", env))
+	assert.True(t, evalHelper(t, "'9' < '10'", nil))
+	assert.True(t, evalHelper(t, "1.5 < 2 && $CIRRUS_BUILD_ID > 1000", env))
+}
+
+func TestNumericComparisonNotANumber(t *testing.T) {
+	_, err := boolevator.New().Eval("$CIRRUS_BRANCH < 10", map[string]string{"CIRRUS_BRANCH": "main"})
+	assert.ErrorIs(t, err, boolevator.ErrInternal)
+	assert.Contains(t, err.Error(), boolevator.ErrNotANumber.Error())
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	var testCases = []struct {
+		Expression string
+		Column     int
+	}{
+		{"$CIRRUS_BRANCH == ", 19},
+		{"$CIRRUS_BRANCH === 'main'", 16},
+		{"$A == 'b' &&& $C == 'd'", 11},
+		{"($A == 'b'", 11},
+		{"'a' 'b'", 5},
+	}
+
+	for _, testCase := range testCases {
+		_, err := boolevator.New().Eval(testCase.Expression, nil)
+
+		var syntaxError *boolevator.SyntaxError
+		if assert.ErrorAs(t, err, &syntaxError, testCase.Expression) {
+			assert.Equal(t, 1, syntaxError.Line, testCase.Expression)
+			assert.Equal(t, testCase.Column, syntaxError.Column, testCase.Expression)
+			assert.ErrorIs(t, err, boolevator.ErrSyntax)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package boolevator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidArguments = errors.New("invalid function arguments")
+
+func builtinFunctions() map[string]Function {
+	return map[string]Function{
+		"startsWith": stringPredicate("startsWith", strings.HasPrefix),
+		"endsWith":   stringPredicate("endsWith", strings.HasSuffix),
+		"contains":   stringPredicate("contains", strings.Contains),
+	}
+}
+
+// stringPredicate turns a function like strings.HasPrefix into a two-argument Function.
+func stringPredicate(name string, predicate func(s string, substr string) bool) Function {
+	return func(arguments ...interface{}) interface{} {
+		if err := handleError(arguments...); err != nil {
+			return err
+		}
+
+		//nolint:gomnd
+		if len(arguments) != 2 {
+			return fmt.Errorf("%w: %s() expects 2 arguments, got %d", ErrInvalidArguments, name, len(arguments))
+		}
+
+		s, ok := arguments[0].(string)
+		if !ok {
+			return fmt.Errorf("%w: %s() expects string arguments", ErrInvalidArguments, name)
+		}
+
+		substr, ok := arguments[1].(string)
+		if !ok {
+			return fmt.Errorf("%w: %s() expects string arguments", ErrInvalidArguments, name)
+		}
+
+		return strconv.FormatBool(predicate(s, substr))
+	}
+}
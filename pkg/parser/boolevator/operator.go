@@ -2,11 +2,16 @@ package boolevator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/cirruslabs/go-java-glob"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+var ErrNotANumber = errors.New("not a number")
+
 func opNot(ctx context.Context, parameter interface{}) (interface{}, error) {
 	if err := handleError(parameter); err != nil {
 		return nil, err
@@ -104,6 +109,66 @@ func opRegexNotEquals(a, b interface{}) (interface{}, error) {
 	return strconv.FormatBool(result == "false"), nil
 }
 
+func opGlobEquals(a, b interface{}) (interface{}, error) {
+	if err := handleError(a, b); err != nil {
+		return nil, err
+	}
+
+	// Similarly to the regex operators, we don't know which operand is the actual pattern
+	return strconv.FormatBool(globMatches(a.(string), b.(string)) || globMatches(b.(string), a.(string))), nil
+}
+
+func opGlobNotEquals(a, b interface{}) (interface{}, error) {
+	result, err := opGlobEquals(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return strconv.FormatBool(result == "false"), nil
+}
+
+func globMatches(pattern string, s string) bool {
+	re, err := glob.ToRegexPattern(pattern, false)
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(s)
+}
+
+func opLess(a, b interface{}) (interface{}, error) {
+	return compareNumbers(a, b, func(left, right float64) bool { return left < right })
+}
+
+func opLessOrEquals(a, b interface{}) (interface{}, error) {
+	return compareNumbers(a, b, func(left, right float64) bool { return left <= right })
+}
+
+func opGreater(a, b interface{}) (interface{}, error) {
+	return compareNumbers(a, b, func(left, right float64) bool { return left > right })
+}
+
+func opGreaterOrEquals(a, b interface{}) (interface{}, error) {
+	return compareNumbers(a, b, func(left, right float64) bool { return left >= right })
+}
+
+func compareNumbers(a, b interface{}, compare func(left, right float64) bool) (interface{}, error) {
+	if err := handleError(a, b); err != nil {
+		return nil, err
+	}
+
+	left, err := strconv.ParseFloat(strings.TrimSpace(a.(string)), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrNotANumber, a)
+	}
+	right, err := strconv.ParseFloat(strings.TrimSpace(b.(string)), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrNotANumber, b)
+	}
+
+	return strconv.FormatBool(compare(left, right)), nil
+}
+
 // handleError is a helper to catch and propagate errors from user-defined functions
 // (see boolevator.WithFunctions option for more details).
 func handleError(arguments ...interface{}) error {
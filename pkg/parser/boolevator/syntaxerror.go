@@ -0,0 +1,77 @@
+package boolevator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrSyntax = errors.New("syntax error")
+
+// Matches gval's parsing errors, e.g. "parsing error: $A == \t:1:7 - 1:7 unexpected EOF while scanning extensions",
+// note that the start position might be missing.
+var gvalParsingErrorRegex = regexp.MustCompile(`(?s)^parsing error: .*\t(?::(\d+):(\d+))? - (\d+):(\d+) (.*)$`)
+
+var unknownOperatorRegex = regexp.MustCompile(`^unknown operator (.*)$`)
+
+// SyntaxError points at the offending position in the expression.
+type SyntaxError struct {
+	Expression string
+	Message    string
+
+	// 1-based position of the offending token in the expression.
+	Line   int
+	Column int
+}
+
+func newSyntaxError(expression string, err error) error {
+	matches := gvalParsingErrorRegex.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+
+	syntaxError := &SyntaxError{
+		Expression: expression,
+		Message:    matches[5],
+	}
+
+	if matches[1] != "" {
+		syntaxError.Line, _ = strconv.Atoi(matches[1])
+		syntaxError.Column, _ = strconv.Atoi(matches[2])
+	} else {
+		syntaxError.Line, _ = strconv.Atoi(matches[3])
+		syntaxError.Column, _ = strconv.Atoi(matches[4])
+
+		// Only the end position is known for the unknown operators
+		if operatorMatches := unknownOperatorRegex.FindStringSubmatch(syntaxError.Message); operatorMatches != nil {
+			syntaxError.Column -= len(operatorMatches[1])
+		}
+	}
+
+	if syntaxError.Column < 1 {
+		syntaxError.Column = 1
+	}
+
+	return syntaxError
+}
+
+func (syntaxError *SyntaxError) Error() string {
+	return fmt.Sprintf("%v at %d:%d: %s\n%s", ErrSyntax, syntaxError.Line, syntaxError.Column,
+		syntaxError.Message, syntaxError.ContextLine())
+}
+
+func (syntaxError *SyntaxError) Unwrap() error {
+	return ErrSyntax
+}
+
+// ContextLine returns the offending line of the expression with the column indicator underneath.
+func (syntaxError *SyntaxError) ContextLine() string {
+	lines := strings.Split(syntaxError.Expression, "\n")
+	if syntaxError.Line < 1 || syntaxError.Line > len(lines) {
+		return ""
+	}
+
+	return lines[syntaxError.Line-1] + "\n" + strings.Repeat(" ", syntaxError.Column-1) + "^"
+}
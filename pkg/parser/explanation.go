@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/node"
+	"sort"
+	"strings"
+)
+
+// Explanation describes why a task won't run: either because it was filtered out
+// by it's only_if condition or because it was skipped by it's skip condition.
+type Explanation struct {
+	TaskName string
+	Line     int
+	Column   int
+
+	// Field is the name of the condition field: either "only_if" or "skip".
+	Field      string
+	Expression string
+
+	// Variables referenced in the expression and their values, as seen by the evaluator.
+	Variables map[string]string
+
+	Result bool
+}
+
+func (explanation *Explanation) String() string {
+	var verb string
+
+	switch explanation.Field {
+	case "only_if":
+		verb = "filtered out"
+	default:
+		verb = "skipped"
+	}
+
+	lines := []string{
+		fmt.Sprintf("task %q (line %d) was %s because %s: %s evaluated to %t", explanation.TaskName,
+			explanation.Line, verb, explanation.Field, explanation.Expression, explanation.Result),
+	}
+
+	var names []string
+	for name := range explanation.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  $%s = %q", name, explanation.Variables[name]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// explain re-evaluates the task's condition with the same environment to capture the variable values.
+func (p *Parser) explain(
+	taskName string,
+	taskNode *node.Node,
+	field string,
+	env map[string]string,
+) (*Explanation, error) {
+	conditionNode := taskNode.DeepFindCollectible(field)
+	if conditionNode == nil {
+		return nil, nil
+	}
+
+	expression, err := conditionNode.GetStringValue()
+	if err != nil {
+		return nil, err
+	}
+
+	result, variables, err := p.parserKit.Boolevator.EvalWithVariables(expression, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Explanation{
+		TaskName:   taskName,
+		Line:       taskNode.Line,
+		Column:     taskNode.Column,
+		Field:      field,
+		Expression: expression,
+		Variables:  variables,
+		Result:     result,
+	}, nil
+}
+
+func (p *Parser) addExplanation(explanation *Explanation) {
+	if explanation == nil {
+		return
+	}
+
+	p.explanations = append(p.explanations, explanation)
+}
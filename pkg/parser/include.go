@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/nameable"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/node"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
+	"path"
+	"strings"
+)
+
+var (
+	ErrInclude      = errors.New("failed to include a configuration file")
+	ErrIncludeCycle = errors.New("include cycle detected")
+)
+
+// IncludeLocator resolves an include: entry (e.g. "ci/lint.yml" or "github.com/org/repo/ci.yml@v1")
+// into a file system and a path within it, similarly to how the Starlark modules are located.
+type IncludeLocator func(
+	ctx context.Context,
+	currentFS fs.FileSystem,
+	env map[string]string,
+	include string,
+) (fs.FileSystem, string, error)
+
+// LocalIncludeLocator only supports the paths in the current file system.
+func LocalIncludeLocator(
+	ctx context.Context,
+	currentFS fs.FileSystem,
+	env map[string]string,
+	include string,
+) (fs.FileSystem, string, error) {
+	return currentFS, include, nil
+}
+
+// includeSource is the file system where the configuration file that contains an include: directive is located.
+type includeSource struct {
+	fs fs.FileSystem
+
+	// origin uniquely identifies the file system, empty for the project's file system.
+	origin string
+
+	// dir is the directory of the configuration file in the file system,
+	// against which the relative includes are resolved.
+	dir string
+}
+
+// uniquePath returns the path of the file that identifies it across all the file systems,
+// e.g. "ci/lint.yml" for the project's file system or "github.com/org/repo/ci.yml@v1:ci/lint.yml" otherwise.
+func (source includeSource) uniquePath(filePath string) string {
+	filePath = path.Clean(filePath)
+
+	if source.origin == "" {
+		return filePath
+	}
+
+	return source.origin + ":" + filePath
+}
+
+// resolveIncludes recursively merges the files specified in the top-level include: directive into the tree.
+//
+// The merging follows the same rules as the YAML merge keys[1]: a key from the included file is only
+// added if the including file doesn't define it already, unless it's a collectible or a repeatable field.
+// The included keys are placed before the including file's keys, so that the latter take precedence
+// when collecting fields like container: and env:.
+//
+// [1]: https://yaml.org/type/merge.html
+func (p *Parser) resolveIncludes(
+	ctx context.Context,
+	tree *node.Node,
+	source includeSource,
+	stack []string,
+	mergeExemptions []nameable.Nameable,
+) error {
+	includeNode := tree.FindChild("include")
+	if includeNode == nil {
+		return nil
+	}
+
+	includes, err := includeNode.GetSliceOfNonEmptyStrings()
+	if err != nil {
+		return err
+	}
+
+	// Remove the directive itself since it's not a part of the configuration
+	var children []*node.Node
+	for _, child := range tree.Children {
+		if child != includeNode {
+			children = append(children, child)
+		}
+	}
+	tree.Children = children
+
+	merged := &node.Node{Value: &node.MapValue{}}
+
+	for _, include := range includes {
+		includeFS, includePath, err := p.includeLocator(ctx, source.fs, p.environment, include)
+		if err != nil {
+			return includeNode.ParserError("%v: %s: %v", ErrInclude, include, err)
+		}
+
+		// Files with the same path, but on the different file systems are different files
+		includeSource := includeSource{fs: includeFS, origin: source.origin}
+		if includeFS != source.fs {
+			includeSource.origin = include
+		} else if !path.IsAbs(includePath) {
+			includePath = path.Join(source.dir, includePath)
+		}
+		includeSource.dir = path.Dir(includePath)
+		key := includeSource.uniquePath(includePath)
+
+		for _, seen := range stack {
+			if seen == key {
+				return includeNode.ParserError("%v: %s is included recursively", ErrIncludeCycle, include)
+			}
+		}
+
+		content, err := includeFS.Get(ctx, includePath)
+		if err != nil {
+			return includeNode.ParserError("%v: %s: %v", ErrInclude, include, err)
+		}
+		p.includedConfigs[key] = string(content)
+
+		includedTree, err := node.NewFromTextWithMergeExemptions(string(content), mergeExemptions)
+		if err != nil {
+			var re *parsererror.Rich
+			if errors.As(err, &re) {
+				return re.WithPath(key)
+			}
+
+			return includeNode.ParserError("%v: %s: %s", ErrInclude, include,
+				strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+		includedTree.SetFile(key)
+
+		if err := p.resolveIncludes(ctx, includedTree, includeSource, append(stack, key), mergeExemptions); err != nil {
+			return err
+		}
+
+		merged.MergeFromMap(includedTree, mergeExemptions)
+	}
+
+	var included []*node.Node
+
+	for _, child := range merged.Children {
+		if tree.HasChild(child.Name) && !isMergeExempt(child.Name, mergeExemptions) {
+			continue
+		}
+
+		child.Parent = tree
+		included = append(included, child)
+	}
+
+	tree.Children = append(included, tree.Children...)
+
+	return nil
+}
+
+func isMergeExempt(name string, mergeExemptions []nameable.Nameable) bool {
+	for _, mergeExemption := range mergeExemptions {
+		if mergeExemption.Matches(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enrichRichError provides the error with the contents of the file it refers to.
+func (p *Parser) enrichRichError(re *parsererror.Rich, config string) {
+	if re.Path() == "" {
+		re.Enrich(config)
+
+		return
+	}
+
+	if includedConfig, ok := p.includedConfigs[re.Path()]; ok {
+		re.Enrich(includedConfig)
+	}
+}
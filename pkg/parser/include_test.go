@@ -0,0 +1,229 @@
+package parser_test
+
+import (
+	"context"
+	"errors"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/memory"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func parseWithFiles(t *testing.T, files map[string]string, opts ...parser.Option) (*parser.Result, error) {
+	fileContents := make(map[string][]byte)
+	for path, contents := range files {
+		fileContents[path] = []byte(contents)
+	}
+
+	memoryFS, err := memory.New(fileContents)
+	require.NoError(t, err)
+
+	p := parser.New(append([]parser.Option{parser.WithFileSystem(memoryFS)}, opts...)...)
+
+	return p.Parse(context.Background(), files[".cirrus.yml"])
+}
+
+func taskNames(result *parser.Result) []string {
+	var names []string
+
+	for _, task := range result.Tasks {
+		names = append(names, task.Name)
+	}
+
+	return names
+}
+
+func TestInclude(t *testing.T) {
+	result, err := parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include:
+  - ci/frontend.yml
+  - ci/backend.yml
+
+container:
+  image: debian:latest
+
+main_task:
+  script: true
+`,
+		"ci/frontend.yml": `container:
+  image: node:latest
+
+frontend_task:
+  script: npm test
+`,
+		"ci/backend.yml": `include: common.yml
+
+backend_task:
+  script: go test ./...
+`,
+		"ci/common.yml": `common_task:
+  script: true
+`,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"frontend", "common", "backend", "main"}, taskNames(result))
+
+	// The including file takes precedence, just like with the YAML merge keys
+	for _, task := range result.Tasks {
+		assert.Contains(t, task.Instance.String(), "debian:latest")
+	}
+}
+
+// TestIncludeRelative ensures that the relative includes are resolved against the directory
+// of the including file, both in the project's and in the remote file systems.
+func TestIncludeRelative(t *testing.T) {
+	remoteFS, err := memory.New(map[string][]byte{
+		"ci/remote.yml": []byte("include: common.yml\n"),
+		"ci/common.yml": []byte("remote_common_task:\n  script: true\n"),
+		"common.yml":    []byte("remote_root_task:\n  script: true\n"),
+	})
+	require.NoError(t, err)
+
+	locator := func(ctx context.Context, currentFS fs.FileSystem, env map[string]string, include string) (fs.FileSystem, string, error) {
+		if strings.HasPrefix(include, "github.com/") {
+			return remoteFS, "ci/remote.yml", nil
+		}
+
+		return parser.LocalIncludeLocator(ctx, currentFS, env, include)
+	}
+
+	result, err := parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include:
+  - ci/backend.yml
+  - github.com/org/repo/ci/remote.yml@v1
+
+container:
+  image: debian:latest
+`,
+		"ci/backend.yml": `include: ../common/lint.yml
+`,
+		"common/lint.yml": `lint_task:
+  script: true
+`,
+	}, parser.WithIncludeLocator(locator))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"lint", "remote_common"}, taskNames(result))
+}
+
+func TestIncludeCycle(t *testing.T) {
+	_, err := parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include: a.yml
+
+container:
+  image: debian:latest
+`,
+		"a.yml": `include: b.yml
+`,
+		"b.yml": `include: ./a.yml
+`,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), parser.ErrIncludeCycle.Error())
+
+	var re *parsererror.Rich
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, "b.yml", re.Path())
+	assert.Equal(t, 1, re.Line())
+}
+
+func TestIncludeMissing(t *testing.T) {
+	_, err := parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include: missing.yml
+`,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), parser.ErrInclude.Error())
+}
+
+func TestIncludeErrorLineNumbers(t *testing.T) {
+	_, err := parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include: ci/lint.yml
+
+container:
+  image: debian:latest
+`,
+		"ci/lint.yml": `lint_task:
+  script: true
+  node_modules_cache:
+    fingerprint_script: cat package-lock.json
+`,
+	})
+	require.Error(t, err)
+
+	var re *parsererror.Rich
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, "ci/lint.yml", re.Path())
+	assert.Equal(t, 3, re.Line())
+	assert.True(t, strings.HasPrefix(re.ContextLines(), "1: lint_task:\n"))
+}
+
+func TestIncludeLocator(t *testing.T) {
+	remoteFS, err := memory.New(map[string][]byte{
+		"ci/remote.yml": []byte("remote_task:\n  script: true\n"),
+	})
+	require.NoError(t, err)
+
+	locator := func(ctx context.Context, currentFS fs.FileSystem, env map[string]string, include string) (fs.FileSystem, string, error) {
+		if strings.HasPrefix(include, "github.com/") {
+			return remoteFS, "ci/remote.yml", nil
+		}
+
+		return parser.LocalIncludeLocator(ctx, currentFS, env, include)
+	}
+
+	result, err := parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include: github.com/org/repo/ci/remote.yml@v1
+
+container:
+  image: debian:latest
+`,
+	}, parser.WithIncludeLocator(locator))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"remote"}, taskNames(result))
+}
+
+// TestIncludeSamePathOnDifferentFileSystems ensures that the errors in the files with the same path,
+// but on the different file systems are reported with the contents of the right file.
+func TestIncludeSamePathOnDifferentFileSystems(t *testing.T) {
+	remoteFS, err := memory.New(map[string][]byte{
+		"ci.yml": []byte("include: common.yml\n"),
+		"common.yml": []byte(`lint_task:
+  script: true
+  node_modules_cache:
+    fingerprint_script: cat package-lock.json
+`),
+	})
+	require.NoError(t, err)
+
+	locator := func(ctx context.Context, currentFS fs.FileSystem, env map[string]string, include string) (fs.FileSystem, string, error) {
+		if strings.HasPrefix(include, "github.com/") {
+			return remoteFS, "ci.yml", nil
+		}
+
+		return parser.LocalIncludeLocator(ctx, currentFS, env, include)
+	}
+
+	_, err = parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include:
+  - common.yml
+  - github.com/org/repo/ci.yml@v1
+`,
+		"common.yml": `container:
+  image: debian:latest
+`,
+	}, parser.WithIncludeLocator(locator))
+	require.Error(t, err)
+
+	var re *parsererror.Rich
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, "github.com/org/repo/ci.yml@v1:common.yml", re.Path())
+	assert.Equal(t, 3, re.Line())
+	assert.True(t, strings.HasPrefix(re.ContextLines(), "1: lint_task:\n"))
+}
@@ -62,7 +62,7 @@ func NewAdditionalContainer(mergedEnv map[string]string, parserKit *parserkit.Pa
 		proto: &api.AdditionalContainer{},
 	}
 
-	ac.OptionalField(nameable.NewSimpleNameable("name"), schema.String(""), func(node *node.Node) error {
+	ac.OptionalField(nameable.NewSimpleNameable("name"), schema.String("Additional container name."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -148,7 +148,7 @@ func NewAdditionalContainer(mergedEnv map[string]string, parserKit *parserkit.Pa
 		return nil
 	})
 
-	ac.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number(""), func(node *node.Node) error {
+	ac.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number("CPU units for the additional container to use."), func(node *node.Node) error {
 		cpu, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -161,7 +161,9 @@ func NewAdditionalContainer(mergedEnv map[string]string, parserKit *parserkit.Pa
 		return nil
 	})
 
-	ac.OptionalField(nameable.NewSimpleNameable("memory"), schema.Memory(), func(node *node.Node) error {
+	memorySchema := schema.Memory()
+	memorySchema.Description = "Memory in megabytes for the additional container to use."
+	ac.OptionalField(nameable.NewSimpleNameable("memory"), memorySchema, func(node *node.Node) error {
 		memory, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -59,7 +59,7 @@ func NewCommunityContainer(mergedEnv map[string]string, parserKit *parserkit.Par
 		return nil
 	})
 
-	container.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number(""), func(node *node.Node) error {
+	container.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number("CPU units for the container to use."), func(node *node.Node) error {
 		cpu, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -72,7 +72,9 @@ func NewCommunityContainer(mergedEnv map[string]string, parserKit *parserkit.Par
 		return nil
 	})
 
-	container.OptionalField(nameable.NewSimpleNameable("memory"), schema.Memory(), func(node *node.Node) error {
+	memorySchema := schema.Memory()
+	memorySchema.Description = "Memory in megabytes for the container to use."
+	container.OptionalField(nameable.NewSimpleNameable("memory"), memorySchema, func(node *node.Node) error {
 		memory, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -87,6 +89,7 @@ func NewCommunityContainer(mergedEnv map[string]string, parserKit *parserkit.Par
 
 	additionalContainersNameable := nameable.NewSimpleNameable("additional_containers")
 	acSchema := schema.ArrayOf(NewAdditionalContainer(nil, nil).Schema())
+	acSchema.Description = "Additional containers to run alongside the main container."
 	container.OptionalField(additionalContainersNameable, acSchema, func(node *node.Node) error {
 		for _, child := range node.Children {
 			ac := NewAdditionalContainer(mergedEnv, parserKit)
@@ -105,7 +108,7 @@ func NewCommunityContainer(mergedEnv map[string]string, parserKit *parserkit.Par
 	})
 
 	// no-op
-	container.OptionalField(nameable.NewSimpleNameable("registry_config"), schema.String(""), func(node *node.Node) error {
+	container.OptionalField(nameable.NewSimpleNameable("registry_config"), schema.String("Encrypted Docker registry configuration."), func(node *node.Node) error {
 		return nil
 	})
 
@@ -58,7 +58,7 @@ func NewMacOSInstance(mergedEnv map[string]string, parserKit *parserkit.ParserKi
 		return nil
 	})
 
-	instance.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number(""), func(node *node.Node) error {
+	instance.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number("Number of VM CPUs."), func(node *node.Node) error {
 		cpu, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -71,7 +71,9 @@ func NewMacOSInstance(mergedEnv map[string]string, parserKit *parserkit.ParserKi
 		return nil
 	})
 
-	instance.OptionalField(nameable.NewSimpleNameable("memory"), schema.Memory(), func(node *node.Node) error {
+	memorySchema := schema.Memory()
+	memorySchema.Description = "VM memory size in megabytes."
+	instance.OptionalField(nameable.NewSimpleNameable("memory"), memorySchema, func(node *node.Node) error {
 		memory, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -19,6 +19,7 @@ import (
 
 type ProtoInstance struct {
 	proto *dynamicpb.Message
+	desc  protoreflect.MessageDescriptor
 
 	parseable.DefaultParser
 }
@@ -31,6 +32,7 @@ func NewProtoParser(
 ) *ProtoInstance {
 	instance := &ProtoInstance{
 		proto: dynamicpb.NewMessage(desc),
+		desc:  desc,
 	}
 
 	fields := desc.Fields()
@@ -38,8 +40,7 @@ func NewProtoParser(
 		field := fields.Get(i)
 		fieldName := string(field.Name())
 
-		// Empty for now
-		fieldDescription := ""
+		fieldDescription := protoDescription(field, humanizeFieldName(fieldName)+".")
 
 		switch field.Kind() {
 		case protoreflect.MessageKind:
@@ -57,6 +58,7 @@ func NewProtoParser(
 			default:
 				messageSchema = NewProtoParser(field.Message(), nil, nil).Schema()
 			}
+			messageSchema.Description = fieldDescription
 
 			instance.OptionalField(nameable.NewSimpleNameable(fieldName), messageSchema, func(node *node.Node) error {
 				switch {
@@ -388,6 +390,33 @@ func (p *ProtoInstance) Schema() *jsschema.Schema {
 	modifiedSchema := p.DefaultParser.Schema()
 
 	modifiedSchema.Type = jsschema.PrimitiveTypes{jsschema.ObjectType}
+	modifiedSchema.Description = protoDescription(p.desc, humanizeFieldName(string(p.desc.Name()))+" definition.")
 
 	return modifiedSchema
 }
+
+// protoDescription returns the comment attached to the descriptor in its .proto file,
+// which is only available when the descriptor set was built with the source info included.
+func protoDescription(desc protoreflect.Descriptor, fallback string) string {
+	file := desc.ParentFile()
+	if file == nil {
+		return fallback
+	}
+
+	comment := strings.Join(strings.Fields(file.SourceLocations().ByDescriptor(desc).LeadingComments), " ")
+	if comment == "" {
+		return fallback
+	}
+
+	return comment
+}
+
+// humanizeFieldName converts names like "disk_size" into "Disk size".
+func humanizeFieldName(name string) string {
+	words := strings.ReplaceAll(name, "_", " ")
+	if words == "" {
+		return ""
+	}
+
+	return strings.ToUpper(words[:1]) + words[1:]
+}
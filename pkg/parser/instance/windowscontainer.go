@@ -69,7 +69,7 @@ func NewWindowsCommunityContainer(mergedEnv map[string]string, parserKit *parser
 		return nil
 	})
 
-	container.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number(""), func(node *node.Node) error {
+	container.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number("CPU units for the container to use."), func(node *node.Node) error {
 		cpu, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -82,7 +82,9 @@ func NewWindowsCommunityContainer(mergedEnv map[string]string, parserKit *parser
 		return nil
 	})
 
-	container.OptionalField(nameable.NewSimpleNameable("memory"), schema.Memory(), func(node *node.Node) error {
+	memorySchema := schema.Memory()
+	memorySchema.Description = "Memory in megabytes for the container to use."
+	container.OptionalField(nameable.NewSimpleNameable("memory"), memorySchema, func(node *node.Node) error {
 		memory, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -0,0 +1,39 @@
+package lint
+
+import (
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/issue"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/nameable"
+)
+
+var cacheNameable = nameable.NewRegexNameable("^(.*)cache$")
+
+// CacheWithoutFingerprint reports caches whose key doesn't depend on the project files,
+// which means that they will never be invalidated when the dependencies change.
+type CacheWithoutFingerprint struct{}
+
+func (rule *CacheWithoutFingerprint) ID() string {
+	return "cache-without-fingerprint"
+}
+
+func (rule *CacheWithoutFingerprint) Description() string {
+	return "cache has neither fingerprint_script nor fingerprint_key"
+}
+
+func (rule *CacheWithoutFingerprint) Check(target *Target, registry *issue.Registry) {
+	for _, taskNode := range TaskNodes(target.ExpandedTree) {
+		for _, child := range taskNode.Children {
+			if !cacheNameable.Matches(child.Name) || !child.IsMap() {
+				continue
+			}
+
+			if child.HasChild("fingerprint_script") || child.HasChild("fingerprint_key") {
+				continue
+			}
+
+			registry.RegisterIssuef(api.Issue_WARNING, child.Line, child.Column,
+				"cache %q has neither fingerprint_script: nor fingerprint_key:, so it won't be "+
+					"invalidated when the cached dependencies change", child.Name)
+		}
+	}
+}
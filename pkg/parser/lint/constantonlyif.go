@@ -0,0 +1,45 @@
+package lint
+
+import (
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/issue"
+)
+
+// ConstantOnlyIf reports only_if conditions that don't depend on the build environment,
+// and are thus either redundant or prevent the task from ever running.
+type ConstantOnlyIf struct{}
+
+func (rule *ConstantOnlyIf) ID() string {
+	return "constant-only-if"
+}
+
+func (rule *ConstantOnlyIf) Description() string {
+	return "only_if condition always evaluates to the same value"
+}
+
+func (rule *ConstantOnlyIf) Check(target *Target, registry *issue.Registry) {
+	for _, taskNode := range TaskNodes(target.Tree) {
+		// Matrix tasks are handled by the UnreachableMatrixCombination rule
+		if taskNode.DeepFindChild("matrix") != nil {
+			continue
+		}
+
+		onlyIfNode := taskNode.FindChild("only_if")
+		if onlyIfNode == nil {
+			continue
+		}
+
+		value, constant := taskNode.ConstantCondition(onlyIfNode)
+		if !constant {
+			continue
+		}
+
+		if value {
+			registry.RegisterIssuef(api.Issue_WARNING, onlyIfNode.Line, onlyIfNode.Column,
+				"only_if condition of task %q is always true and can be removed", taskNode.Name)
+		} else {
+			registry.RegisterIssuef(api.Issue_WARNING, onlyIfNode.Line, onlyIfNode.Column,
+				"only_if condition of task %q is always false, so it will never run", taskNode.Name)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package lint
+
+import (
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/issue"
+)
+
+// DependsOnSkippedTask reports dependencies on tasks that never run,
+// because their only_if or skip conditions are constant.
+type DependsOnSkippedTask struct{}
+
+func (rule *DependsOnSkippedTask) ID() string {
+	return "depends-on-skipped-task"
+}
+
+func (rule *DependsOnSkippedTask) Description() string {
+	return "depends_on references a task that is always skipped"
+}
+
+func (rule *DependsOnSkippedTask) Check(target *Target, registry *issue.Registry) {
+	taskNodes := TaskNodes(target.ExpandedTree)
+
+	// A dependency is considered always skipped only when
+	// all the tasks with such name or alias are always skipped
+	alwaysSkipped := make(map[string]bool)
+
+	for _, taskNode := range taskNodes {
+		skipped := taskNode.AlwaysSkipped()
+
+		for _, name := range []string{taskNode.Name, taskNode.Alias} {
+			if name == "" {
+				continue
+			}
+
+			if previous, ok := alwaysSkipped[name]; ok {
+				alwaysSkipped[name] = previous && skipped
+			} else {
+				alwaysSkipped[name] = skipped
+			}
+		}
+	}
+
+	for _, taskNode := range taskNodes {
+		dependsOnNode := taskNode.FindChild("depends_on")
+		if dependsOnNode == nil || taskNode.AlwaysSkipped() {
+			continue
+		}
+
+		dependsOn, err := dependsOnNode.GetSliceOfExpandedStrings(taskNode.Environment)
+		if err != nil {
+			continue
+		}
+
+		for _, dependency := range dependsOn {
+			if !alwaysSkipped[dependency] {
+				continue
+			}
+
+			registry.RegisterIssuef(api.Issue_WARNING, dependsOnNode.Line, dependsOnNode.Column,
+				"task %q depends on task %q, which is always skipped", taskNode.Name, dependency)
+		}
+	}
+}
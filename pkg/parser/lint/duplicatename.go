@@ -0,0 +1,46 @@
+package lint
+
+import (
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/issue"
+	"strings"
+)
+
+// DuplicateTaskName reports tasks that have the same name and no labels to distinguish them,
+// which makes them indistinguishable in the UI and ambiguous when referenced in depends_on.
+type DuplicateTaskName struct{}
+
+func (rule *DuplicateTaskName) ID() string {
+	return "duplicate-task-name"
+}
+
+func (rule *DuplicateTaskName) Description() string {
+	return "several tasks have the same name and no distinguishing labels"
+}
+
+func (rule *DuplicateTaskName) Check(target *Target, registry *issue.Registry) {
+	if target.Result == nil {
+		return
+	}
+
+	seen := make(map[string]struct{})
+
+	for _, task := range target.Result.Tasks {
+		key := task.Name + "\x00" + strings.Join(task.Metadata.GetUniqueLabels(), "\x00")
+
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+
+			continue
+		}
+
+		// Positions in the included files don't refer to the configuration being linted
+		var line, column int
+		if taskNode, ok := target.Result.TaskNodes[task.LocalGroupId]; ok && taskNode.File == "" {
+			line, column = taskNode.Line, taskNode.Column
+		}
+
+		registry.RegisterIssuef(api.Issue_WARNING, line, column,
+			"there's already a task named %q with the same labels", task.Name)
+	}
+}
@@ -0,0 +1,131 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/issue"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matches the "# cirrus-lint: disable=rule-a,rule-b" directive in the configuration.
+var disableDirectiveRegex = regexp.MustCompile(`(?m)^\s*#\s*cirrus-lint:\s*disable=([A-Za-z0-9_,\s-]+)$`)
+
+// Rule is a single check that inspects the configuration and registers the issues it finds.
+type Rule interface {
+	// ID uniquely identifies the rule and is used to disable it.
+	ID() string
+	Description() string
+	Check(target *Target, registry *issue.Registry)
+}
+
+// Issue is an api.Issue produced by a specific rule.
+type Issue struct {
+	*api.Issue
+	RuleID string
+}
+
+func (issue *Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", issue.Path, issue.Line, issue.Column,
+		strings.ToLower(issue.Level.String()), issue.Message, issue.RuleID)
+}
+
+type Linter struct {
+	rules         []Rule
+	disabledRules map[string]struct{}
+}
+
+// Rules returns the catalogue of all available rules.
+func Rules() []Rule {
+	return []Rule{
+		&UnusedAnchor{},
+		&CacheWithoutFingerprint{},
+		&DependsOnSkippedTask{},
+		&DuplicateTaskName{},
+		&ConstantOnlyIf{},
+		&UnreachableMatrixCombination{},
+	}
+}
+
+func New(opts ...Option) *Linter {
+	linter := &Linter{
+		rules:         Rules(),
+		disabledRules: make(map[string]struct{}),
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(linter)
+	}
+
+	return linter
+}
+
+// Lint runs the enabled rules against the YAML configuration. The parsing result is optional
+// and only needed for the rules that operate on the resulting tasks.
+func (linter *Linter) Lint(config string, result *parser.Result) ([]*Issue, error) {
+	target, err := NewTarget(config, result)
+	if err != nil {
+		return nil, err
+	}
+
+	disabledRules := make(map[string]struct{})
+	for id := range linter.disabledRules {
+		disabledRules[id] = struct{}{}
+	}
+	for _, id := range DisabledRulesFromConfig(config) {
+		disabledRules[id] = struct{}{}
+	}
+
+	var issues []*Issue
+	seen := make(map[string]struct{})
+
+	for _, rule := range linter.rules {
+		if _, ok := disabledRules[rule.ID()]; ok {
+			continue
+		}
+
+		registry := issue.NewRegistry()
+		rule.Check(target, registry)
+
+		for _, apiIssue := range registry.Issues() {
+			lintIssue := &Issue{Issue: apiIssue, RuleID: rule.ID()}
+
+			// Matrix expansion might result in the same issue being reported multiple times
+			if _, ok := seen[lintIssue.String()]; ok {
+				continue
+			}
+			seen[lintIssue.String()] = struct{}{}
+
+			issues = append(issues, lintIssue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+
+		return issues[i].Column < issues[j].Column
+	})
+
+	return issues, nil
+}
+
+// DisabledRulesFromConfig returns the IDs of the rules disabled in the configuration
+// using the "# cirrus-lint: disable=rule-a,rule-b" comments.
+func DisabledRulesFromConfig(config string) []string {
+	var result []string
+
+	for _, matches := range disableDirectiveRegex.FindAllStringSubmatch(config, -1) {
+		for _, id := range strings.Split(matches[1], ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				result = append(result, id)
+			}
+		}
+	}
+
+	return result
+}
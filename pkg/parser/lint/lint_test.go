@@ -0,0 +1,117 @@
+package lint_test
+
+import (
+	"context"
+	"github.com/cirruslabs/cirrus-cli/pkg/larker/fs/memory"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/lint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func lintFile(t *testing.T, name string, opts ...lint.Option) []string {
+	config, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	result, err := parser.New().Parse(context.Background(), string(config))
+	require.NoError(t, err)
+
+	issues, err := lint.New(opts...).Lint(string(config), result)
+	require.NoError(t, err)
+
+	var formatted []string
+	for _, issue := range issues {
+		formatted = append(formatted, issue.String())
+	}
+
+	return formatted
+}
+
+func TestRules(t *testing.T) {
+	var testCases = []struct {
+		File     string
+		Expected []string
+	}{
+		{"unused-anchor.yml", []string{
+			`.cirrus.yml:4:13: warning: anchor "unused" is never used [unused-anchor]`,
+		}},
+		{"cache-without-fingerprint.yml", []string{
+			`.cirrus.yml:5:3: warning: cache "node_modules_cache" has neither fingerprint_script: nor ` +
+				`fingerprint_key:, so it won't be invalidated when the cached dependencies change [cache-without-fingerprint]`,
+		}},
+		{"depends-on-skipped-task.yml", []string{
+			`.cirrus.yml:8:3: warning: only_if condition of task "deploy" is always false, ` +
+				`so it will never run [constant-only-if]`,
+			`.cirrus.yml:12:3: warning: task "check" depends on task "deploy", ` +
+				`which is always skipped [depends-on-skipped-task]`,
+		}},
+		{"duplicate-task-name.yml", []string{
+			`.cirrus.yml:7:1: warning: there's already a task named "test" with the same labels [duplicate-task-name]`,
+		}},
+		{"constant-only-if.yml", []string{
+			`.cirrus.yml:6:3: warning: only_if condition of task "always" is always true ` +
+				`and can be removed [constant-only-if]`,
+			`.cirrus.yml:13:3: warning: only_if condition of task "never" is always false, ` +
+				`so it will never run [constant-only-if]`,
+		}},
+		{"unreachable-matrix-combination.yml", []string{
+			`.cirrus.yml:4:1: warning: matrix combination #1 of task "test 1.14" is unreachable, ` +
+				`since its only_if condition is always false [unreachable-matrix-combination]`,
+		}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.File, func(t *testing.T) {
+			assert.Equal(t, testCase.Expected, lintFile(t, testCase.File))
+		})
+	}
+}
+
+// TestDuplicateTaskNameWithIncludes ensures that the duplicate task is reported at the right position
+// even when the included tasks precede it.
+func TestDuplicateTaskNameWithIncludes(t *testing.T) {
+	config := `include: ci/build.yml
+
+container:
+  image: debian:latest
+
+test_task:
+  script: true
+
+test_task:
+  script: true
+`
+
+	memoryFS, err := memory.New(map[string][]byte{
+		"ci/build.yml": []byte("build_task:\n  script: true\n\nlint_task:\n  script: true\n"),
+	})
+	require.NoError(t, err)
+
+	result, err := parser.New(parser.WithFileSystem(memoryFS)).Parse(context.Background(), config)
+	require.NoError(t, err)
+
+	issues, err := lint.New().Lint(config, result)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, `.cirrus.yml:9:1: warning: there's already a task named "test" with the same labels `+
+		`[duplicate-task-name]`, issues[0].String())
+}
+
+func TestDisableDirective(t *testing.T) {
+	assert.Empty(t, lintFile(t, "disable-directive.yml"))
+}
+
+func TestDisableOption(t *testing.T) {
+	assert.Empty(t, lintFile(t, "unused-anchor.yml", lint.WithDisabledRules([]string{"unused-anchor"})))
+}
+
+func TestDisabledRulesFromConfig(t *testing.T) {
+	config := "# cirrus-lint: disable=unused-anchor,constant-only-if\ntask:\n  script: true\n"
+
+	assert.Equal(t, []string{"unused-anchor", "constant-only-if"}, lint.DisabledRulesFromConfig(config))
+}
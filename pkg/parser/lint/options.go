@@ -0,0 +1,19 @@
+package lint
+
+type Option func(*Linter)
+
+// WithDisabledRules disables the rules with the specified IDs.
+func WithDisabledRules(ids []string) Option {
+	return func(linter *Linter) {
+		for _, id := range ids {
+			linter.disabledRules[id] = struct{}{}
+		}
+	}
+}
+
+// WithRules replaces the default rule catalogue.
+func WithRules(rules []Rule) Option {
+	return func(linter *Linter) {
+		linter.rules = rules
+	}
+}
@@ -0,0 +1,198 @@
+package lint
+
+import (
+	"github.com/cirruslabs/cirrus-cli/internal/executor/environment"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/boolevator"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/modifier/matrix"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/nameable"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/node"
+	"gopkg.in/yaml.v3"
+	"regexp"
+)
+
+var (
+	taskLikeNameables = []*nameable.RegexNameable{
+		nameable.NewRegexNameable("^(.*)task$"),
+		nameable.NewRegexNameable("^(.*)pipe$"),
+		nameable.NewRegexNameable("^(.*)docker_builder$"),
+	}
+
+	variableRegex     = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?|%([A-Za-z_][A-Za-z0-9_]*)%`)
+	functionCallRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*\s*\(`)
+)
+
+// Target is what the rules inspect: the configuration in its various representations.
+type Target struct {
+	Config string
+
+	// Document is the raw YAML document, which preserves the anchors and the aliases.
+	Document *yaml.Node
+
+	// Tree is the configuration as written.
+	Tree *node.Node
+
+	// ExpandedTree is the configuration with all the matrices expanded.
+	ExpandedTree *node.Node
+
+	// Result of the configuration parsing, might be nil.
+	Result *parser.Result
+}
+
+func NewTarget(config string, result *parser.Result) (*Target, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal([]byte(config), &document); err != nil {
+		return nil, err
+	}
+
+	tree, err := node.NewFromText(config)
+	if err != nil {
+		return nil, err
+	}
+
+	expandedTree := tree.DeepCopy()
+	if err := matrix.ExpandMatrices(expandedTree); err != nil {
+		return nil, err
+	}
+
+	return &Target{
+		Config:       config,
+		Document:     &document,
+		Tree:         tree,
+		ExpandedTree: expandedTree,
+		Result:       result,
+	}, nil
+}
+
+// TaskNode is a task-like (task, pipe or docker_builder) node in the configuration tree.
+type TaskNode struct {
+	*node.Node
+
+	// Name is the task's name, as seen by the other tasks' depends_on.
+	Name  string
+	Alias string
+
+	// Environment of the task, including the environment inherited from the configuration's root.
+	Environment map[string]string
+}
+
+// TaskNodes returns the task-like nodes of the tree in the same order as the parser sees them.
+func TaskNodes(tree *node.Node) []*TaskNode {
+	var result []*TaskNode
+
+	for _, child := range tree.Children {
+		for _, taskLikeNameable := range taskLikeNameables {
+			if !taskLikeNameable.Matches(child.Name) {
+				continue
+			}
+
+			taskNode := &TaskNode{
+				Node:        child,
+				Environment: taskEnvironment(child),
+			}
+
+			taskNode.Name = taskLikeNameable.FirstGroupOrDefault(child.Name, "main")
+			if nameNode := child.FindChild("name"); nameNode != nil {
+				if name, err := nameNode.GetExpandedStringValue(taskNode.Environment); err == nil {
+					taskNode.Name = name
+				}
+			}
+
+			if aliasNode := child.FindChild("alias"); aliasNode != nil {
+				if alias, err := aliasNode.GetExpandedStringValue(taskNode.Environment); err == nil {
+					taskNode.Alias = alias
+				}
+			}
+
+			result = append(result, taskNode)
+
+			break
+		}
+	}
+
+	return result
+}
+
+// ConstantCondition evaluates the condition if it only depends on the task's own environment.
+func (taskNode *TaskNode) ConstantCondition(conditionNode *node.Node) (value bool, constant bool) {
+	expression, err := conditionNode.GetStringValue()
+	if err != nil {
+		return false, false
+	}
+
+	env := environment.Merge(taskNode.Environment, map[string]string{
+		"CIRRUS_TASK_NAME": taskNode.Name,
+	})
+
+	if !isConstantExpression(expression, env) {
+		return false, false
+	}
+
+	value, err = boolevator.New().Eval(expression, env)
+	if err != nil {
+		return false, false
+	}
+
+	return value, true
+}
+
+// AlwaysSkipped returns true if the task is guaranteed to be either filtered out by it's only_if
+// condition or skipped because of it's skip condition, regardless of the build environment.
+func (taskNode *TaskNode) AlwaysSkipped() bool {
+	if onlyIf := taskNode.DeepFindCollectible("only_if"); onlyIf != nil {
+		if value, constant := taskNode.ConstantCondition(onlyIf); constant && !value {
+			return true
+		}
+	}
+
+	if skip := taskNode.DeepFindCollectible("skip"); skip != nil {
+		if value, constant := taskNode.ConstantCondition(skip); constant && value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func taskEnvironment(taskNode *node.Node) map[string]string {
+	result := make(map[string]string)
+
+	for _, name := range []string{"env", "environment"} {
+		envNode := taskNode.DeepFindCollectible(name)
+		if envNode == nil {
+			continue
+		}
+
+		env, err := envNode.GetMapOrListOfMaps()
+		if err != nil {
+			continue
+		}
+
+		result = environment.Merge(result, env)
+	}
+
+	return result
+}
+
+// isConstantExpression returns true if the expression doesn't call any functions and only
+// references the variables that are defined in env and don't reference other variables themselves.
+func isConstantExpression(expression string, env map[string]string) bool {
+	if functionCallRegex.MatchString(expression) {
+		return false
+	}
+
+	for _, matches := range variableRegex.FindAllStringSubmatch(expression, -1) {
+		name := matches[1]
+		if name == "" {
+			name = matches[2]
+		}
+
+		value, ok := env[name]
+		if !ok || variableRegex.MatchString(value) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,79 @@
+package lint
+
+import (
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/issue"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/modifier/matrix"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/node"
+)
+
+// UnreachableMatrixCombination reports matrix combinations that never run,
+// because the task's only_if condition is always false for them.
+type UnreachableMatrixCombination struct{}
+
+func (rule *UnreachableMatrixCombination) ID() string {
+	return "unreachable-matrix-combination"
+}
+
+func (rule *UnreachableMatrixCombination) Description() string {
+	return "matrix combination is never run because of the only_if condition"
+}
+
+func (rule *UnreachableMatrixCombination) Check(target *Target, registry *issue.Registry) {
+	for _, taskNode := range TaskNodes(target.Tree) {
+		if taskNode.DeepFindChild("matrix") == nil {
+			continue
+		}
+
+		// Expand the matrix of this task alone to find out which combinations it produces
+		combinations, err := expandSingleTask(target.Tree, taskNode.Node)
+		if err != nil {
+			continue
+		}
+
+		for i, combination := range combinations {
+			onlyIfNode := combination.DeepFindCollectible("only_if")
+			if onlyIfNode == nil {
+				continue
+			}
+
+			if value, constant := combination.ConstantCondition(onlyIfNode); !constant || value {
+				continue
+			}
+
+			registry.RegisterIssuef(api.Issue_WARNING, taskNode.Line, taskNode.Column,
+				"matrix combination #%d of task %q is unreachable, since its only_if condition is always false",
+				i+1, combination.Name)
+		}
+	}
+}
+
+func expandSingleTask(tree *node.Node, taskNode *node.Node) ([]*TaskNode, error) {
+	singleTaskTree := tree.DeepCopy()
+
+	// Keep the root-level fields (e.g. env) and only the task of interest
+	var children []*node.Node
+
+	for i, child := range tree.Children {
+		if child == taskNode || !isTaskLike(child) {
+			children = append(children, singleTaskTree.Children[i])
+		}
+	}
+	singleTaskTree.Children = children
+
+	if err := matrix.ExpandMatrices(singleTaskTree); err != nil {
+		return nil, err
+	}
+
+	return TaskNodes(singleTaskTree), nil
+}
+
+func isTaskLike(child *node.Node) bool {
+	for _, taskLikeNameable := range taskLikeNameables {
+		if taskLikeNameable.Matches(child.Name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"github.com/cirruslabs/cirrus-ci-agent/api"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/issue"
+	"gopkg.in/yaml.v3"
+)
+
+// UnusedAnchor reports YAML anchors that are never referenced by an alias.
+type UnusedAnchor struct{}
+
+func (rule *UnusedAnchor) ID() string {
+	return "unused-anchor"
+}
+
+func (rule *UnusedAnchor) Description() string {
+	return "YAML anchor is defined, but never referenced"
+}
+
+func (rule *UnusedAnchor) Check(target *Target, registry *issue.Registry) {
+	// Anchors can be redefined, so track the latest definition for each name
+	var definitions []*yaml.Node
+	current := make(map[string]*yaml.Node)
+	used := make(map[*yaml.Node]struct{})
+
+	var walk func(yamlNode *yaml.Node)
+	walk = func(yamlNode *yaml.Node) {
+		if yamlNode.Anchor != "" {
+			definitions = append(definitions, yamlNode)
+			current[yamlNode.Anchor] = yamlNode
+		}
+
+		if yamlNode.Kind == yaml.AliasNode {
+			if definition, ok := current[yamlNode.Value]; ok {
+				used[definition] = struct{}{}
+			}
+		}
+
+		for _, child := range yamlNode.Content {
+			walk(child)
+		}
+	}
+	walk(target.Document)
+
+	for _, definition := range definitions {
+		if _, ok := used[definition]; ok {
+			continue
+		}
+
+		registry.RegisterIssuef(api.Issue_WARNING, definition.Line, definition.Column,
+			"anchor %q is never used", definition.Anchor)
+	}
+}
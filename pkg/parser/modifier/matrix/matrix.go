@@ -17,11 +17,7 @@ func singlePass(inputTree *node.Node) error {
 	}
 
 	// Ensure this matrix node is attached to either a task or a docker_builder
-	taskNode := matrixNode.FindParent(func(nodeName string) bool {
-		return strings.HasSuffix(nodeName, "task") ||
-			strings.HasSuffix(nodeName, "docker_builder") ||
-			strings.HasSuffix(nodeName, "pipe")
-	})
+	taskNode := matrixNode.FindParent(isTaskNode)
 	if taskNode == nil {
 		return matrixNode.ParserError("matrix can be defined only under a task, docker_builder or pipe")
 	}
@@ -50,7 +46,30 @@ func singlePass(inputTree *node.Node) error {
 	return nil
 }
 
+func isTaskNode(nodeName string) bool {
+	return strings.HasSuffix(nodeName, "task") ||
+		strings.HasSuffix(nodeName, "docker_builder") ||
+		strings.HasSuffix(nodeName, "pipe")
+}
+
 func ExpandMatrices(tree *node.Node) error {
+	// Tasks with exclude: and include: entries need to be expanded separately,
+	// otherwise we won't be able to tell which combinations belong to them
+	tasksWithModifiers, err := extractModifiers(tree)
+	if err != nil {
+		return err
+	}
+
+	for _, modifiers := range tasksWithModifiers {
+		if err := expandWithModifiers(modifiers); err != nil {
+			return err
+		}
+	}
+
+	return expandAll(tree)
+}
+
+func expandAll(tree *node.Node) error {
 	for {
 		if err := singlePass(tree); err != nil {
 			if errors.Is(err, errNoExpansionDone) {
@@ -63,12 +63,21 @@ var goodCases = []string{
 	"parallel.yaml",
 	"one-sized-matrix.yaml",
 	"expansion-order.yaml",
+	// Exclusions and inclusions
+	"exclude.yaml",
+	"exclude-list-item.yaml",
+	"include.yaml",
+	"include-extends.yaml",
+	"include-duplicate-fields.yaml",
 }
 
 var badCases = []string{
 	"bad-matrix-without-collection.yaml",
 	"bad-matrix-with-list-of-scalars.yaml",
 	"bad-only-task-and-docker-builder-expand.yaml",
+	"bad-exclude-matches-nothing.yaml",
+	"bad-exclude-not-a-list.yaml",
+	"bad-modifier-mixed-with-variant.yaml",
 }
 
 func runPreprocessor(input string, expand bool) (string, error) {
@@ -0,0 +1,377 @@
+package matrix
+
+import (
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/node"
+	"strings"
+)
+
+const (
+	excludeModifier = "exclude"
+	includeModifier = "include"
+)
+
+// taskModifiers holds the exclude: and include: entries collected from all the matrices of a single task.
+type taskModifiers struct {
+	task    *node.Node
+	exclude []*node.Node
+	include []*node.Node
+}
+
+// extractModifiers removes the exclude: and include: entries from the matrices
+// and groups them by the task they belong to.
+func extractModifiers(tree *node.Node) ([]*taskModifiers, error) {
+	var result []*taskModifiers
+
+	byTask := map[*node.Node]*taskModifiers{}
+
+	for _, matrixNode := range findMatrices(tree) {
+		modifierNodes, err := popModifiers(matrixNode)
+		if err != nil {
+			return nil, err
+		}
+		if len(modifierNodes) == 0 {
+			continue
+		}
+
+		taskNode := matrixNode.FindParent(isTaskNode)
+		if taskNode == nil {
+			return nil, matrixNode.ParserError("matrix can be defined only under a task, docker_builder or pipe")
+		}
+
+		modifiers, ok := byTask[taskNode]
+		if !ok {
+			modifiers = &taskModifiers{task: taskNode}
+			byTask[taskNode] = modifiers
+			result = append(result, modifiers)
+		}
+
+		for _, modifierNode := range modifierNodes {
+			if _, ok := modifierNode.Value.(*node.ListValue); !ok {
+				return nil, modifierNode.ParserError("matrix %s: should contain a list of maps", modifierNode.Name)
+			}
+
+			for _, entry := range modifierNode.Children {
+				if _, ok := entry.Value.(*node.MapValue); !ok {
+					return nil, entry.ParserError("matrix %s: should contain a list of maps", modifierNode.Name)
+				}
+			}
+
+			if modifierNode.Name == excludeModifier {
+				modifiers.exclude = append(modifiers.exclude, modifierNode.Children...)
+			} else {
+				modifiers.include = append(modifiers.include, modifierNode.Children...)
+			}
+		}
+
+		// A matrix that only consisted of modifiers has nothing left to expand
+		if len(matrixNode.Children) == 0 {
+			matrixNode.ReplaceWith(nil)
+		}
+	}
+
+	return result, nil
+}
+
+func findMatrices(tree *node.Node) []*node.Node {
+	var result []*node.Node
+
+	for _, child := range tree.Children {
+		if child.Name == "matrix" {
+			result = append(result, child)
+		}
+
+		result = append(result, findMatrices(child)...)
+	}
+
+	return result
+}
+
+// popModifiers removes the exclude: and include: entries from the matrix node and returns them.
+//
+// For a map-based matrix these are the keys of the map, and for a list-based matrix
+// these are the keys of a list item that contains nothing else.
+func popModifiers(matrixNode *node.Node) ([]*node.Node, error) {
+	var modifiers, rest []*node.Node
+
+	switch matrixNode.Value.(type) {
+	case *node.MapValue:
+		for _, child := range matrixNode.Children {
+			if isModifier(child) {
+				modifiers = append(modifiers, child)
+			} else {
+				rest = append(rest, child)
+			}
+		}
+	case *node.ListValue:
+		for _, item := range matrixNode.Children {
+			var itemModifiers int
+
+			for _, child := range item.Children {
+				if isModifier(child) {
+					itemModifiers++
+				}
+			}
+
+			switch itemModifiers {
+			case 0:
+				rest = append(rest, item)
+			case len(item.Children):
+				modifiers = append(modifiers, item.Children...)
+			default:
+				return nil, item.ParserError("matrix exclude: and include: should be specified " +
+					"in a separate list item")
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	matrixNode.Children = rest
+
+	return modifiers, nil
+}
+
+func isModifier(child *node.Node) bool {
+	return child.Name == excludeModifier || child.Name == includeModifier
+}
+
+// expandWithModifiers expands the task's matrices separately from the rest of the tree,
+// removes the combinations matching the exclude: entries and applies the include: entries.
+//
+// Similarly to GitHub Actions, an include: entry is merged into every combination whose fields set
+// by the matrices it doesn't overwrite. When there are no such combinations, the entry is merged
+// into the task with all the matrices removed and added as a new combination instead.
+func expandWithModifiers(modifiers *taskModifiers) error {
+	taskNode := modifiers.task
+
+	// Tasks added by the include: entries start from the task without any matrices
+	baseTask := taskNode.DeepCopy()
+	removeMatrices(baseTask)
+
+	matrixFields := map[string]bool{}
+	collectMatrixFields(taskNode, nil, false, matrixFields)
+
+	virtualRoot := &node.Node{Value: &node.MapValue{}}
+	virtualRoot.Children = []*node.Node{taskNode.CopyWithParent(virtualRoot)}
+
+	if err := expandAll(virtualRoot); err != nil {
+		return err
+	}
+
+	var combinations []*node.Node
+
+	excludeMatched := make([]bool, len(modifiers.exclude))
+
+	for _, combination := range virtualRoot.Children {
+		var excluded bool
+
+		for i, excludeEntry := range modifiers.exclude {
+			if matches(excludeEntry, []*node.Node{combination}) {
+				excludeMatched[i] = true
+				excluded = true
+			}
+		}
+
+		if !excluded {
+			combinations = append(combinations, combination)
+		}
+	}
+
+	for i, excludeEntry := range modifiers.exclude {
+		if !excludeMatched[i] {
+			return excludeEntry.ParserError("matrix exclusion doesn't match any of the task's combinations")
+		}
+	}
+
+	var included []*node.Node
+
+	for _, includeEntry := range modifiers.include {
+		var extended bool
+
+		for _, combination := range combinations {
+			if overwritesMatrixFields(includeEntry, []*node.Node{combination}, nil, matrixFields) {
+				continue
+			}
+
+			mergeInclude(combination, includeEntry)
+			extended = true
+		}
+
+		if !extended {
+			includedTask := baseTask.DeepCopy()
+			mergeInclude(includedTask, includeEntry)
+			included = append(included, includedTask)
+		}
+	}
+
+	taskNode.ReplaceWith(append(combinations, included...))
+
+	return nil
+}
+
+func mergeInclude(task *node.Node, includeEntry *node.Node) {
+	// Merge into the last of the same-named fields, since that's the one that takes effect
+	existingChildren := map[string]*node.Node{}
+
+	for _, child := range task.Children {
+		existingChildren[child.Name] = child
+	}
+
+	for _, child := range includeEntry.Children {
+		if existingChild, ok := existingChildren[child.Name]; ok {
+			existingChild.MergeFrom(child)
+		} else {
+			task.Children = append(task.Children, child.CopyWithParent(task))
+		}
+	}
+}
+
+// collectMatrixFields records the paths of the fields (relative to the task) that are set by the matrices.
+func collectMatrixFields(tree *node.Node, path []string, inMatrix bool, result map[string]bool) {
+	for _, child := range tree.Children {
+		switch {
+		case child.Name == "matrix":
+			if _, ok := child.Value.(*node.ListValue); ok {
+				for _, item := range child.Children {
+					collectMatrixFields(item, path, true, result)
+				}
+			} else {
+				collectMatrixFields(child, path, true, result)
+			}
+		case child.IsMap():
+			collectMatrixFields(child, appendPath(path, child.Name), inMatrix, result)
+		case inMatrix:
+			result[strings.Join(appendPath(path, child.Name), "/")] = true
+		}
+	}
+}
+
+// overwritesMatrixFields returns true if the pattern changes the value of any of the fields set by the matrices
+// in the candidates. See matches() for why there can be multiple candidates.
+func overwritesMatrixFields(
+	pattern *node.Node,
+	candidates []*node.Node,
+	path []string,
+	matrixFields map[string]bool,
+) bool {
+	for _, patternChild := range pattern.Children {
+		childPath := appendPath(path, patternChild.Name)
+
+		var childCandidates []*node.Node
+
+		for _, candidate := range candidates {
+			if !candidate.IsMap() {
+				continue
+			}
+
+			for _, candidateChild := range candidate.Children {
+				if candidateChild.Name == patternChild.Name {
+					childCandidates = append(childCandidates, candidateChild)
+				}
+			}
+		}
+
+		// Fields missing from the combination are simply added
+		if len(childCandidates) == 0 {
+			continue
+		}
+
+		if patternChild.IsMap() {
+			if overwritesMatrixFields(patternChild, childCandidates, childPath, matrixFields) {
+				return true
+			}
+
+			continue
+		}
+
+		if matrixFields[strings.Join(childPath, "/")] && !matches(patternChild, childCandidates) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendPath(path []string, name string) []string {
+	return append(path[:len(path):len(path)], name)
+}
+
+func removeMatrices(tree *node.Node) {
+	var children []*node.Node
+
+	for _, child := range tree.Children {
+		if child.Name == "matrix" {
+			continue
+		}
+
+		// Drop the fields that were only populated by the matrices (e.g. "container: matrix: ...")
+		if len(child.Children) != 0 {
+			removeMatrices(child)
+
+			if len(child.Children) == 0 {
+				continue
+			}
+		}
+
+		children = append(children, child)
+	}
+
+	tree.Children = children
+}
+
+// matches returns true if every field in the pattern is found in one of the candidates with the same value.
+//
+// Multiple candidates are needed because the expanded task can contain several fields with the same name
+// (e.g. env: defined both in the task and in the matrix), which are merged later by the parser.
+func matches(pattern *node.Node, candidates []*node.Node) bool {
+	switch patternValue := pattern.Value.(type) {
+	case *node.ScalarValue:
+		for _, candidate := range candidates {
+			if candidateValue, ok := candidate.Value.(*node.ScalarValue); ok && candidateValue.Value == patternValue.Value {
+				return true
+			}
+		}
+
+		return false
+	case *node.MapValue:
+		for _, patternChild := range pattern.Children {
+			var childCandidates []*node.Node
+
+			for _, candidate := range candidates {
+				if _, ok := candidate.Value.(*node.MapValue); !ok {
+					continue
+				}
+
+				for _, candidateChild := range candidate.Children {
+					if candidateChild.Name == patternChild.Name {
+						childCandidates = append(childCandidates, candidateChild)
+					}
+				}
+			}
+
+			if !matches(patternChild, childCandidates) {
+				return false
+			}
+		}
+
+		return true
+	case *node.ListValue:
+		var itemCandidates []*node.Node
+
+		for _, candidate := range candidates {
+			if _, ok := candidate.Value.(*node.ListValue); ok {
+				itemCandidates = append(itemCandidates, candidate.Children...)
+			}
+		}
+
+		for _, patternItem := range pattern.Children {
+			if !matches(patternItem, itemCandidates) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return false
+	}
+}
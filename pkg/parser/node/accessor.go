@@ -2,6 +2,7 @@ package node
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/cirruslabs/cirrus-cli/internal/executor/environment"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/boolevator"
@@ -20,14 +21,20 @@ func (node *Node) GetStringValue() (string, error) {
 	return valueNode.Value, nil
 }
 
-func (node *Node) GetBoolValue(env map[string]string, boolevator *boolevator.Boolevator) (bool, error) {
+func (node *Node) GetBoolValue(env map[string]string, evaluator *boolevator.Boolevator) (bool, error) {
 	expression, err := node.GetStringValue()
 	if err != nil {
 		return false, err
 	}
 
-	evaluation, err := boolevator.Eval(expression, env)
+	evaluation, err := evaluator.Eval(expression, env)
 	if err != nil {
+		var syntaxError *boolevator.SyntaxError
+		if errors.As(err, &syntaxError) {
+			return false, node.ParserError("%s at column %d of the expression %q", syntaxError.Message,
+				syntaxError.Column, expression)
+		}
+
 		return false, err
 	}
 
@@ -14,12 +14,20 @@ type Node struct {
 
 	Line   int
 	Column int
+
+	// File is the path of the included file that this node originates from,
+	// empty for the nodes from the main configuration file.
+	File string
 }
 
 type MapValue struct{}
 type ListValue struct{}
 type ScalarValue struct {
 	Value string
+
+	// Null is set for the YAML null values (e.g. an empty value or "~"),
+	// to tell them apart from the empty strings.
+	Null bool
 }
 
 func (node *Node) ValueTypeAsString() string {
@@ -80,6 +88,7 @@ func (node *Node) CopyWithParent(parent *Node) *Node {
 		Parent: parent,
 		Line:   node.Line,
 		Column: node.Column,
+		File:   node.File,
 	}
 
 	for _, child := range node.Children {
@@ -146,6 +155,7 @@ func (node *Node) MergeFrom(other *Node) {
 	node.Name = other.Name
 	node.Line = other.Line
 	node.Column = other.Column
+	node.File = other.File
 
 	// Special treatment for environment variables since they can also be represented as a list of maps
 	if node.Name == "env" || node.Name == "environment" {
@@ -194,3 +204,12 @@ func (node *Node) MergeFrom(other *Node) {
 		node.Value = other.Value
 	}
 }
+
+// SetFile recursively marks the node and it's descendants as originating from the specified file.
+func (node *Node) SetFile(file string) {
+	node.File = file
+
+	for _, child := range node.Children {
+		child.SetFile(file)
+	}
+}
@@ -150,7 +150,7 @@ func convert(
 			result.Children = append(result.Children, mapSubtree)
 		}
 	case yaml.ScalarNode:
-		result.Value = &ScalarValue{Value: yamlNode.Value}
+		result.Value = &ScalarValue{Value: yamlNode.Value, Null: yamlNode.Tag == "!!null"}
 	case yaml.AliasNode:
 		// YAML aliases generally don't need line and column helper values
 		// since they are merged into some other data structure afterwards
@@ -20,6 +20,14 @@ func WithFileSystem(fs fs.FileSystem) Option {
 	}
 }
 
+// WithIncludeLocator enables the non-local files (e.g. "github.com/org/repo/ci.yml@v1")
+// in the include: directive, see LocalIncludeLocator for the default behavior.
+func WithIncludeLocator(locator IncludeLocator) Option {
+	return func(parser *Parser) {
+		parser.includeLocator = locator
+	}
+}
+
 func WithAffectedFiles(affectedFiles []string) Option {
 	return func(parser *Parser) {
 		parser.affectedFiles = affectedFiles
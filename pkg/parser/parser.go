@@ -17,6 +17,7 @@ import (
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/parseable"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/parserkit"
+	parserschema "github.com/cirruslabs/cirrus-cli/pkg/parser/schema"
 	"github.com/cirruslabs/cirrus-cli/pkg/parser/task"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/lestrrat-go/jsschema"
@@ -57,8 +58,15 @@ type Parser struct {
 	additionalTaskProperties []*descriptor.FieldDescriptorProto
 	missingInstancesAllowed  bool
 
+	// Locates the files referenced in the include: directive and
+	// keeps their contents to enrich the errors that refer to them.
+	includeLocator  IncludeLocator
+	includedConfigs map[string]string
+
 	tasksCountBeforeFiltering   int64
 	disabledTaskNamesAndAliases map[string]struct{}
+	explanations                []*Explanation
+	taskNodes                   map[int64]*node.Node
 }
 
 type Result struct {
@@ -68,13 +76,24 @@ type Result struct {
 	// A helper field that lets some external post-processor
 	// to inject new tasks correctly (e.g. Dockerfile build tasks)
 	TasksCountBeforeFiltering int64
+
+	// Explanations for the tasks that were either filtered out
+	// by their only_if conditions or skipped by their skip conditions
+	Explanations []*Explanation
+
+	// Nodes that the tasks were parsed from (with the includes, templates and matrices resolved),
+	// keyed by the task's LocalGroupId. Service tasks have no nodes.
+	TaskNodes map[int64]*node.Node
 }
 
 func New(opts ...Option) *Parser {
 	parser := &Parser{
 		environment:                 make(map[string]string),
 		fs:                          dummy.New(),
+		includeLocator:              LocalIncludeLocator,
+		includedConfigs:             make(map[string]string),
 		disabledTaskNamesAndAliases: make(map[string]struct{}),
+		taskNodes:                   make(map[int64]*node.Node),
 	}
 
 	// Apply options
@@ -94,6 +113,7 @@ func New(opts ...Option) *Parser {
 		Boolevator: boolevator.New(boolevator.WithFunctions(map[string]boolevator.Function{
 			"changesInclude":     parser.bfuncChangesInclude(),
 			"changesIncludeOnly": parser.bfuncChangesIncludeOnly(),
+			"changesIncludeAny":  parser.bfuncChangesIncludeAny(),
 		})),
 		IssueRegistry: issue.NewRegistry(),
 	}
@@ -150,6 +170,7 @@ func (p *Parser) parseTasks(tree *node.Node) ([]task.ParseableTaskLike, error) {
 			}
 
 			taskLike.SetID(p.NextTaskID())
+			p.taskNodes[taskLike.ID()] = treeItem
 
 			// Set task's name if not set in the definition
 			if rn, ok := key.(*nameable.RegexNameable); ok {
@@ -167,17 +188,38 @@ func (p *Parser) parseTasks(tree *node.Node) ([]task.ParseableTaskLike, error) {
 
 			p.tasksCountBeforeFiltering++
 
-			enabled, err := taskLike.Enabled(environment.Merge(taskSpecificEnv, p.environment), p.parserKit.Boolevator)
+			onlyIfEnv := environment.Merge(taskSpecificEnv, p.environment)
+
+			enabled, err := taskLike.Enabled(onlyIfEnv, p.parserKit.Boolevator)
 			if err != nil {
 				return nil, err
 			}
 
+			protoTask := taskLike.Proto().(*api.Task)
+
 			if !enabled {
 				p.disabledTaskNamesAndAliases[taskLike.Name()] = struct{}{}
 				p.disabledTaskNamesAndAliases[taskLike.Alias()] = struct{}{}
+
+				explanation, err := p.explain(taskLike.Name(), treeItem, "only_if",
+					environment.Merge(protoTask.Environment, onlyIfEnv))
+				if err != nil {
+					return nil, err
+				}
+				p.addExplanation(explanation)
+
 				continue
 			}
 
+			if protoTask.Status == api.Status_SKIPPED {
+				explanation, err := p.explain(taskLike.Name(), treeItem, "skip",
+					environment.Merge(protoTask.Environment, p.environment))
+				if err != nil {
+					return nil, err
+				}
+				p.addExplanation(explanation)
+			}
+
 			taskLike.SetIndexWithinBuild(p.NextTaskLocalIndex())
 
 			tasks = append(tasks, taskLike)
@@ -191,7 +233,7 @@ func (p *Parser) parseTasks(tree *node.Node) ([]task.ParseableTaskLike, error) {
 func (p *Parser) Parse(ctx context.Context, config string) (result *Result, err error) {
 	defer func() {
 		if re, ok := err.(*parsererror.Rich); ok {
-			re.Enrich(config)
+			p.enrichRichError(re, config)
 		}
 	}()
 
@@ -232,6 +274,9 @@ func (p *Parser) Parse(ctx context.Context, config string) (result *Result, err
 		}
 	}
 
+	// Templates from the included files are merged with the including file's templates
+	mergeExemptions = append(mergeExemptions, nameable.NewSimpleNameable(templatesField))
+
 	// Convert the parsed and nested YAML structure into a tree
 	// to get the ability to walk parents
 	tree, err := node.NewFromTextWithMergeExemptions(config, mergeExemptions)
@@ -239,6 +284,16 @@ func (p *Parser) Parse(ctx context.Context, config string) (result *Result, err
 		return nil, err
 	}
 
+	// Merge the included configuration files
+	if err := p.resolveIncludes(ctx, tree, includeSource{fs: p.fs}, nil, mergeExemptions); err != nil {
+		return nil, err
+	}
+
+	// Merge the templates into the tasks that extend them
+	if err := p.resolveTemplates(tree, mergeExemptions); err != nil {
+		return nil, err
+	}
+
 	// Run modifiers on it
 	if err := matrix.ExpandMatrices(tree); err != nil {
 		return nil, err
@@ -255,7 +310,10 @@ func (p *Parser) Parse(ctx context.Context, config string) (result *Result, err
 	}
 
 	if len(tasks) == 0 {
-		return &Result{Issues: p.parserKit.IssueRegistry.Issues()}, nil
+		return &Result{
+			Issues:       p.parserKit.IssueRegistry.Issues(),
+			Explanations: p.explanations,
+		}, nil
 	}
 
 	if err := validateDependenciesDeep(tasks); err != nil {
@@ -315,6 +373,8 @@ func (p *Parser) Parse(ctx context.Context, config string) (result *Result, err
 		Tasks:                     protoTasks,
 		TasksCountBeforeFiltering: p.tasksCountBeforeFiltering,
 		Issues:                    p.parserKit.IssueRegistry.Issues(),
+		Explanations:              p.explanations,
+		TaskNodes:                 p.taskNodes,
 	}, nil
 }
 
@@ -353,11 +413,14 @@ func (p *Parser) Schema() *schema.Schema {
 	}
 
 	for parserName, parser := range p.parsers {
+		parserSchema := parser.Schema()
+		parserSchema.Properties[extendsField] = parserschema.Extends()
+
 		switch nameable := parserName.(type) {
 		case *nameable.SimpleNameable:
-			schema.Properties[nameable.Name()] = parser.Schema()
+			schema.Properties[nameable.Name()] = parserSchema
 		case *nameable.RegexNameable:
-			schema.PatternProperties[nameable.Regex()] = parser.Schema()
+			schema.PatternProperties[nameable.Regex()] = parserSchema
 		}
 
 		// Note: this is a simplification that doesn't return collectible fields recursively,
@@ -367,6 +430,10 @@ func (p *Parser) Schema() *schema.Schema {
 		}
 	}
 
+	schema.Properties["include"] = parserschema.StringOrListOfStrings("Configuration files to include, " +
+		"either paths in the repository or remote files like github.com/org/repo/ci.yml@v1.")
+	schema.Properties[templatesField] = parserschema.Templates()
+
 	return schema
 }
 
@@ -544,6 +544,54 @@ func TestSchema(t *testing.T) {
 	}
 }
 
+// TestSchemaDescriptions ensures that every field in the schema is documented,
+// including the fields of the additional instances.
+func TestSchemaDescriptions(t *testing.T) {
+	p := parser.New(parser.WithAdditionalInstances(map[string]protoreflect.MessageDescriptor{
+		"proto_container": (&api.ContainerInstance{}).ProtoReflect().Descriptor(),
+	}))
+
+	schemaBytes, err := json.Marshal(p.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schemaObject map[string]interface{}
+	if err := json.Unmarshal(schemaBytes, &schemaObject); err != nil {
+		t.Fatal(err)
+	}
+
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			for _, keyword := range []string{"properties", "patternProperties"} {
+				fields, _ := typedValue[keyword].(map[string]interface{})
+				for fieldName, field := range fields {
+					fieldObject, _ := field.(map[string]interface{})
+					assert.NotEmpty(t, fieldObject["description"], "%s/%s/%s has no description",
+						path, keyword, fieldName)
+				}
+			}
+			for key, child := range typedValue {
+				walk(path+"/"+key, child)
+			}
+		case []interface{}:
+			for i, child := range typedValue {
+				walk(fmt.Sprintf("%s/%d", path, i), child)
+			}
+		}
+	}
+	walk("", schemaObject)
+
+	// Enumerations like instance platforms are exported too
+	platformSchema := schemaObject
+	for _, key := range []string{"patternProperties", "^(.*)task$", "properties", "proto_container",
+		"properties", "platform"} {
+		platformSchema = platformSchema[key].(map[string]interface{})
+	}
+	assert.Equal(t, []interface{}{"linux", "windows", "darwin"}, platformSchema["enum"])
+}
+
 func TestTasksCountBeforeFiltering(t *testing.T) {
 	p := parser.New()
 	result, err := p.ParseFromFile(context.Background(), "testdata/tasks-count-before-filtering.yml")
@@ -554,6 +602,40 @@ func TestTasksCountBeforeFiltering(t *testing.T) {
 	assert.EqualValues(t, 2, result.TasksCountBeforeFiltering)
 }
 
+func TestExplanations(t *testing.T) {
+	p := parser.New(parser.WithEnvironment(map[string]string{
+		"CIRRUS_BRANCH": "feature",
+	}))
+	result, err := p.ParseFromFile(context.Background(), "testdata/explanations.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*parser.Explanation{
+		{
+			TaskName:   "deploy",
+			Line:       7,
+			Column:     1,
+			Field:      "only_if",
+			Expression: "$CIRRUS_BRANCH == $DEPLOY_BRANCH",
+			Variables: map[string]string{
+				"CIRRUS_BRANCH": "feature",
+				"DEPLOY_BRANCH": "main",
+			},
+			Result: false,
+		},
+		{
+			TaskName:   "docs",
+			Line:       11,
+			Column:     1,
+			Field:      "skip",
+			Expression: "!changesInclude('docs/**')",
+			Variables:  map[string]string{},
+			Result:     true,
+		},
+	}, result.Explanations)
+}
+
 func TestRichErrors(t *testing.T) {
 	testCases := []struct {
 		File  string
@@ -565,6 +647,8 @@ func TestRichErrors(t *testing.T) {
 			"expected a scalar value or a list with scalar values")},
 		{"testdata/rich-errors-matrix.yml", parsererror.NewRich(3, 5,
 			"matrix can be defined only under a task, docker_builder or pipe")},
+		{"testdata/rich-errors-matrix-exclude.yml", parsererror.NewRich(7, 11,
+			"matrix exclusion doesn't match any of the task's combinations")},
 	}
 
 	for _, testCase := range testCases {
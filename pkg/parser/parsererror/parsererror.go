@@ -13,6 +13,7 @@ var (
 
 type Rich struct {
 	config  string
+	path    string
 	message string
 	line    int
 	column  int
@@ -26,11 +27,23 @@ func NewRich(line, column int, format string, args ...interface{}) *Rich {
 	}
 }
 
+// WithPath associates the error with an included configuration file,
+// an empty path means that the error is in the main configuration file.
+func (rich *Rich) WithPath(path string) *Rich {
+	rich.path = path
+
+	return rich
+}
+
 func (rich *Rich) Enrich(config string) {
 	rich.config = config
 }
 
 func (rich *Rich) Error() string {
+	if rich.path != "" {
+		return fmt.Sprintf("parsing error: %s:%d:%d: %s", rich.path, rich.line, rich.column, rich.message)
+	}
+
 	return fmt.Sprintf("parsing error: %d:%d: %s", rich.line, rich.column, rich.message)
 }
 
@@ -38,6 +51,10 @@ func (rich *Rich) Config() string {
 	return rich.config
 }
 
+func (rich *Rich) Path() string {
+	return rich.path
+}
+
 func (rich *Rich) Message() string {
 	return rich.message
 }
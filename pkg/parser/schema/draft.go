@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+// Draft is a JSON Schema specification version that the schema can be exported in.
+type Draft string
+
+const (
+	Draft4      Draft = "4"
+	Draft7      Draft = "7"
+	Draft202012 Draft = "2020-12"
+)
+
+var ErrUnsupportedDraft = errors.New("unsupported JSON Schema draft")
+
+var draftURIs = map[Draft]string{
+	Draft4:      "http://json-schema.org/draft-04/schema#",
+	Draft7:      "http://json-schema.org/draft-07/schema#",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// Drafts returns the supported JSON Schema drafts.
+func Drafts() []Draft {
+	return []Draft{Draft4, Draft7, Draft202012}
+}
+
+// Convert turns the draft-04 schema generated by the parser into a generic JSON object
+// that conforms to the requested draft.
+func Convert(s *schema.Schema, draft Draft) (map[string]interface{}, error) {
+	uri, ok := draftURIs[draft]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedDraft, draft)
+	}
+
+	schemaBytes, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(schemaBytes, &result); err != nil {
+		return nil, err
+	}
+
+	if draft != Draft4 {
+		convertSubschema(result, draft)
+	}
+
+	result["$schema"] = uri
+
+	return result, nil
+}
+
+func convertSubschema(subschema map[string]interface{}, draft Draft) {
+	// Draft 6 renamed "id" to "$id"
+	renameKeyword(subschema, "id", "$id")
+
+	// Draft 6 turned the boolean "exclusiveMinimum" and "exclusiveMaximum" into numbers
+	convertExclusive(subschema, "exclusiveMinimum", "minimum")
+	convertExclusive(subschema, "exclusiveMaximum", "maximum")
+
+	for _, keyword := range []string{"properties", "patternProperties", "definitions"} {
+		if subschemas, ok := subschema[keyword].(map[string]interface{}); ok {
+			for _, value := range subschemas {
+				convertValue(value, draft)
+			}
+		}
+	}
+
+	for _, keyword := range []string{"items", "additionalItems", "additionalProperties", "not",
+		"allOf", "anyOf", "oneOf"} {
+		convertValue(subschema[keyword], draft)
+	}
+
+	if draft == Draft202012 {
+		// Draft 2020-12 split the tuple form of "items" into "prefixItems"
+		// and repurposed "items" as a replacement for "additionalItems"
+		if _, ok := subschema["items"].([]interface{}); ok {
+			renameKeyword(subschema, "items", "prefixItems")
+			renameKeyword(subschema, "additionalItems", "items")
+		} else {
+			delete(subschema, "additionalItems")
+		}
+
+		renameKeyword(subschema, "definitions", "$defs")
+	}
+}
+
+func convertValue(value interface{}, draft Draft) {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		convertSubschema(typedValue, draft)
+	case []interface{}:
+		for _, item := range typedValue {
+			convertValue(item, draft)
+		}
+	}
+}
+
+func renameKeyword(subschema map[string]interface{}, from string, to string) {
+	value, ok := subschema[from]
+	if !ok {
+		return
+	}
+
+	delete(subschema, from)
+	subschema[to] = value
+}
+
+func convertExclusive(subschema map[string]interface{}, exclusiveKeyword string, limitKeyword string) {
+	exclusive, ok := subschema[exclusiveKeyword].(bool)
+	if !ok {
+		return
+	}
+
+	if limit, ok := subschema[limitKeyword]; ok && exclusive {
+		subschema[exclusiveKeyword] = limit
+		delete(subschema, limitKeyword)
+	} else {
+		delete(subschema, exclusiveKeyword)
+	}
+}
@@ -16,6 +16,7 @@ func Map(description string) *schema.Schema {
 		PatternProperties: map[*regexp.Regexp]*schema.Schema{
 			regexp.MustCompile(".*"): {
 				Type:                 schema.PrimitiveTypes{schema.StringType},
+				Description:          "Map value.",
 				AdditionalProperties: &schema.AdditionalProperties{Schema: nil},
 			},
 		},
@@ -159,3 +160,51 @@ func ArrayOf(arrayItemSchema *schema.Schema) *schema.Schema {
 		AdditionalItems: &schema.AdditionalItems{Schema: nil},
 	}
 }
+
+func Templates() *schema.Schema {
+	// No type is enforced, since this field was traditionally used to hold arbitrary YAML anchors
+	return &schema.Schema{
+		Description: "Reusable task definitions that tasks can inherit the fields from with extends:.",
+		PatternProperties: map[*regexp.Regexp]*schema.Schema{
+			regexp.MustCompile(".*"): {
+				Type: schema.PrimitiveTypes{schema.ObjectType},
+				Description: "Task template. Its parameters: are referenced as ${{ name }} " +
+					"and are required unless they have a default value.",
+				Properties: map[string]*schema.Schema{
+					"parameters": Map("Template parameters along with their default values."),
+				},
+				AdditionalProperties: &schema.AdditionalProperties{Schema: nil},
+			},
+		},
+		AdditionalProperties: &schema.AdditionalProperties{Schema: nil},
+	}
+}
+
+func Extends() *schema.Schema {
+	templateReference := &schema.Schema{
+		Type:        schema.PrimitiveTypes{schema.ObjectType},
+		Description: "Template name along with the values of its parameters.",
+		Properties: map[string]*schema.Schema{
+			"template":   String("Template name."),
+			"parameters": Map("Values of the template parameters."),
+		},
+		Required:             []string{"template"},
+		AdditionalProperties: &schema.AdditionalProperties{Schema: nil},
+	}
+
+	return &schema.Schema{
+		Description: "Templates to inherit the fields from, later templates take precedence.",
+		AnyOf: schema.SchemaList{
+			String("Template name."),
+			templateReference,
+			ArrayOf(&schema.Schema{
+				AnyOf: schema.SchemaList{
+					String("Template name."),
+					templateReference,
+				},
+			}),
+		},
+		AdditionalItems:      &schema.AdditionalItems{Schema: nil},
+		AdditionalProperties: &schema.AdditionalProperties{Schema: nil},
+	}
+}
@@ -53,7 +53,7 @@ func AttachBaseTaskFields(
 		task.Metadata.Properties["auto_cancellation"] = strconv.FormatBool(autoCancellation)
 	}
 
-	parser.OptionalField(nameable.NewSimpleNameable("name"), schema.String(""), func(node *node.Node) error {
+	parser.OptionalField(nameable.NewSimpleNameable("name"), schema.String("Task name."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(environment.Merge(task.Environment, env))
 		if err != nil {
 			return err
@@ -140,7 +140,7 @@ func AttachBaseTaskInstructions(
 	parserKit *parserkit.ParserKit,
 ) {
 	bgNameable := nameable.NewRegexNameable("^(.*)background_script$")
-	parser.OptionalField(bgNameable, schema.Script(""), func(node *node.Node) error {
+	parser.OptionalField(bgNameable, schema.Script("Script to run in the background."), func(node *node.Node) error {
 		command, err := handleBackgroundScript(node, bgNameable)
 		if err != nil {
 			return err
@@ -152,7 +152,7 @@ func AttachBaseTaskInstructions(
 	})
 
 	scriptNameable := nameable.NewRegexNameable("^(.*)script$")
-	parser.OptionalField(scriptNameable, schema.Script(""), func(node *node.Node) error {
+	parser.OptionalField(scriptNameable, schema.Script("Script to run."), func(node *node.Node) error {
 		command, err := handleScript(node, scriptNameable)
 		if err != nil {
 			return err
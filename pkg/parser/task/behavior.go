@@ -25,7 +25,7 @@ func NewBehavior(
 	b := &Behavior{}
 
 	bgNameable := nameable.NewRegexNameable("^(.*)background_script$")
-	b.OptionalField(bgNameable, schema.Script(""), func(node *node.Node) error {
+	b.OptionalField(bgNameable, schema.Script("Script to run in the background."), func(node *node.Node) error {
 		command, err := handleBackgroundScript(node, bgNameable)
 		if err != nil {
 			return err
@@ -37,7 +37,7 @@ func NewBehavior(
 	})
 
 	scriptNameable := nameable.NewRegexNameable("^(.*)script$")
-	b.OptionalField(scriptNameable, schema.Script(""), func(node *node.Node) error {
+	b.OptionalField(scriptNameable, schema.Script("Script to run."), func(node *node.Node) error {
 		command, err := handleScript(node, scriptNameable)
 		if err != nil {
 			return err
@@ -23,7 +23,7 @@ func NewArtifactsCommand(mergedEnv map[string]string) *ArtifactsCommand {
 		instruction: &api.ArtifactsInstruction{},
 	}
 
-	articom.OptionalField(nameable.NewSimpleNameable("name"), schema.String(""), func(node *node.Node) error {
+	articom.OptionalField(nameable.NewSimpleNameable("name"), schema.String("Artifacts name."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -33,6 +33,7 @@ func NewArtifactsCommand(mergedEnv map[string]string) *ArtifactsCommand {
 	})
 
 	pathsSchema := schema.ArrayOf(schema.String("Path or pattern of artifacts."))
+	pathsSchema.Description = "Paths or patterns of artifacts."
 	articom.OptionalField(nameable.NewSimpleNameable("paths"), pathsSchema, func(node *node.Node) error {
 		artifactPaths, err := node.GetSliceOfExpandedStrings(mergedEnv)
 		if err != nil {
@@ -100,6 +101,7 @@ func (articom *ArtifactsCommand) Schema() *jsschema.Schema {
 	modifiedSchema := articom.DefaultParser.Schema()
 
 	modifiedSchema.Type = jsschema.PrimitiveTypes{jsschema.ObjectType}
+	modifiedSchema.Description = "Artifacts Definition."
 
 	return modifiedSchema
 }
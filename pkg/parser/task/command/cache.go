@@ -31,7 +31,7 @@ func NewCacheCommand(mergedEnv map[string]string, parserKit *parserkit.ParserKit
 		},
 	}
 
-	cache.OptionalField(nameable.NewSimpleNameable("name"), schema.String(""), func(node *node.Node) error {
+	cache.OptionalField(nameable.NewSimpleNameable("name"), schema.String("Cache name."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -24,7 +24,7 @@ func NewFileCommand(mergedEnv map[string]string) *FileCommand {
 		instruction: &api.FileInstruction{},
 	}
 
-	fileCommand.OptionalField(nameable.NewSimpleNameable("name"), schema.String(""), func(node *node.Node) error {
+	fileCommand.OptionalField(nameable.NewSimpleNameable("name"), schema.String("File instruction name."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -87,6 +87,7 @@ func (fileCommand *FileCommand) Schema() *jsschema.Schema {
 	modifiedSchema := fileCommand.DefaultParser.Schema()
 
 	modifiedSchema.Type = jsschema.PrimitiveTypes{jsschema.ObjectType}
+	modifiedSchema.Description = "File Definition."
 
 	return modifiedSchema
 }
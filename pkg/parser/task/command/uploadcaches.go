@@ -63,5 +63,8 @@ func UploadCachesHelper(
 }
 
 func UploadCachesSchema() *jsschema.Schema {
-	return schema.ArrayOf(schema.String("Cache name to upload."))
+	uploadCachesSchema := schema.ArrayOf(schema.String("Cache name to upload."))
+	uploadCachesSchema.Description = "Caches to upload at this point of the task."
+
+	return uploadCachesSchema
 }
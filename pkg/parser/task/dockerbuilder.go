@@ -44,7 +44,7 @@ func NewDockerBuilder(
 	AttachBaseTaskFields(&dbuilder.DefaultParser, &dbuilder.proto, env, parserKit, additionalTaskProperties)
 	AttachBaseTaskInstructions(&dbuilder.DefaultParser, &dbuilder.proto, env, parserKit)
 
-	dbuilder.OptionalField(nameable.NewSimpleNameable("alias"), schema.String(""), func(node *node.Node) error {
+	dbuilder.OptionalField(nameable.NewSimpleNameable("alias"), schema.String("Task alias that can be referenced in depends_on."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(environment.Merge(dbuilder.proto.Environment, env))
 		if err != nil {
 			return err
@@ -195,6 +195,7 @@ func (dbuilder *DockerBuilder) Schema() *jsschema.Schema {
 	modifiedSchema := dbuilder.DefaultParser.Schema()
 
 	modifiedSchema.Type = jsschema.PrimitiveTypes{jsschema.ObjectType}
+	modifiedSchema.Description = "Docker builder VM definition."
 
 	return modifiedSchema
 }
@@ -73,7 +73,7 @@ func NewDockerPipe(
 		return nil
 	})
 
-	pipe.OptionalField(nameable.NewSimpleNameable("name"), schema.String(""), func(node *node.Node) error {
+	pipe.OptionalField(nameable.NewSimpleNameable("name"), schema.String("Task name."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(environment.Merge(pipe.proto.Environment, env))
 		if err != nil {
 			return err
@@ -81,7 +81,7 @@ func NewDockerPipe(
 		pipe.proto.Name = name
 		return nil
 	})
-	pipe.OptionalField(nameable.NewSimpleNameable("alias"), schema.String(""), func(node *node.Node) error {
+	pipe.OptionalField(nameable.NewSimpleNameable("alias"), schema.String("Task alias that can be referenced in depends_on."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(environment.Merge(pipe.proto.Environment, env))
 		if err != nil {
 			return err
@@ -107,6 +107,7 @@ func NewDockerPipe(
 	})
 
 	stepsSchema := schema.ArrayOf(NewPipeStep(nil, nil, nil).Schema())
+	stepsSchema.Description = "Steps to run sequentially in the pipe."
 	pipe.RequiredField(nameable.NewSimpleNameable("steps"), stepsSchema, func(stepsNode *node.Node) error {
 		if _, ok := stepsNode.Value.(*node.ListValue); !ok {
 			return stepsNode.ParserError("steps should be a list")
@@ -247,6 +248,7 @@ func (pipe *DockerPipe) Schema() *jsschema.Schema {
 	modifiedSchema := pipe.DefaultParser.Schema()
 
 	modifiedSchema.Type = jsschema.PrimitiveTypes{jsschema.ObjectType}
+	modifiedSchema.Description = "Docker pipe definition."
 
 	return modifiedSchema
 }
@@ -21,7 +21,7 @@ type PipeResources struct {
 func NewPipeResources(mergedEnv map[string]string) *PipeResources {
 	res := &PipeResources{}
 
-	res.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number(""), func(node *node.Node) error {
+	res.OptionalField(nameable.NewSimpleNameable("cpu"), schema.Number("CPU units for each pipe step to use."), func(node *node.Node) error {
 		cpu, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -34,7 +34,9 @@ func NewPipeResources(mergedEnv map[string]string) *PipeResources {
 		return nil
 	})
 
-	res.OptionalField(nameable.NewSimpleNameable("memory"), schema.Memory(), func(node *node.Node) error {
+	memorySchema := schema.Memory()
+	memorySchema.Description = "Memory in megabytes for each pipe step to use."
+	res.OptionalField(nameable.NewSimpleNameable("memory"), memorySchema, func(node *node.Node) error {
 		memory, err := node.GetExpandedStringValue(mergedEnv)
 		if err != nil {
 			return err
@@ -38,7 +38,7 @@ func NewPipeStep(
 	})
 
 	scriptNameable := nameable.NewRegexNameable("^(.*)script$")
-	step.OptionalField(scriptNameable, schema.Script(""), func(node *node.Node) error {
+	step.OptionalField(scriptNameable, schema.Script("Script to run."), func(node *node.Node) error {
 		command, err := handleScript(node, scriptNameable)
 		if err != nil {
 			return err
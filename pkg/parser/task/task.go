@@ -218,7 +218,7 @@ func NewTask(
 	AttachBaseTaskFields(&task.DefaultParser, &task.proto, env, parserKit, additionalTaskProperties)
 	AttachBaseTaskInstructions(&task.DefaultParser, &task.proto, env, parserKit)
 
-	task.OptionalField(nameable.NewSimpleNameable("alias"), schema.String(""), func(node *node.Node) error {
+	task.OptionalField(nameable.NewSimpleNameable("alias"), schema.String("Task alias that can be referenced in depends_on."), func(node *node.Node) error {
 		name, err := node.GetExpandedStringValue(environment.Merge(task.proto.Environment, env))
 		if err != nil {
 			return err
@@ -0,0 +1,385 @@
+package parser
+
+import (
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/nameable"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/node"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	templatesField  = "templates"
+	extendsField    = "extends"
+	parametersField = "parameters"
+)
+
+// parameterReference matches the template parameter references like ${{ version }}.
+var parameterReference = regexp.MustCompile(`\$\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// templateReference is a single entry of the extends: field.
+type templateReference struct {
+	node       *node.Node
+	name       string
+	parameters map[string]*node.Node
+}
+
+// resolveTemplates removes the top-level templates: field and merges the templates
+// referenced by the tasks' extends: field into these tasks.
+//
+// The merging follows the same rules as the include: directive: a field from the template is only added
+// if the task doesn't define it already, unless it's a collectible or a repeatable field, which are
+// merged by the parser later. The template's fields are placed before the task's fields.
+func (p *Parser) resolveTemplates(tree *node.Node, mergeExemptions []nameable.Nameable) error {
+	templates := map[string]*node.Node{}
+
+	var children []*node.Node
+
+	for _, child := range tree.Children {
+		// Historically, the templates: field was commonly used to hold the YAML anchors,
+		// so the fields that don't look like the templates section are left as is
+		if _, ok := child.Value.(*node.MapValue); child.Name != templatesField || !ok {
+			children = append(children, child)
+
+			continue
+		}
+
+		// Later definitions (e.g. from the including file) take precedence
+		for _, template := range child.Children {
+			templates[template.Name] = template
+		}
+	}
+
+	tree.Children = children
+
+	for _, child := range tree.Children {
+		var isTaskLike bool
+
+		for key := range p.parsers {
+			if key.Matches(child.Name) {
+				isTaskLike = true
+
+				break
+			}
+		}
+
+		if !isTaskLike {
+			continue
+		}
+
+		if err := extend(child, templates, nil, mergeExemptions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extend merges the templates referenced by the node's extends: field into the node.
+func extend(
+	target *node.Node,
+	templates map[string]*node.Node,
+	stack []string,
+	mergeExemptions []nameable.Nameable,
+) error {
+	extendsNode := target.FindChild(extendsField)
+	if extendsNode == nil {
+		return nil
+	}
+
+	references, err := parseTemplateReferences(extendsNode)
+	if err != nil {
+		return err
+	}
+
+	// Remove the field itself since it's not a part of the task
+	var children []*node.Node
+	for _, child := range target.Children {
+		if child != extendsNode {
+			children = append(children, child)
+		}
+	}
+	target.Children = children
+
+	merged := &node.Node{Value: &node.MapValue{}}
+
+	for _, reference := range references {
+		template, ok := templates[reference.name]
+		if !ok {
+			return reference.node.ParserError("template %q is not defined", reference.name)
+		}
+
+		if _, ok := template.Value.(*node.MapValue); !ok {
+			return reference.node.ParserError("template %q should be a map", reference.name)
+		}
+
+		for _, seen := range stack {
+			if seen == reference.name {
+				return reference.node.ParserError("template %q is extended recursively", reference.name)
+			}
+		}
+
+		instance, err := instantiateTemplate(template, reference)
+		if err != nil {
+			return err
+		}
+
+		if err := extend(instance, templates, append(stack, reference.name), mergeExemptions); err != nil {
+			return err
+		}
+
+		// Templates listed later take precedence
+		for _, child := range instance.Children {
+			child.Parent = merged
+
+			if isMergeExempt(child.Name, mergeExemptions) || !replaceChild(merged, child) {
+				merged.Children = append(merged.Children, child)
+			}
+		}
+	}
+
+	var inherited []*node.Node
+
+	for _, child := range merged.Children {
+		if target.HasChild(child.Name) && !isMergeExempt(child.Name, mergeExemptions) {
+			continue
+		}
+
+		child.Parent = target
+		inherited = append(inherited, child)
+	}
+
+	target.Children = append(inherited, target.Children...)
+
+	return nil
+}
+
+// replaceChild replaces the similarly named child of the tree, if any.
+func replaceChild(tree *node.Node, child *node.Node) bool {
+	for i, existing := range tree.Children {
+		if existing.Name == child.Name {
+			tree.Children[i] = child
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTemplateReferences supports a template name, a map with the template name and its parameters
+// and a list of both.
+func parseTemplateReferences(extendsNode *node.Node) ([]*templateReference, error) {
+	switch extendsNode.Value.(type) {
+	case *node.ListValue:
+		var result []*templateReference
+
+		for _, child := range extendsNode.Children {
+			reference, err := parseTemplateReference(child)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, reference)
+		}
+
+		return result, nil
+	default:
+		reference, err := parseTemplateReference(extendsNode)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*templateReference{reference}, nil
+	}
+}
+
+func parseTemplateReference(referenceNode *node.Node) (*templateReference, error) {
+	reference := &templateReference{
+		node:       referenceNode,
+		parameters: map[string]*node.Node{},
+	}
+
+	switch referenceNode.Value.(type) {
+	case *node.ScalarValue:
+		name, err := referenceNode.GetStringValue()
+		if err != nil {
+			return nil, err
+		}
+
+		reference.name = name
+	case *node.MapValue:
+		templateNode := referenceNode.FindChild("template")
+		if templateNode == nil {
+			return nil, referenceNode.ParserError("template name should be specified in the template: field")
+		}
+
+		name, err := templateNode.GetStringValue()
+		if err != nil {
+			return nil, err
+		}
+		reference.name = name
+
+		if parametersNode := referenceNode.FindChild(parametersField); parametersNode != nil {
+			if _, ok := parametersNode.Value.(*node.MapValue); !ok {
+				return nil, parametersNode.ParserError("template parameters should be a map")
+			}
+
+			for _, parameter := range parametersNode.Children {
+				if _, ok := parameter.Value.(*node.ScalarValue); !ok {
+					return nil, parameter.ParserError("template parameter %q should be a scalar value",
+						parameter.Name)
+				}
+
+				reference.parameters[parameter.Name] = parameter
+			}
+		}
+	default:
+		return nil, referenceNode.ParserError("extends should be a template name, a map or a list")
+	}
+
+	return reference, nil
+}
+
+// instantiateTemplate returns a copy of the template with the parameter references substituted.
+//
+// The copy retains the template's locations, so that the errors are reported against the template.
+func instantiateTemplate(template *node.Node, reference *templateReference) (*node.Node, error) {
+	values := map[string]string{}
+
+	instance := template.DeepCopy()
+
+	var children []*node.Node
+
+	for _, child := range instance.Children {
+		if child.Name != parametersField {
+			children = append(children, child)
+
+			continue
+		}
+
+		if _, ok := child.Value.(*node.MapValue); !ok {
+			return nil, child.ParserError("template parameters should be a map")
+		}
+
+		for _, parameter := range child.Children {
+			scalar, ok := parameter.Value.(*node.ScalarValue)
+			if !ok {
+				return nil, parameter.ParserError("template parameter %q should be a scalar value", parameter.Name)
+			}
+
+			// Parameters without a default value are required
+			if scalar.Null {
+				continue
+			}
+
+			values[parameter.Name] = scalar.Value
+		}
+	}
+	instance.Children = children
+
+	declared := declaredParameters(template)
+
+	var unknown []string
+
+	for name, parameter := range reference.parameters {
+		if _, ok := declared[name]; !ok {
+			unknown = append(unknown, name)
+
+			continue
+		}
+
+		values[name] = parameter.Value.(*node.ScalarValue).Value
+	}
+
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+
+		return nil, reference.parameters[unknown[0]].ParserError("template %q has no parameter %q",
+			reference.name, unknown[0])
+	}
+
+	var missing []string
+
+	for name := range declared {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) != 0 {
+		sort.Strings(missing)
+
+		return nil, reference.node.ParserError("template %q requires the %s parameter(s) to be set",
+			reference.name, strings.Join(missing, ", "))
+	}
+
+	if err := substituteParameters(instance, reference.name, values); err != nil {
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+func declaredParameters(template *node.Node) map[string]struct{} {
+	result := map[string]struct{}{}
+
+	for _, child := range template.Children {
+		if child.Name != parametersField {
+			continue
+		}
+
+		for _, parameter := range child.Children {
+			result[parameter.Name] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+func substituteParameters(tree *node.Node, templateName string, values map[string]string) error {
+	substitute := func(s string) (string, error) {
+		var unknown string
+
+		result := parameterReference.ReplaceAllStringFunc(s, func(reference string) string {
+			name := parameterReference.FindStringSubmatch(reference)[1]
+
+			value, ok := values[name]
+			if !ok && unknown == "" {
+				unknown = name
+			}
+
+			return value
+		})
+
+		if unknown != "" {
+			return "", tree.ParserError("template %q has no parameter %q", templateName, unknown)
+		}
+
+		return result, nil
+	}
+
+	name, err := substitute(tree.Name)
+	if err != nil {
+		return err
+	}
+	tree.Name = name
+
+	if scalar, ok := tree.Value.(*node.ScalarValue); ok {
+		value, err := substitute(scalar.Value)
+		if err != nil {
+			return err
+		}
+
+		// Scalar values are shared between the copies of the node
+		tree.Value = &node.ScalarValue{Value: value}
+	}
+
+	for _, child := range tree.Children {
+		if err := substituteParameters(child, templateName, values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
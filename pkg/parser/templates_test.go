@@ -0,0 +1,168 @@
+package parser_test
+
+import (
+	"context"
+	"errors"
+	"github.com/cirruslabs/cirrus-cli/internal/testutil"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser"
+	"github.com/cirruslabs/cirrus-cli/pkg/parser/parsererror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// TestTemplates ensures that the configuration with templates is equivalent to the one written without them.
+func TestTemplates(t *testing.T) {
+	withTemplates, err := parser.New().ParseFromFile(context.Background(), absolutize("templates.yml"))
+	require.NoError(t, err)
+
+	withoutTemplates, err := parser.New().ParseFromFile(context.Background(), absolutize("templates-expanded.yml"))
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(testutil.TasksToJSON(t, withoutTemplates.Tasks)),
+		string(testutil.TasksToJSON(t, withTemplates.Tasks)))
+}
+
+func TestTemplatesFromIncludedFiles(t *testing.T) {
+	result, err := parseWithFiles(t, map[string]string{
+		".cirrus.yml": `include: ci/templates.yml
+
+templates:
+  local:
+    container:
+      image: debian:latest
+
+local_task:
+  extends: local
+  script: true
+
+remote_task:
+  extends: remote
+  script: true
+`,
+		"ci/templates.yml": `templates:
+  remote:
+    container:
+      image: alpine:latest
+`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local", "remote"}, taskNames(result))
+}
+
+func TestTemplateErrors(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Config string
+		Line   int
+		Column int
+		Error  string
+	}{
+		{
+			Name: "undefined template",
+			Config: `task:
+  extends: missing
+  script: true
+`,
+			Line:   2,
+			Column: 3,
+			Error:  "template \"missing\" is not defined",
+		},
+		{
+			Name: "recursion",
+			Config: `templates:
+  a:
+    extends: b
+  b:
+    extends: a
+
+task:
+  extends: a
+  script: true
+`,
+			Line:   5,
+			Column: 5,
+			Error:  "template \"a\" is extended recursively",
+		},
+		{
+			Name: "missing parameter",
+			Config: `templates:
+  go:
+    parameters:
+      version:
+    container:
+      image: golang:${{ version }}
+
+task:
+  extends: go
+  script: true
+`,
+			Line:   9,
+			Column: 3,
+			Error:  "template \"go\" requires the version parameter(s) to be set",
+		},
+		{
+			Name: "unknown parameter",
+			Config: `templates:
+  go:
+    container:
+      image: golang:latest
+
+task:
+  extends:
+    template: go
+    parameters:
+      version: 1.19
+  script: true
+`,
+			Line:   10,
+			Column: 7,
+			Error:  "template \"go\" has no parameter \"version\"",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			_, err := parser.New().Parse(context.Background(), testCase.Config)
+
+			var re *parsererror.Rich
+			require.True(t, errors.As(err, &re), err)
+			assert.Equal(t, testCase.Error, re.Message())
+			assert.Equal(t, testCase.Line, re.Line())
+			assert.Equal(t, testCase.Column, re.Column())
+		})
+	}
+}
+
+// TestTemplateErrorLocation ensures that the errors in the template's fields
+// are reported against the template and not against the task that extends it.
+func TestTemplateErrorLocation(t *testing.T) {
+	config, err := parser.New().ParseFromFile(context.Background(), absolutize("templates-errors.yml"))
+	require.Nil(t, config)
+
+	var re *parsererror.Rich
+	require.True(t, errors.As(err, &re), err)
+	assert.Equal(t, "template \"go\" has no parameter \"packages\"", re.Message())
+	assert.Equal(t, 7, re.Line())
+	assert.Equal(t, 5, re.Column())
+}
+
+// TestTemplateEmptyDefault ensures that an explicit empty string is a valid default value of a parameter.
+func TestTemplateEmptyDefault(t *testing.T) {
+	result, err := parser.New().Parse(context.Background(), `templates:
+  lint:
+    parameters:
+      suffix: ""
+    name: lint${{ suffix }}
+    container:
+      image: golangci/golangci-lint:latest
+
+task:
+  extends: lint
+  script: golangci-lint run
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lint"}, taskNames(result))
+}